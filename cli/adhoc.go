@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// getAdHocPattern returns the pattern of --system or of the file or URL given with --pattern, nil for an installed
+// pattern. Ad-hoc patterns are read on every run, so a prompt can be edited without installing it.
+func getAdHocPattern(currentFlags *Flags, registry *core.PluginRegistry) (ret *fsdb.Pattern, err error) {
+	switch {
+	case currentFlags.System != "":
+		if currentFlags.Pattern != "" {
+			err = fmt.Errorf("--system is used instead of a pattern, don't combine it with --pattern")
+			return
+		}
+		ret = &fsdb.Pattern{Name: "system", Pattern: currentFlags.System}
+	case fsdb.IsPatternURL(currentFlags.Pattern):
+		ret, err = registry.PatternsLoader.GetRemote(currentFlags.Pattern)
+	case fsdb.IsPatternFile(currentFlags.Pattern):
+		if ret, err = registry.Db.Patterns.GetFromFile(currentFlags.Pattern); err != nil {
+			err = fmt.Errorf("could not read pattern file %s: %v", currentFlags.Pattern, err)
+		}
+	}
+	return
+}
+
+// setAdHocPattern lets the request use the ad-hoc pattern instead of an installed one
+func setAdHocPattern(chatReq *common.ChatRequest, pattern *fsdb.Pattern) {
+	if pattern == nil {
+		return
+	}
+	chatReq.PatternName = ""
+	chatReq.SystemPrompt = pattern.Pattern
+	chatReq.UserPrompt = pattern.User
+}
+
+// patternKey returns the name of the pattern for file names, ad-hoc patterns are named by the hash of their
+// reference or prompt
+func patternKey(currentFlags *Flags) string {
+	if currentFlags.System != "" {
+		return fmt.Sprintf("system-%x", sha256.Sum256([]byte(currentFlags.System)))[:23]
+	}
+	if fsdb.IsPatternURL(currentFlags.Pattern) || fsdb.IsPatternFile(currentFlags.Pattern) {
+		return fmt.Sprintf("adhoc-%x", sha256.Sum256([]byte(currentFlags.Pattern)))[:22]
+	}
+	return currentFlags.Pattern
+}
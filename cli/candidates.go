@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+const mergeMarker = "#### candidate"
+
+// getCandidates returns the number of answers to pick from: the chosen one, or with --pattern-candidates the one the
+// pattern asks for if there is a terminal to pick in. Dry runs send a single request.
+func getCandidates(currentFlags *Flags, fabricDb *fsdb.Db) (ret int) {
+	if currentFlags.DryRun {
+		return 1
+	}
+	if ret = currentFlags.Candidates; ret > 0 || currentFlags.Pattern == "" || !currentFlags.PatternCandidates {
+		return
+	}
+
+	metadata, err := fabricDb.Patterns.GetMetadata(currentFlags.Pattern)
+	if err != nil || metadata.Candidates <= 1 {
+		return
+	}
+	if terminal, terminalErr := openTerminal(); terminalErr == nil {
+		if terminal != os.Stdin {
+			terminal.Close()
+		}
+		ret = metadata.Candidates
+		fmt.Fprintf(os.Stderr, "pattern %s asks for %d answers to pick from, they are not streamed\n",
+			currentFlags.Pattern, ret)
+	}
+	return
+}
+
+// pickCandidate shows the candidates in the pager and lets the user pick one, edit one or merge them in the editor.
+// The questions go to the terminal, so that the output can be redirected.
+func pickCandidate(candidates []string) (ret string, err error) {
+	if len(candidates) == 1 {
+		ret = candidates[0]
+		return
+	}
+
+	var terminal *os.File
+	if terminal, err = openTerminal(); err != nil {
+		return
+	}
+	if terminal != os.Stdin {
+		defer terminal.Close()
+	}
+	answers := bufio.NewReader(terminal)
+
+	if err = showInPager(formatCandidates(candidates), terminal); err != nil {
+		return
+	}
+
+	for {
+		fmt.Fprintf(terminal, "Pick 1-%d, e<n> to edit one, m to merge them, v to view them again or q to quit: ",
+			len(candidates))
+		line, readErr := answers.ReadString('\n')
+		if readErr != nil && (!errors.Is(readErr, io.EOF) || line == "") {
+			err = fmt.Errorf("no candidate picked")
+			return
+		}
+
+		line = strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case line == "q":
+			err = fmt.Errorf("no candidate picked")
+			return
+		case line == "v":
+			if err = showInPager(formatCandidates(candidates), terminal); err != nil {
+				return
+			}
+		case line == "m":
+			if ret, err = editText(buildMergeText(candidates), terminal); err == nil {
+				ret = removeMergeMarkers(ret)
+			}
+			return
+		case strings.HasPrefix(line, "e"):
+			if index, ok := candidateIndex(strings.TrimPrefix(line, "e"), len(candidates)); ok {
+				ret, err = editText(candidates[index], terminal)
+				return
+			}
+		default:
+			if index, ok := candidateIndex(line, len(candidates)); ok {
+				ret = candidates[index]
+				return
+			}
+		}
+	}
+}
+
+func candidateIndex(value string, count int) (ret int, ok bool) {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if ok = err == nil && number >= 1 && number <= count; ok {
+		ret = number - 1
+	}
+	return
+}
+
+func formatCandidates(candidates []string) string {
+	var ret strings.Builder
+	for i, candidate := range candidates {
+		ret.WriteString(fmt.Sprintf("==================== %d/%d ====================\n\n", i+1, len(candidates)))
+		ret.WriteString(strings.TrimSpace(candidate))
+		ret.WriteString("\n\n")
+	}
+	return ret.String()
+}
+
+// buildMergeText puts all candidates into one text, the user keeps the parts they want
+func buildMergeText(candidates []string) string {
+	var ret strings.Builder
+	ret.WriteString(mergeMarker + "s: keep and combine the parts you want, the lines starting with " + mergeMarker +
+		" are removed\n\n")
+	for i, candidate := range candidates {
+		ret.WriteString(fmt.Sprintf("%s %d\n\n%s\n\n", mergeMarker, i+1, strings.TrimSpace(candidate)))
+	}
+	return ret.String()
+}
+
+func removeMergeMarkers(text string) string {
+	var lines []string
+	afterMarker := false
+	for _, line := range strings.Split(text, "\n") {
+		// the empty line after a marker is removed with it
+		if strings.HasPrefix(line, mergeMarker) || afterMarker && strings.TrimSpace(line) == "" {
+			afterMarker = strings.HasPrefix(line, mergeMarker)
+			continue
+		}
+		afterMarker = false
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// showInPager shows the text with $PAGER, less by default, and prints it if there is no pager
+func showInPager(text string, terminal *os.File) (err error) {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+	if _, lookErr := exec.LookPath(pager[0]); lookErr != nil {
+		_, err = fmt.Fprint(terminal, text)
+		return
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = terminal
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	return
+}
+
+// editText opens the text in $VISUAL or $EDITOR, vi by default, and returns the saved text
+func editText(text string, terminal *os.File) (ret string, err error) {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	var file *os.File
+	if file, err = os.CreateTemp("", "fabric-candidate-*.md"); err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.WriteString(text); err != nil {
+		file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin = terminal
+	cmd.Stdout = terminal
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("editor %s failed: %v", editor[0], err)
+		return
+	}
+
+	var content []byte
+	if content, err = os.ReadFile(file.Name()); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(string(content))
+	return
+}
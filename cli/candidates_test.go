@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCandidates(t *testing.T) {
+	text := buildMergeText([]string{"first tweet\n", "second tweet"})
+	assert.Contains(t, text, "first tweet")
+	assert.Contains(t, text, "second tweet")
+	assert.Equal(t, "first tweet\n\nsecond tweet", removeMergeMarkers(text))
+}
+
+func TestCandidateIndex(t *testing.T) {
+	index, ok := candidateIndex("2", 3)
+	assert.True(t, ok)
+	assert.Equal(t, 1, index)
+
+	_, ok = candidateIndex("4", 3)
+	assert.False(t, ok)
+	_, ok = candidateIndex("x", 3)
+	assert.False(t, ok)
+}
+
+func TestGetCandidates(t *testing.T) {
+	dir := t.TempDir()
+	fabricDb := fsdb.NewDb(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "patterns", "tweet"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "patterns", "tweet", "pattern.json"), []byte(`{"candidates": 3}`), 0644))
+
+	// the number of the pattern is only used on request
+	assert.Equal(t, 0, getCandidates(&Flags{Pattern: "tweet"}, fabricDb))
+	assert.Equal(t, 2, getCandidates(&Flags{Pattern: "tweet", Candidates: 2}, fabricDb))
+	assert.Equal(t, 1, getCandidates(&Flags{Pattern: "tweet", Candidates: 2, DryRun: true}, fabricDb))
+}
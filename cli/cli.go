@@ -5,16 +5,23 @@ import (
 	"github.com/danielmiessler/fabric/core"
 	"github.com/danielmiessler/fabric/plugins/ai"
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/chatlog"
 	"github.com/danielmiessler/fabric/plugins/tools/converter"
+	"github.com/danielmiessler/fabric/plugins/tools/forge"
+	"github.com/danielmiessler/fabric/plugins/tools/jina"
 	"github.com/danielmiessler/fabric/restapi"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Cli Controls the cli. It takes in the flags and runs the appropriate functions
 func Cli(version string) (err error) {
+	startTime := time.Now()
+
 	var currentFlags *Flags
 	if currentFlags, err = Init(); err != nil {
 		return
@@ -25,6 +32,14 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	if currentFlags.Debug {
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	logStartup := func(phase string) {
+		slog.Debug("startup", "phase", phase, "elapsed", time.Since(startTime))
+	}
+	logStartup("flags parsed")
+
 	var homedir string
 	if homedir, err = os.UserHomeDir(); err != nil {
 		return
@@ -32,36 +47,52 @@ func Cli(version string) (err error) {
 
 	fabricDb := fsdb.NewDb(filepath.Join(homedir, ".config/fabric"))
 
-	if err = fabricDb.Configure(); err != nil {
-		if !currentFlags.Setup {
-			println(err.Error())
-			currentFlags.Setup = true
+	if currentFlags.Ephemeral {
+		if conflicts := currentFlags.ephemeralConflicts(); len(conflicts) > 0 {
+			err = fmt.Errorf("%s can't be used with --ephemeral, it writes to disk", strings.Join(conflicts, ", "))
+			return
 		}
+		fabricDb.SetEphemeral()
 	}
 
-	registry := core.NewPluginRegistry(fabricDb)
+	if currentFlags.DryRun {
+		if conflicts := currentFlags.dryRunConflicts(); len(conflicts) > 0 {
+			err = fmt.Errorf("%s can't be used with --dry-run, it would publish the request", strings.Join(conflicts, ", "))
+			return
+		}
+	}
 
-	// if the setup flag is set, run the setup function
-	if currentFlags.Setup {
-		err = registry.Setup()
+	if currentFlags.PullRequestComment && currentFlags.PullRequest == "" {
+		err = fmt.Errorf("--pr-comment needs the pull request given with --pr")
 		return
 	}
 
-	if currentFlags.Serve {
-		err = restapi.Serve(registry, currentFlags.ServeAddress)
-		return
+	if err = fabricDb.Configure(); err != nil {
+		// the setup writes the configuration, an ephemeral run needs an existing one
+		if currentFlags.Ephemeral {
+			return
+		}
+		if !currentFlags.Setup {
+			println(err.Error())
+			currentFlags.Setup = true
+		}
 	}
 
-	if currentFlags.UpdatePatterns {
-		err = registry.PatternsLoader.PopulateDB()
-		return
+	logStartup("database configured")
+
+	if currentFlags.TrashDays > 0 && !currentFlags.Ephemeral {
+		if _, purgeErr := fabricDb.Trash.Purge(time.Duration(currentFlags.TrashDays) * 24 * time.Hour); purgeErr != nil {
+			fmt.Fprintf(os.Stderr, "could not purge the trash: %v\n", purgeErr)
+		}
 	}
 
-	if currentFlags.ChangeDefaultModel {
-		err = registry.Defaults.Setup()
+	// if the setup flag is set, run the setup function
+	if currentFlags.Setup {
+		err = core.NewPluginRegistry(fabricDb).Setup()
 		return
 	}
 
+	// the following commands only need the database, they run before any plugin is initialized
 	if currentFlags.LatestPatterns != "0" {
 		var parsedToInt int
 		if parsedToInt, err = strconv.Atoi(currentFlags.LatestPatterns); err != nil {
@@ -79,15 +110,6 @@ func Cli(version string) (err error) {
 		return
 	}
 
-	if currentFlags.ListAllModels {
-		var models *ai.VendorsModels
-		if models, err = registry.VendorManager.GetModels(); err != nil {
-			return
-		}
-		models.Print()
-		return
-	}
-
 	if currentFlags.ListAllContexts {
 		err = fabricDb.Contexts.ListNames()
 		return
@@ -108,6 +130,27 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	if currentFlags.TrashList {
+		err = fabricDb.Trash.PrintList()
+		return
+	}
+
+	if currentFlags.Restore != "" {
+		var item *fsdb.TrashItem
+		if item, err = fabricDb.Trash.Restore(currentFlags.Restore); err == nil {
+			fmt.Printf("restored %s/%s\n", item.Kind, item.Name)
+		}
+		return
+	}
+
+	if currentFlags.TrashEmpty {
+		var items []*fsdb.TrashItem
+		if items, err = fabricDb.Trash.Empty(); err == nil {
+			fmt.Printf("deleted %d items from the trash\n", len(items))
+		}
+		return
+	}
+
 	if currentFlags.PrintSession != "" {
 		err = fabricDb.Sessions.PrintSession(currentFlags.PrintSession)
 		return
@@ -118,6 +161,74 @@ func Cli(version string) (err error) {
 		return
 	}
 
+	if currentFlags.Command == "shell-init" {
+		err = printShellInit(currentFlags)
+		return
+	}
+
+	if currentFlags.Command == "launchers generate" {
+		err = generateLaunchers(currentFlags, fabricDb)
+		return
+	}
+
+	registry := core.NewPluginRegistry(fabricDb)
+	logStartup("plugins registered")
+
+	switch currentFlags.Command {
+	case "render":
+		err = renderDocument(currentFlags, registry)
+		return
+	case "shell-command":
+		err = runShellCommand(currentFlags, registry)
+		return
+	case "quiz":
+		err = runQuiz(currentFlags, registry)
+		return
+	case "patterns optimize":
+		err = optimizePattern(currentFlags, registry)
+		return
+	case "patterns to-ollama":
+		err = exportPatternsToOllama(currentFlags, registry)
+		return
+	}
+
+	if strings.HasPrefix(currentFlags.Command, "vendors ") {
+		err = runVendorsCommand(currentFlags, registry)
+		return
+	}
+
+	if currentFlags.Serve {
+		err = restapi.Serve(registry, currentFlags.ServeAddress,
+			&restapi.ByokPolicy{Mode: currentFlags.ServeByok, Vendors: currentFlags.ServeByokVendors})
+		return
+	}
+
+	if currentFlags.UpdatePatterns {
+		err = registry.PatternsLoader.PopulateDB()
+		return
+	}
+
+	if currentFlags.ChangeDefaultModel {
+		err = registry.Defaults.Setup()
+		return
+	}
+
+	if currentFlags.ListAllModels {
+		var models *ai.VendorsModels
+		if models, err = registry.VendorManager.GetModels(); err != nil {
+			return
+		}
+		models.Print()
+		return
+	}
+
+	var replayed *RequestFile
+	if currentFlags.Request != "" {
+		if replayed, err = loadRequestFile(currentFlags); err != nil {
+			return
+		}
+	}
+
 	if currentFlags.HtmlReadability {
 		if msg, cleanErr := converter.HtmlReadability(currentFlags.Message); cleanErr != nil {
 			fmt.Println("use original input, because can't apply html readability", err)
@@ -133,6 +244,26 @@ func Cli(version string) (err error) {
 
 	// if none of the above currentFlags are set, run the initiate chat function
 
+	if currentFlags.ChatLog != "" {
+		var chatLogOptions *chatlog.Options
+		if chatLogOptions, err = currentFlags.BuildChatLogOptions(); err != nil {
+			return
+		}
+
+		var chat string
+		if chat, err = chatlog.Load(currentFlags.ChatLog, chatLogOptions); err != nil {
+			return
+		}
+
+		currentFlags.AppendMessage(chat)
+
+		if !currentFlags.IsChatRequest() {
+			// if the pattern flag is not set, we wanted only to normalize the chat export
+			fmt.Println(currentFlags.Message)
+			return
+		}
+	}
+
 	if currentFlags.YouTube != "" {
 		if registry.YouTube.IsConfigured() == false {
 			err = fmt.Errorf("YouTube is not configured, please run the setup procedure")
@@ -146,15 +277,7 @@ func Cli(version string) (err error) {
 
 		if !currentFlags.YouTubeComments || currentFlags.YouTubeTranscript {
 			var transcript string
-			var language = "en"
-			if currentFlags.Language != "" || registry.Language.DefaultLanguage.Value != "" {
-				if currentFlags.Language != "" {
-					language = currentFlags.Language
-				} else {
-					language = registry.Language.DefaultLanguage.Value
-				}
-			}
-			if transcript, err = registry.YouTube.GrabTranscript(videoId, language); err != nil {
+			if transcript, err = registry.YouTube.GrabTranscript(videoId, transcriptLanguage(currentFlags, registry)); err != nil {
 				return
 			}
 
@@ -179,11 +302,42 @@ func Cli(version string) (err error) {
 		}
 	}
 
+	if currentFlags.PullRequest != "" {
+		var pullRequest *forge.PullRequest
+		if pullRequest, err = registry.Forge.GetPullRequest(currentFlags.PullRequest); err != nil {
+			return
+		}
+
+		currentFlags.AppendMessage(pullRequest.String())
+
+		if !currentFlags.IsChatRequest() {
+			// if the pattern flag is not set, we wanted only to grab the pull request
+			fmt.Println(currentFlags.Message)
+			return
+		}
+	}
+
+	var adHocPattern *fsdb.Pattern
+	if adHocPattern, err = getAdHocPattern(currentFlags, registry); err != nil {
+		return
+	}
+
+	if replayed != nil {
+		for _, warning := range checkRequestFile(replayed, version, currentFlags, fabricDb, adHocPattern) {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+
+	var jinaOptions *jina.Options
+	if jinaOptions, err = getJinaOptions(currentFlags, fabricDb); err != nil {
+		return
+	}
+
 	if (currentFlags.ScrapeURL != "" || currentFlags.ScrapeQuestion != "") && registry.Jina.IsConfigured() {
 		// Check if the scrape_url flag is set and call ScrapeURL
 		if currentFlags.ScrapeURL != "" {
 			var website string
-			if website, err = registry.Jina.ScrapeURL(currentFlags.ScrapeURL); err != nil {
+			if website, err = registry.Jina.ScrapeURLWithOptions(currentFlags.ScrapeURL, jinaOptions); err != nil {
 				return
 			}
 
@@ -193,7 +347,7 @@ func Cli(version string) (err error) {
 		// Check if the scrape_question flag is set and call ScrapeQuestion
 		if currentFlags.ScrapeQuestion != "" {
 			var website string
-			if website, err = registry.Jina.ScrapeQuestion(currentFlags.ScrapeQuestion); err != nil {
+			if website, err = registry.Jina.ScrapeQuestionWithOptions(currentFlags.ScrapeQuestion, jinaOptions); err != nil {
 				return
 			}
 
@@ -207,23 +361,118 @@ func Cli(version string) (err error) {
 		}
 	}
 
+	if currentFlags.Pattern != "" && !currentFlags.NoAutoInput && !currentFlags.WatchClipboard {
+		if err = acquirePatternInput(currentFlags, registry, jinaOptions); err != nil {
+			return
+		}
+	}
+
+	diffLast := currentFlags.DiffLast || currentFlags.ChangesOnly
+	if diffLast && currentFlags.Pattern == "" && currentFlags.System == "" {
+		err = fmt.Errorf("--diff-last and --changes-only compare the outputs of a pattern, choose one with --pattern or --system")
+		return
+	}
+
+	sweeping := len(currentFlags.Sweep) > 0
+	if sweeping {
+		if conflicts := currentFlags.sweepConflicts(); len(conflicts) > 0 {
+			err = fmt.Errorf("%s can't be used with --sweep, it runs the request several times", strings.Join(conflicts, ", "))
+			return
+		}
+	}
+
+	if currentFlags.WatchClipboard {
+		if conflicts := currentFlags.watchConflicts(); len(conflicts) > 0 {
+			err = fmt.Errorf("%s can't be used with --watch-clipboard, it answers every clipboard content once", strings.Join(conflicts, ", "))
+			return
+		}
+	}
+
+	// the clipboard watch sends a single request per content, whatever the pattern asks for
+	candidates := 1
+	if !currentFlags.WatchClipboard {
+		candidates = getCandidates(currentFlags, fabricDb)
+	}
+
 	var chatter *core.Chatter
-	if chatter, err = registry.GetChatter(currentFlags.Model, currentFlags.Stream, currentFlags.DryRun); err != nil {
+	// the output is compared or picked once it is complete, so it is not streamed
+	streamed := currentFlags.Stream && !diffLast && candidates <= 1 && !sweeping
+	if replayed != nil {
+		chatter, err = getChatterOfRequest(replayed, registry, streamed, currentFlags.DryRun)
+	} else {
+		chatter, err = registry.GetChatter(currentFlags.Model, streamed, currentFlags.DryRun)
+	}
+	if err != nil {
+		return
+	}
+
+	if currentFlags.WatchClipboard {
+		if currentFlags.WatchURLsOnly && !registry.Jina.IsConfigured() {
+			err = fmt.Errorf("--watch-urls-only scrapes the copied URLs with Jina AI, but Jina AI is not configured, please run the setup procedure")
+			return
+		}
+		loadURL := func(url string) (string, error) {
+			return registry.Jina.ScrapeURLWithOptions(strings.TrimSpace(url), jinaOptions)
+		}
+
+		err = watchClipboard(currentFlags, streamed, loadURL, func(message string) (ret string, err error) {
+			chatReq := currentFlags.BuildChatRequest("")
+			chatReq.Message = message
+			setAdHocPattern(chatReq, adHocPattern)
+			if chatReq.Language == "" {
+				chatReq.Language = registry.Language.DefaultLanguage.Value
+			}
+
+			var session *fsdb.Session
+			if session, err = chatter.Send(chatReq, currentFlags.BuildChatOptions()); err != nil {
+				return
+			}
+			ret = session.GetLastMessage().Content
+			return
+		})
 		return
 	}
 
 	var session *fsdb.Session
-	chatReq := currentFlags.BuildChatRequest(strings.Join(os.Args[1:], " "))
+	chatReq := currentFlags.BuildChatRequest(registry.Privacy.BuildMeta(os.Args[1:], commandLine()))
+	setAdHocPattern(chatReq, adHocPattern)
 	if chatReq.Language == "" {
 		chatReq.Language = registry.Language.DefaultLanguage.Value
 	}
-	if session, err = chatter.Send(chatReq, currentFlags.BuildChatOptions()); err != nil {
+
+	if currentFlags.SaveRequest != "" {
+		var request *RequestFile
+		if request, err = buildRequestFile(version, currentFlags, chatReq, chatter, registry, adHocPattern); err != nil {
+			return
+		}
+		if err = saveRequestFile(currentFlags.SaveRequest, request); err != nil {
+			return
+		}
+	}
+
+	if sweeping {
+		err = runSweep(currentFlags, registry, chatReq)
+		return
+	}
+
+	if candidates > 1 {
+		session, err = chatter.SendCandidates(chatReq, currentFlags.BuildChatOptions(), candidates, pickCandidate)
+	} else {
+		session, err = chatter.Send(chatReq, currentFlags.BuildChatOptions())
+	}
+	if err != nil {
 		return
 	}
 
 	result := session.GetLastMessage().Content
 
-	if !currentFlags.Stream {
+	if diffLast {
+		if result, err = diffLastOutput(currentFlags, fabricDb, result); err != nil {
+			return
+		}
+	}
+
+	if !streamed && result != "" {
 		// print the result if it was not streamed already
 		fmt.Println(result)
 	}
@@ -235,6 +484,13 @@ func Cli(version string) (err error) {
 		}
 	}
 
+	// if the pr-comment flag is set, post the result back to the pull request, there is nothing to post without changes
+	if currentFlags.PullRequestComment && strings.TrimSpace(result) != "" {
+		if err = registry.Forge.PostComment(currentFlags.PullRequest, result); err != nil {
+			return
+		}
+	}
+
 	// if the output flag is set, create an output file
 	if currentFlags.Output != "" {
 		if currentFlags.OutputSession {
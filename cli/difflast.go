@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/diff"
+)
+
+// diffLastOutput remembers the output as the last one of the pattern for the input and returns what changed since the
+// previous one: a word-level diff, or only the new items with --changes-only. Without a change nothing is returned.
+func diffLastOutput(currentFlags *Flags, fabricDb *fsdb.Db, output string) (ret string, err error) {
+	name := fmt.Sprintf("%s-%s", patternKey(currentFlags), currentFlags.InputFingerprint()[:16])
+
+	var previous []byte
+	hasPrevious := fabricDb.LastOutputs.Exists(name)
+	if hasPrevious {
+		if previous, err = fabricDb.LastOutputs.Load(name); err != nil {
+			return
+		}
+	}
+
+	// dry runs have no output and ephemeral runs don't write anything
+	if !currentFlags.DryRun && !fabricDb.Ephemeral {
+		if err = fabricDb.LastOutputs.Save(name, []byte(output)); err != nil {
+			return
+		}
+	}
+
+	switch {
+	case !hasPrevious:
+		fmt.Fprintln(os.Stderr, "no previous output of the pattern for this input, printing the whole output")
+		ret = output
+	case currentFlags.ChangesOnly:
+		if ret = strings.Join(diff.NewItems(string(previous), output), "\n"); ret == "" {
+			fmt.Fprintln(os.Stderr, "no new items since the previous run")
+		}
+	case !diff.Changed(string(previous), output):
+		fmt.Fprintln(os.Stderr, "no changes since the previous run")
+	default:
+		ret = diff.Words(string(previous), output)
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLastOutput(t *testing.T) {
+	fabricDb := fsdb.NewDb(t.TempDir())
+	assert.NoError(t, fabricDb.LastOutputs.Configure())
+
+	currentFlags := &Flags{Pattern: "extract_ideas", ScrapeURL: "https://example.com", Message: "page v1"}
+	output, err := diffLastOutput(currentFlags, fabricDb, "- idea one\n- idea two")
+	assert.NoError(t, err)
+	assert.Equal(t, "- idea one\n- idea two", output)
+
+	// the page changed, but it is still the same input
+	currentFlags.Message = "page v2"
+	output, err = diffLastOutput(currentFlags, fabricDb, "- idea one\n- idea three")
+	assert.NoError(t, err)
+	assert.Equal(t, "- idea one\n- idea [-two-]{+three+}", output)
+
+	currentFlags.ChangesOnly = true
+	output, err = diffLastOutput(currentFlags, fabricDb, "- idea one\n- idea three\n- idea four")
+	assert.NoError(t, err)
+	assert.Equal(t, "- idea four", output)
+
+	output, err = diffLastOutput(currentFlags, fabricDb, "- idea one\n- idea three\n- idea four")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+}
+
+func TestInputFingerprint(t *testing.T) {
+	fromStdin := &Flags{Message: "text", messageSource: "stdin"}
+	assert.NotEqual(t, fromStdin.InputFingerprint(), (&Flags{Message: "other", messageSource: "stdin"}).InputFingerprint())
+
+	fromUrl := &Flags{ScrapeURL: "https://example.com", Message: "page v1"}
+	assert.Equal(t, fromUrl.InputFingerprint(), (&Flags{ScrapeURL: "https://example.com", Message: "page v2"}).InputFingerprint())
+}
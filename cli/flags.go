@@ -2,19 +2,29 @@ package cli
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/tools/chatlog"
+	"github.com/danielmiessler/fabric/plugins/tools/jina"
+	"github.com/danielmiessler/fabric/plugins/tools/privacy"
 	"github.com/jessevdk/go-flags"
 	"golang.org/x/text/language"
 )
 
 // Flags create flags struct. the users flags go into this, this will be passed to the chat struct in cli
 type Flags struct {
-	Pattern            string            `short:"p" long:"pattern" description:"Choose a pattern from the available patterns" default:""`
+	Pattern            string            `short:"p" long:"pattern" description:"Choose a pattern from the available patterns, or a pattern file or URL, e.g. ./draft/system.md" default:""`
+	System             string            `long:"system" description:"Use the inline system prompt instead of a pattern"`
 	PatternVariables   map[string]string `short:"v" long:"variable" description:"Values for pattern variables, e.g. -v=#role:expert -v=#points:30"`
 	Context            string            `short:"C" long:"context" description:"Choose a context from the available contexts" default:""`
 	Session            string            `long:"session" description:"Choose a session from the available sessions"`
@@ -43,16 +53,174 @@ type Flags struct {
 	Language           string            `short:"g" long:"language" description:"Specify the Language Code for the chat, e.g. -g=en -g=zh" default:""`
 	ScrapeURL          string            `short:"u" long:"scrape_url" description:"Scrape website URL to markdown using Jina AI"`
 	ScrapeQuestion     string            `short:"q" long:"scrape_question" description:"Search question using Jina AI"`
+	JinaTarget         string            `long:"jina-target" description:"CSS selector of the content Jina AI returns instead of the whole page, e.g. article"`
+	JinaWaitFor        string            `long:"jina-wait-for" description:"CSS selector of an element Jina AI waits for before reading the page"`
+	JinaRemove         string            `long:"jina-remove" description:"CSS selector of the elements Jina AI removes, e.g. \"header, footer\""`
+	JinaImageCaptions  string            `long:"jina-image-captions" optional:"yes" optional-value:"true" choice:"true" choice:"false" description:"Let Jina AI caption the images without alt text, =false turns off the pattern default"`
+	JinaLinksSummary   string            `long:"jina-links-summary" optional:"yes" optional-value:"true" choice:"true" choice:"false" description:"Add a summary of all links of the page, =false turns off the pattern default"`
+	JinaImagesSummary  string            `long:"jina-images-summary" optional:"yes" optional-value:"true" choice:"true" choice:"false" description:"Add a summary of all images of the page, =false turns off the pattern default"`
+	JinaJson           string            `long:"jina-json" optional:"yes" optional-value:"true" choice:"true" choice:"false" description:"Return the JSON response of Jina AI with the title and metadata of the page, =false turns off the pattern default"`
+	JinaNoCache        string            `long:"jina-no-cache" optional:"yes" optional-value:"true" choice:"true" choice:"false" description:"Bypass the cache of Jina AI, =false turns off the pattern default"`
+	JinaTimeout        int               `long:"jina-timeout" description:"Seconds Jina AI waits for the page to load"`
+	JinaLocale         string            `long:"jina-locale" description:"Browser locale Jina AI reads the page with, e.g. de-DE"`
 	Seed               int               `short:"e" long:"seed" description:"Seed to be used for LMM generation"`
-	WipeContext        string            `short:"w" long:"wipecontext" description:"Wipe context"`
-	WipeSession        string            `short:"W" long:"wipesession" description:"Wipe session"`
+	WipeContext        string            `short:"w" long:"wipecontext" description:"Wipe context, it is moved to the trash"`
+	WipeSession        string            `short:"W" long:"wipesession" description:"Wipe session, it is moved to the trash"`
+	TrashList          bool              `long:"trash-list" description:"List the deleted patterns, contexts and sessions in the trash"`
+	Restore            string            `long:"restore" description:"Restore a deleted item from the trash, e.g. --restore=sessions/research"`
+	TrashEmpty         bool              `long:"trash-empty" description:"Permanently delete all items in the trash"`
+	TrashDays          int               `long:"trash-days" description:"Permanently delete items after this number of days in the trash, 0 keeps them" default:"30"`
 	PrintContext       string            `long:"printcontext" description:"Print context"`
 	PrintSession       string            `long:"printsession" description:"Print session"`
 	HtmlReadability    bool              `long:"readability" description:"Convert HTML input into a clean, readable view"`
 	DryRun             bool              `long:"dry-run" description:"Show what would be sent to the model without actually sending it"`
 	Serve              bool              `long:"serve" description:"Serve the Fabric Rest API"`
 	ServeAddress       string            `long:"address" description:"The address to bind the REST API" default:":8080"`
+	ServeByok          string            `long:"byok" description:"Vendor credentials of REST chat requests (X-Fabric-<Vendor>-Key and X-Fabric-<Vendor>-Base-Url headers): off, allow them or require them" choice:"off" choice:"allow" choice:"require" default:"off"`
+	ServeByokVendors   []string          `long:"byok-vendor" description:"Only accept request credentials for these vendors, e.g. --byok-vendor=OpenAI"`
 	Version            bool              `long:"version" description:"Print current version"`
+	Debug              bool              `long:"debug" description:"Print debug logs, e.g. the startup timing, to stderr"`
+	Ephemeral          bool              `long:"ephemeral" description:"Don't write anything to disk, sessions are used but not saved"`
+	ChatLog            string            `long:"chatlog" description:"Chat export (file or directory) of Slack, Discord, WhatsApp or Matrix to send to chat"`
+	ChatLogFormat      string            `long:"chatlog-format" description:"Format of the chat export" choice:"auto" choice:"slack" choice:"discord" choice:"whatsapp" choice:"matrix" default:"auto"`
+	ChatLogSince       string            `long:"chatlog-since" description:"Only use chat messages from this date on, e.g. 2024-01-31"`
+	ChatLogUntil       string            `long:"chatlog-until" description:"Only use chat messages before this date, e.g. 2024-02-29"`
+	ChatLogChannels    []string          `long:"chatlog-channel" description:"Only use chat messages of these channels, e.g. --chatlog-channel=general"`
+	PullRequest        string            `long:"pr" description:"GitHub, GitLab or Gitea pull request \"URL\" to grab title, description, diff and comments from it and send to chat"`
+	PullRequestComment bool              `long:"pr-comment" description:"Post the result as a comment to the pull request given with --pr"`
+	WatchClipboard     bool              `long:"watch-clipboard" description:"Watch the clipboard and run the pattern on every new text or HTML copied to it"`
+	WatchMinLength     int               `long:"watch-min-length" description:"Ignore clipboard content shorter than this number of characters" default:"0"`
+	WatchURLsOnly      bool              `long:"watch-urls-only" description:"Only react to clipboard content that is a URL, the page is scraped with Jina AI"`
+	WatchOutput        string            `long:"watch-output" description:"Where to put the results of the clipboard watch" choice:"print" choice:"clipboard" choice:"journal" default:"print"`
+	WatchJournal       string            `long:"watch-journal" description:"Journal file to append the results of the clipboard watch to"`
+	WatchInterval      time.Duration     `long:"watch-interval" description:"Polling interval of the clipboard watch" default:"1s"`
+	NoAutoInput        bool              `long:"no-auto-input" description:"Send the input as given, don't fetch the input the pattern declares, e.g. the transcript of a bare YouTube URL or the git diff"`
+	DiffLast           bool              `long:"diff-last" description:"Show a word-level diff against the previous output of the pattern for the same input, e.g. to monitor a page"`
+	ChangesOnly        bool              `long:"changes-only" description:"Like --diff-last, but only print the items that newly appeared, for list patterns like extract_ideas"`
+	Candidates         int               `long:"candidates" description:"Generate N answers and pick, edit or merge them in the terminal, only the picked one is stored in the session"`
+	PatternCandidates  bool              `long:"pattern-candidates" description:"Generate the number of answers to pick from that the pattern.json of the pattern asks for"`
+	Sweep              []string          `long:"sweep" description:"Run the request for every combination of the values, e.g. --sweep temperature=0,0.5,1 --sweep top_p=0.9,1 (also presence_penalty, frequency_penalty, seed and model)"`
+	SweepJobs          int               `long:"sweep-jobs" description:"Number of sweep runs executed in parallel" default:"4"`
+	SweepOutput        string            `long:"sweep-output" description:"Save the sweep to a directory with one file per run, or to a .json file"`
+	SweepJudge         string            `long:"sweep-judge" description:"Pattern that scores the output of every sweep run, e.g. rate_ai_result"`
+	SaveRequest        string            `long:"save-request" description:"Write the fully resolved request to a JSON file, e.g. for bug reports or research notes"`
+	Request            string            `long:"request" description:"Run the request of a file written with --save-request again"`
+
+	Render    RenderCommand    `command:"render" description:"Execute the fabric blocks of a Markdown document and insert their outputs below them"`
+	Launchers LaunchersCommand `command:"launchers" description:"Integrate the patterns into desktop launchers"`
+	ShellInit ShellInitCommand `command:"shell-init" description:"Print the shell integration that generates and explains commands, e.g. eval \"$(fabric shell-init bash)\""`
+	ShellCmd  ShellCmdCommand  `command:"shell-command" hidden:"yes" description:"Generate or explain a command line, used by the shell integration"`
+	Quiz      QuizCommand      `command:"quiz" description:"Take the quiz of the output of create_quiz or to_flashcards from stdin or a session, missed questions come back with spaced repetition"`
+	Vendors   VendorsCommand   `command:"vendors" description:"List, enable, disable, remove and test the vendors and choose the default one"`
+	Patterns  PatternsCommand  `command:"patterns" description:"Improve the patterns"`
+
+	// Command is the name of the active command, empty for plain chat requests
+	Command string `no-flag:"true"`
+	// messageSource is where the message was read from, stdin or argument
+	messageSource string
+	// dynamicVariables are the values of the dynamic variables of a request file
+	dynamicVariables map[string]string
+}
+
+// RenderCommand executes ```fabric pattern=... blocks of a Markdown document
+type RenderCommand struct {
+	Jobs  int  `short:"j" long:"jobs" description:"Number of blocks executed in parallel" default:"4"`
+	Force bool `long:"force" description:"Execute all blocks, also the ones whose input did not change"`
+	Args  struct {
+		Document string `positional-arg-name:"document" description:"Markdown document to render"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// LaunchersCommand groups the desktop launcher commands
+type LaunchersCommand struct {
+	Generate LaunchersGenerateCommand `command:"generate" description:"Generate launcher entries for all installed patterns"`
+}
+
+// LaunchersGenerateCommand writes the entries of a launcher, they run a pattern on the selection or the clipboard
+type LaunchersGenerateCommand struct {
+	Target string `long:"target" description:"Launcher to generate the entries for" choice:"rofi" choice:"ulauncher" choice:"albert" choice:"raycast" required:"yes"`
+	Output string `long:"output-dir" description:"Directory to write the entries to, default is the one the launcher reads"`
+	Show   string `long:"show" description:"Where to show the result" choice:"notify" choice:"editor" default:"notify"`
+}
+
+// ShellInitCommand prints the key bindings of a shell
+type ShellInitCommand struct {
+	Model string `long:"model" description:"Model of the widgets, default is a small model of the default vendor"`
+	Args  struct {
+		Shell string `positional-arg-name:"shell" description:"bash, zsh or fish"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// ShellCmdCommand is called by the widgets with the command line, the recent history is read from stdin
+type ShellCmdCommand struct {
+	Shell   string `long:"shell" description:"Shell of the command line" default:"bash"`
+	Explain bool   `long:"explain" description:"Explain the command line instead of generating a command from it"`
+	Args    struct {
+		Line string `positional-arg-name:"line" description:"Command line"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// QuizCommand asks the questions of a deck one at a time, the model grades the answers
+type QuizCommand struct {
+	Deck        string `long:"deck" description:"Deck that stores the questions and scores, default is the session name or default"`
+	FromSession string `long:"from-session" description:"Take the questions from the last answer of this session"`
+	Count       int    `long:"count" description:"Number of questions to ask, the due ones first" default:"10"`
+	Stats       bool   `long:"stats" description:"Print the scores of the previous quizzes of the deck"`
+}
+
+// PatternsCommand groups the pattern commands
+type PatternsCommand struct {
+	Optimize PatternsOptimizeCommand `command:"optimize" description:"Improve a pattern with a dataset of cases: revisions are proposed for the failing cases and the best-scoring one is saved as a new version"`
+	ToOllama PatternsToOllamaCommand `command:"to-ollama" description:"Create Ollama models with the patterns as system prompts, e.g. fabric-extract_wisdom"`
+}
+
+// PatternsOptimizeCommand optimizes a pattern, the outputs are generated with --model and scored with the judge model
+type PatternsOptimizeCommand struct {
+	Dataset      string  `long:"dataset" description:"JSONL file with one case per line, e.g. {\"input\": \"...\", \"expected\": \"...\", \"criteria\": \"...\"}" required:"yes"`
+	Judge        string  `long:"judge" description:"Model that scores the outputs, default is the model of --model"`
+	Iterations   int     `long:"iterations" description:"Number of revision rounds" default:"3"`
+	Revisions    int     `long:"revisions" description:"Number of revisions proposed per round" default:"2"`
+	PassingScore float64 `long:"passing-score" description:"Cases scored below this (0 to 10) are the failing ones the revisions are based on" default:"8"`
+	Jobs         int     `short:"j" long:"jobs" description:"Number of cases evaluated in parallel" default:"4"`
+	Name         string  `long:"name" description:"Name of the optimized pattern, default is the next version, e.g. summarize_v2"`
+	Args         struct {
+		Pattern string `positional-arg-name:"pattern" description:"Pattern to optimize"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+// PatternsToOllamaCommand exports patterns as Ollama models, with --dry-run the Modelfiles are only printed
+type PatternsToOllamaCommand struct {
+	Base      string `long:"base" description:"Ollama model the pattern models are based on, e.g. llama3" required:"yes"`
+	All       bool   `long:"all" description:"Export all installed patterns"`
+	Prefix    string `long:"prefix" description:"Prefix of the names of the models" default:"fabric-"`
+	OutputDir string `long:"output-dir" description:"Also write the Modelfiles to this directory"`
+	Args      struct {
+		Pattern string `positional-arg-name:"pattern" description:"Pattern to export"`
+	} `positional-args:"yes"`
+}
+
+// VendorsCommand groups the vendor management commands, disabled vendors keep their credentials
+type VendorsCommand struct {
+	Json       bool                    `long:"json" description:"Print the result as JSON"`
+	List       struct{}                `command:"list" description:"List all vendors with their state"`
+	Enable     VendorNameCommand       `command:"enable" description:"Use the vendor again for models and routing"`
+	Disable    VendorNameCommand       `command:"disable" description:"Exclude the vendor from models and routing, its credentials are kept"`
+	Remove     VendorNameCommand       `command:"remove" description:"Remove the credentials and settings of the vendor"`
+	Test       VendorNameCommand       `command:"test" description:"Test the connection of the vendor by listing its models"`
+	SetDefault VendorSetDefaultCommand `command:"set-default" description:"Make the vendor the default one"`
+}
+
+type VendorNameCommand struct {
+	Args struct {
+		Name string `positional-arg-name:"name" description:"Vendor name"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+type VendorSetDefaultCommand struct {
+	Args struct {
+		Name  string `positional-arg-name:"name" description:"Vendor name"`
+		Model string `positional-arg-name:"model" description:"Default model, default is the current one if the vendor serves it, otherwise its first model"`
+	} `positional-args:"yes" required:"1"`
 }
 
 // Init Initialize flags. returns a Flags struct and an error
@@ -61,11 +229,18 @@ func Init() (ret *Flags, err error) {
 
 	ret = &Flags{}
 	parser := flags.NewParser(ret, flags.Default)
+	parser.SubcommandsOptional = true
 	var args []string
 	if args, err = parser.Parse(); err != nil {
 		return
 	}
 
+	if parser.Active != nil {
+		ret.Command = commandName(parser.Active)
+		// the positional arguments belong to the command
+		args = nil
+	}
+
 	info, _ := os.Stdin.Stat()
 	hasStdin := (info.Mode() & os.ModeCharDevice) == 0
 
@@ -74,8 +249,10 @@ func Init() (ret *Flags, err error) {
 		if message, err = readStdin(); err != nil {
 			return
 		}
+		ret.messageSource = "stdin"
 	} else if len(args) > 0 {
 		message = args[len(args)-1]
+		ret.messageSource = "argument"
 	} else {
 		message = ""
 	}
@@ -84,6 +261,15 @@ func Init() (ret *Flags, err error) {
 	return
 }
 
+// commandName returns the full name of a (sub)command, e.g. "vendors list"
+func commandName(command *flags.Command) (ret string) {
+	ret = command.Name
+	if command.Active != nil {
+		ret = fmt.Sprintf("%s %s", ret, commandName(command.Active))
+	}
+	return
+}
+
 // readStdin reads from stdin and returns the input as a string or an error
 func readStdin() (string, error) {
 	reader := bufio.NewReader(os.Stdin)
@@ -121,6 +307,8 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *common.ChatRequest) {
 		PatternVariables: o.PatternVariables,
 		Message:          o.Message,
 		Meta:             Meta,
+		InputSource:      o.InputSource(),
+		DynamicVariables: o.dynamicVariables,
 	}
 	if o.Language != "" {
 		langTag, err := language.Parse(o.Language)
@@ -131,6 +319,203 @@ func (o *Flags) BuildChatRequest(Meta string) (ret *common.ChatRequest) {
 	return
 }
 
+// flagAliases maps the short and long names of the flags to each other
+func flagAliases() (ret map[string]string) {
+	ret = map[string]string{}
+	parser := flags.NewParser(&Flags{}, flags.None)
+	for _, group := range append(parser.Groups(), parser.Group) {
+		for _, option := range group.Options() {
+			if option.ShortName != 0 && option.LongName != "" {
+				ret[string(option.ShortName)] = option.LongName
+				ret[option.LongName] = string(option.ShortName)
+			}
+		}
+	}
+	return
+}
+
+// commandLine describes the flags for the privacy settings, the aliases and the flags without value
+func commandLine() (ret *privacy.CommandLine) {
+	ret = &privacy.CommandLine{Aliases: flagAliases(), Booleans: map[string]bool{}}
+	parser := flags.NewParser(&Flags{}, flags.None)
+	for _, group := range append(parser.Groups(), parser.Group) {
+		for _, option := range group.Options() {
+			if kind := option.Field().Type.Kind(); !option.OptionalArgument && kind != reflect.Bool && (kind != reflect.Slice ||
+				option.Field().Type.Elem().Kind() != reflect.Bool) {
+				continue
+			}
+			if option.ShortName != 0 {
+				ret.Booleans[string(option.ShortName)] = true
+			}
+			if option.LongName != "" {
+				ret.Booleans[option.LongName] = true
+			}
+		}
+	}
+	return
+}
+
+// writingCommands are the commands that write to disk, e.g. the quiz stores the reviews of the cards
+var writingCommands = []string{
+	"render", "launchers generate", "quiz", "patterns optimize", "patterns to-ollama",
+	"vendors enable", "vendors disable", "vendors remove", "vendors set-default",
+}
+
+// ephemeralConflicts returns the flags that write to disk and can't be used in an ephemeral run
+func (o *Flags) ephemeralConflicts() (ret []string) {
+	for _, conflict := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--setup", o.Setup}, {"--updatepatterns", o.UpdatePatterns}, {"--changeDefaultModel", o.ChangeDefaultModel},
+		{"--wipecontext", o.WipeContext != ""}, {"--wipesession", o.WipeSession != ""}, {"--restore", o.Restore != ""},
+		{"--trash-empty", o.TrashEmpty}, {"--output", o.Output != ""}, {"--watch-journal", o.WatchJournal != ""},
+		{"--save-request", o.SaveRequest != ""}, {"--sweep-output", o.SweepOutput != ""},
+		{o.Command, slices.Contains(writingCommands, o.Command)},
+	} {
+		if conflict.set {
+			ret = append(ret, conflict.flag)
+		}
+	}
+	return
+}
+
+// dryRunConflicts returns the flags that publish the result, a dry run would publish the request instead
+func (o *Flags) dryRunConflicts() (ret []string) {
+	for _, conflict := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--pr-comment", o.PullRequestComment},
+	} {
+		if conflict.set {
+			ret = append(ret, conflict.flag)
+		}
+	}
+	return
+}
+
+// sweepConflicts returns the flags that can't be used with --sweep, they expect a single answer
+func (o *Flags) sweepConflicts() (ret []string) {
+	for _, conflict := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--session", o.Session != ""}, {"--candidates", o.Candidates > 1}, {"--diff-last", o.DiffLast},
+		{"--changes-only", o.ChangesOnly}, {"--watch-clipboard", o.WatchClipboard}, {"--copy", o.Copy},
+		{"--output", o.Output != ""}, {"--pr-comment", o.PullRequestComment},
+	} {
+		if conflict.set {
+			ret = append(ret, conflict.flag)
+		}
+	}
+	return
+}
+
+// watchConflicts returns the flags that can't be used with --watch-clipboard, they compare or pick complete outputs
+func (o *Flags) watchConflicts() (ret []string) {
+	for _, conflict := range []struct {
+		flag string
+		set  bool
+	}{
+		{"--diff-last", o.DiffLast}, {"--changes-only", o.ChangesOnly}, {"--candidates", o.Candidates > 1},
+	} {
+		if conflict.set {
+			ret = append(ret, conflict.flag)
+		}
+	}
+	return
+}
+
+// InputSource describes where the message comes from, it is stored in sessions instead of inputs that are too large
+func (o *Flags) InputSource() string {
+	var sources []string
+	if o.messageSource != "" {
+		sources = append(sources, o.messageSource)
+	}
+	for _, source := range []struct{ label, value string }{
+		{"youtube", o.YouTube}, {"pull request", o.PullRequest}, {"url", o.ScrapeURL},
+		{"search", o.ScrapeQuestion}, {"chatlog", o.ChatLog}} {
+		if source.value != "" {
+			sources = append(sources, fmt.Sprintf("%s %s", source.label, source.value))
+		}
+	}
+	return strings.Join(sources, ", ")
+}
+
+// BuildJinaOptions returns the Jina AI options of the flags, the pattern defaults are merged in later
+func (o *Flags) BuildJinaOptions() *jina.Options {
+	return &jina.Options{
+		TargetSelector:  o.JinaTarget,
+		WaitForSelector: o.JinaWaitFor,
+		RemoveSelector:  o.JinaRemove,
+		ImageCaptions:   jinaSwitch(o.JinaImageCaptions),
+		LinksSummary:    jinaSwitch(o.JinaLinksSummary),
+		ImagesSummary:   jinaSwitch(o.JinaImagesSummary),
+		Json:            jinaSwitch(o.JinaJson),
+		NoCache:         jinaSwitch(o.JinaNoCache),
+		Timeout:         o.JinaTimeout,
+		Locale:          o.JinaLocale,
+	}
+}
+
+// jinaSwitch returns nil for a Jina AI flag that is not given, so that the pattern default applies
+func jinaSwitch(value string) (ret *bool) {
+	if value == "" {
+		return
+	}
+	on := value == "true"
+	ret = &on
+	return
+}
+
+// InputFingerprint identifies the input of a run for --diff-last. Fetched inputs are identified by their references,
+// e.g. the URL, so that a page that changed is still the same input. Other inputs are identified by their content.
+func (o *Flags) InputFingerprint() string {
+	var parts []string
+	for _, reference := range []string{o.YouTube, o.PullRequest, o.ScrapeURL, o.ScrapeQuestion, o.ChatLog} {
+		if reference != "" {
+			parts = append(parts, reference)
+		}
+	}
+	switch o.messageSource {
+	case "", "stdin", "argument":
+	case "git diff":
+		// the changes of the repository in the working directory
+		workingDir, _ := os.Getwd()
+		parts = append(parts, o.messageSource, workingDir)
+	default:
+		// the reference of an input fetched for the pattern
+		parts = append(parts, o.messageSource)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, o.Message)
+	}
+	hash := sha256.Sum256([]byte(strings.Join(append(parts, o.Context), "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+func (o *Flags) BuildChatLogOptions() (ret *chatlog.Options, err error) {
+	ret = &chatlog.Options{
+		Format:   o.ChatLogFormat,
+		Channels: o.ChatLogChannels,
+		Location: time.Local,
+	}
+	if o.ChatLogSince != "" {
+		if ret.Since, err = time.ParseInLocation(time.DateOnly, o.ChatLogSince, time.Local); err != nil {
+			err = fmt.Errorf("invalid --chatlog-since date %s, expected YYYY-MM-DD", o.ChatLogSince)
+			return
+		}
+	}
+	if o.ChatLogUntil != "" {
+		if ret.Until, err = time.ParseInLocation(time.DateOnly, o.ChatLogUntil, time.Local); err != nil {
+			err = fmt.Errorf("invalid --chatlog-until date %s, expected YYYY-MM-DD", o.ChatLogUntil)
+			return
+		}
+	}
+	return
+}
+
 func (o *Flags) AppendMessage(message string) {
 	if o.Message != "" {
 		o.Message = o.Message + "\n" + message
@@ -141,6 +526,6 @@ func (o *Flags) AppendMessage(message string) {
 }
 
 func (o *Flags) IsChatRequest() (ret bool) {
-	ret = (o.Message != "" || o.Context != "") && (o.Session != "" || o.Pattern != "")
+	ret = (o.Message != "" || o.Context != "") && (o.Session != "" || o.Pattern != "" || o.System != "")
 	return
 }
@@ -106,3 +106,47 @@ func TestBuildChatRequest(t *testing.T) {
 	request := flags.BuildChatRequest("test")
 	assert.Equal(t, expectedRequest, request)
 }
+
+func TestFlagAliases(t *testing.T) {
+	aliases := flagAliases()
+	assert.Equal(t, "variable", aliases["v"])
+	assert.Equal(t, "v", aliases["variable"])
+
+	booleans := commandLine().Booleans
+	assert.True(t, booleans["s"])
+	assert.False(t, booleans["v"])
+	assert.True(t, booleans["jina-image-captions"])
+}
+
+func TestBuildJinaOptions(t *testing.T) {
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--jina-image-captions=false", "--jina-no-cache", "--copy"}
+
+	flags, err := Init()
+	assert.NoError(t, err)
+	options := flags.BuildJinaOptions()
+	assert.False(t, *options.ImageCaptions)
+	assert.True(t, *options.NoCache)
+	assert.Nil(t, options.LinksSummary)
+	assert.True(t, flags.Copy)
+}
+
+func TestEphemeralConflicts(t *testing.T) {
+	assert.Empty(t, (&Flags{Pattern: "summarize", Session: "work"}).ephemeralConflicts())
+	assert.Equal(t, []string{"--wipesession", "--output"},
+		(&Flags{WipeSession: "work", Output: "out.md"}).ephemeralConflicts())
+	assert.Equal(t, []string{"render"}, (&Flags{Command: "render"}).ephemeralConflicts())
+	assert.Equal(t, []string{"vendors disable"}, (&Flags{Command: "vendors disable"}).ephemeralConflicts())
+	assert.Empty(t, (&Flags{Command: "vendors list"}).ephemeralConflicts())
+}
+
+func TestWatchConflicts(t *testing.T) {
+	assert.Empty(t, (&Flags{Pattern: "summarize", Stream: true}).watchConflicts())
+	assert.Equal(t, []string{"--diff-last", "--candidates"}, (&Flags{DiffLast: true, Candidates: 3}).watchConflicts())
+}
+
+func TestDryRunConflicts(t *testing.T) {
+	assert.Empty(t, (&Flags{PullRequest: "https://github.com/o/r/pull/1"}).dryRunConflicts())
+	assert.Equal(t, []string{"--pr-comment"}, (&Flags{PullRequestComment: true}).dryRunConflicts())
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/input"
+	"github.com/danielmiessler/fabric/plugins/tools/jina"
+)
+
+// acquirePatternInput fetches the input the pattern declares in its pattern.json if the message only refers to it,
+// e.g. the transcript of a bare YouTube URL or the git diff without a message, and warns if the input clearly doesn't
+// match the declared one
+func acquirePatternInput(currentFlags *Flags, registry *core.PluginRegistry, jinaOptions *jina.Options) (err error) {
+	var metadata *fsdb.PatternMetadata
+	if metadata, err = registry.Db.Patterns.GetMetadata(currentFlags.Pattern); err != nil {
+		// a missing pattern is reported when the session is built
+		err = nil
+		return
+	}
+	contract := metadata.Input
+	if contract == nil {
+		return
+	}
+
+	reference := strings.TrimSpace(currentFlags.Message)
+	switch input.Plan(contract, currentFlags.Message) {
+	case input.SourceYouTube:
+		if !registry.YouTube.IsConfigured() {
+			err = fmt.Errorf("pattern %s expects a YouTube transcript, but YouTube is not configured, please run the setup procedure or use --no-auto-input",
+				currentFlags.Pattern)
+			return
+		}
+		var videoId string
+		if videoId, err = registry.YouTube.GetVideoId(reference); err != nil {
+			return
+		}
+		if currentFlags.Message, err = registry.YouTube.GrabTranscript(videoId, transcriptLanguage(currentFlags, registry)); err != nil {
+			return
+		}
+		currentFlags.messageSource = "YouTube transcript of " + reference
+	case input.SourceURL:
+		if !registry.Jina.IsConfigured() {
+			err = fmt.Errorf("pattern %s expects the content of the URL, but Jina AI is not configured, please run the setup procedure or use --no-auto-input",
+				currentFlags.Pattern)
+			return
+		}
+		if currentFlags.Message, err = registry.Jina.ScrapeURLWithOptions(reference, jinaOptions); err != nil {
+			return
+		}
+		currentFlags.messageSource = reference
+	case input.SourceGitDiff:
+		if currentFlags.Message, err = input.GitDiff(); err != nil {
+			return
+		}
+		currentFlags.messageSource = "git diff"
+	}
+
+	for _, warning := range input.Check(contract, currentFlags.Message) {
+		fmt.Fprintf(os.Stderr, "warning: pattern %s: %s\n", currentFlags.Pattern, warning)
+	}
+	return
+}
+
+// transcriptLanguage is the language of the YouTube transcripts, the language of the output if one is chosen
+func transcriptLanguage(currentFlags *Flags, registry *core.PluginRegistry) string {
+	if currentFlags.Language != "" {
+		return currentFlags.Language
+	}
+	if registry.Language.DefaultLanguage.Value != "" {
+		return registry.Language.DefaultLanguage.Value
+	}
+	return "en"
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/jina"
+)
+
+// getJinaOptions returns the Jina AI options of the flags, the ones that are not set are taken from the pattern.json
+// of the pattern
+func getJinaOptions(currentFlags *Flags, fabricDb *fsdb.Db) (ret *jina.Options, err error) {
+	ret = currentFlags.BuildJinaOptions()
+	if currentFlags.Pattern == "" {
+		return
+	}
+
+	metadata, metadataErr := fabricDb.Patterns.GetMetadata(currentFlags.Pattern)
+	if metadataErr != nil || len(metadata.Jina) == 0 {
+		return
+	}
+	defaults := &jina.Options{}
+	if err = json.Unmarshal(metadata.Jina, defaults); err != nil {
+		err = fmt.Errorf("invalid jina options in %s of pattern %s: %v", fsdb.PatternMetadataFile, currentFlags.Pattern, err)
+		return
+	}
+	ret = ret.Merge(defaults)
+	return
+}
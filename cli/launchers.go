@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/launchers"
+)
+
+// generateLaunchers writes the entries of all installed patterns for the chosen launcher
+func generateLaunchers(currentFlags *Flags, fabricDb *fsdb.Db) (err error) {
+	options := currentFlags.Launchers.Generate
+
+	var target *launchers.Target
+	if target, err = launchers.FindTarget(options.Target); err != nil {
+		return
+	}
+
+	output := options.Output
+	if output == "" {
+		var homeDir string
+		if homeDir, err = os.UserHomeDir(); err != nil {
+			return
+		}
+		output = filepath.Join(homeDir, target.DefaultOutput)
+	}
+
+	var names []string
+	if names, err = fabricDb.Patterns.GetInstalledNames(); err != nil {
+		return
+	}
+	if len(names) == 0 {
+		err = fmt.Errorf("no patterns installed, run fabric --updatepatterns first")
+		return
+	}
+
+	var entries []*launchers.Entry
+	for _, name := range names {
+		entry := &launchers.Entry{Pattern: name}
+		if metadata, metadataErr := fabricDb.Patterns.GetMetadata(name); metadataErr == nil {
+			entry.Description = metadata.Description
+		}
+		entries = append(entries, entry)
+	}
+
+	fabricPath, executableErr := os.Executable()
+	if executableErr != nil {
+		fabricPath = "fabric"
+	}
+
+	generator := &launchers.Generator{
+		FabricPath: fabricPath,
+		RunnerPath: fabricDb.FilePath(filepath.Join("launchers", "fabric-launcher")),
+		Show:       options.Show,
+	}
+
+	var files []string
+	if files, err = generator.Generate(target, output, entries); err != nil {
+		return
+	}
+	fmt.Printf("generated %s entries for %d patterns in %s (%d files)\n", target.Name, len(entries), output, len(files))
+	return
+}
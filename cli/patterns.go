@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/ai/ollama"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/optimize"
+)
+
+// optimizePattern improves the pattern with the cases of the dataset and saves the best revision with its report as a
+// new local version of the pattern, the pattern itself is not changed
+func optimizePattern(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	options := currentFlags.Patterns.Optimize
+	patternName := options.Args.Pattern
+
+	var pattern *fsdb.Pattern
+	if pattern, err = registry.Db.Patterns.Get(patternName); err != nil {
+		err = fmt.Errorf("could not find pattern %s: %v", patternName, err)
+		return
+	}
+	for _, name := range []string{optimize.JudgePattern, optimize.RevisePattern} {
+		if !registry.Db.Patterns.Exists(name) {
+			err = fmt.Errorf("pattern %s not found, run fabric --updatepatterns", name)
+			return
+		}
+	}
+
+	var cases []*optimize.Case
+	if cases, err = optimize.LoadCases(options.Dataset); err != nil {
+		return
+	}
+
+	var chattersMutex sync.Mutex
+	chatters := map[string]*core.Chatter{}
+	send := func(model string, request *common.ChatRequest, opts *common.ChatOptions) (ret string, err error) {
+		chattersMutex.Lock()
+		chatter := chatters[model]
+		if chatter == nil {
+			if chatter, err = registry.GetChatter(model, false, currentFlags.DryRun); err == nil {
+				chatters[model] = chatter
+			}
+		}
+		chattersMutex.Unlock()
+		if err != nil {
+			return
+		}
+
+		var session *fsdb.Session
+		if session, err = chatter.Send(request, opts); err != nil {
+			return
+		}
+		ret = session.GetLastMessage().Content
+		return
+	}
+
+	judgeModel := options.Judge
+	if judgeModel == "" {
+		judgeModel = currentFlags.Model
+	}
+
+	optimizer := &optimize.Optimizer{
+		Jobs:         options.Jobs,
+		Iterations:   options.Iterations,
+		Candidates:   options.Revisions,
+		PassingScore: options.PassingScore,
+		Run: func(prompt string, testCase *optimize.Case) (string, error) {
+			return send(currentFlags.Model, &common.ChatRequest{
+				SystemPrompt:     prompt,
+				PatternVariables: testCase.Variables,
+				Message:          testCase.Input,
+			}, currentFlags.BuildChatOptions())
+		},
+		Judge: func(input string) (string, error) {
+			// the scores are compared, so the judge is as deterministic as possible
+			return send(judgeModel, &common.ChatRequest{PatternName: optimize.JudgePattern, Message: input},
+				&common.ChatOptions{Temperature: 0, TopP: 1, Seed: currentFlags.Seed})
+		},
+		Revise: func(input string) (string, error) {
+			return send(currentFlags.Model, &common.ChatRequest{PatternName: optimize.RevisePattern, Message: input},
+				currentFlags.BuildChatOptions())
+		},
+		Log: func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+
+	var report *optimize.Report
+	if report, err = optimizer.Optimize(pattern.Pattern, cases); err != nil {
+		return
+	}
+
+	markdown := report.Markdown(patternName, options.Dataset)
+	fmt.Println(markdown)
+	if !report.Improved() {
+		fmt.Printf("no revision scored better than %.2f, pattern %s is unchanged\n", report.Initial.Score, patternName)
+		return
+	}
+
+	name := options.Name
+	if name == "" {
+		name = registry.Db.Patterns.NextVersionName(patternName)
+	}
+	files := map[string][]byte{
+		registry.Db.Patterns.SystemPatternFile: []byte(report.Best.Prompt + "\n"),
+		optimize.ReportFile:                    []byte(markdown),
+	}
+	// the optimized version keeps the metadata, e.g. the input contract
+	if metadata, readErr := os.ReadFile(filepath.Join(registry.Db.Patterns.Dir, patternName, fsdb.PatternMetadataFile)); readErr == nil {
+		files[fsdb.PatternMetadataFile] = metadata
+	}
+	if err = registry.Db.Patterns.SaveFiles(name, files); err != nil {
+		return
+	}
+	fmt.Printf("saved pattern %s with score %.2f (%s scored %.2f)\n", name, report.Best.Score, patternName,
+		report.Initial.Score)
+	return
+}
+
+// exportPatternsToOllama creates an Ollama model per pattern with the system prompt and the recommended parameters of
+// the pattern. A failed pattern doesn't stop the export of all patterns.
+func exportPatternsToOllama(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	options := currentFlags.Patterns.ToOllama
+
+	var names []string
+	switch {
+	case options.All && options.Args.Pattern != "":
+		err = fmt.Errorf("give a pattern or --all, not both")
+		return
+	case options.All:
+		if names, err = registry.Db.Patterns.GetInstalledNames(); err != nil {
+			return
+		}
+	case options.Args.Pattern != "":
+		names = []string{options.Args.Pattern}
+	default:
+		err = fmt.Errorf("give the pattern to export or --all")
+		return
+	}
+
+	var client *ollama.Client
+	if !currentFlags.DryRun {
+		if client, err = getOllamaClient(registry); err != nil {
+			return
+		}
+	}
+
+	if options.OutputDir != "" {
+		if err = os.MkdirAll(options.OutputDir, os.ModePerm); err != nil {
+			return
+		}
+	}
+
+	if len(names) == 1 {
+		err = exportPatternToOllama(names[0], options, registry.Db, client)
+		return
+	}
+
+	var failed int
+	for _, name := range names {
+		if exportErr := exportPatternToOllama(name, options, registry.Db, client); exportErr != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, exportErr)
+			failed++
+		}
+	}
+	if failed > 0 {
+		err = fmt.Errorf("%d of %d patterns could not be exported", failed, len(names))
+	}
+	return
+}
+
+// exportPatternToOllama creates the model of the pattern, without a client the Modelfile is printed. The Modelfile is
+// only written for other tools, the model is created from the base model, the system prompt and the parameters.
+func exportPatternToOllama(name string, options PatternsToOllamaCommand, fabricDb *fsdb.Db, client *ollama.Client) (
+	err error) {
+
+	var pattern *fsdb.Pattern
+	if pattern, err = fabricDb.Patterns.Get(name); err != nil {
+		return
+	}
+	var metadata *fsdb.PatternMetadata
+	if metadata, err = fabricDb.Patterns.GetMetadata(name); err != nil {
+		return
+	}
+
+	model := options.Prefix + name
+	modelfile := ollama.BuildModelfile(options.Base, pattern.Pattern, metadata.Parameters)
+	if options.OutputDir != "" {
+		if err = os.WriteFile(filepath.Join(options.OutputDir, model+".Modelfile"), []byte(modelfile), 0644); err != nil {
+			return
+		}
+	}
+
+	if client == nil {
+		fmt.Printf("# %s\n%s\n", model, modelfile)
+		return
+	}
+	if err = client.CreateModel(model, options.Base, pattern.Pattern, metadata.Parameters); err != nil {
+		return
+	}
+	fmt.Printf("created %s\n", model)
+	return
+}
+
+func getOllamaClient(registry *core.PluginRegistry) (ret *ollama.Client, err error) {
+	vendor := registry.VendorManager.FindByName("Ollama")
+	if lazy, ok := vendor.(*ai.LazyVendor); ok {
+		if vendor, err = lazy.Configured(); err != nil {
+			return
+		}
+	}
+	var ok bool
+	if ret, ok = vendor.(*ollama.Client); !ok {
+		err = fmt.Errorf("Ollama is not configured or disabled, run fabric --setup")
+	}
+	return
+}
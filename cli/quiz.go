@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/quiz"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// runQuiz adds the questions of the input to the deck and asks the due and new ones. The answers are read from the
+// terminal, also if the questions were piped in.
+func runQuiz(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	options := currentFlags.Quiz
+
+	deckName := options.Deck
+	if deckName == "" {
+		deckName = options.FromSession
+	}
+	if deckName == "" {
+		deckName = "default"
+	}
+
+	var deck *fsdb.QuizDeck
+	if deck, err = registry.Db.Quizzes.Get(deckName); err != nil {
+		return
+	}
+
+	if options.Stats {
+		printQuizStats(deck)
+		return
+	}
+
+	output := currentFlags.Message
+	if options.FromSession != "" {
+		if output, err = getLastAnswer(registry.Db, options.FromSession); err != nil {
+			return
+		}
+	}
+
+	if strings.TrimSpace(output) != "" {
+		var cards []*fsdb.QuizCard
+		if cards, err = quiz.Parse(output); err != nil {
+			err = fmt.Errorf("%v, use the output of the create_quiz or to_flashcards pattern", err)
+			return
+		}
+		if len(cards) == 0 {
+			err = fmt.Errorf("no questions found, use the output of the create_quiz or to_flashcards pattern")
+			return
+		}
+		fmt.Printf("added %d of %d questions to deck %s\n", deck.AddCards(cards), len(cards), deckName)
+		if err = saveDeck(registry.Db, deck); err != nil {
+			return
+		}
+	}
+
+	cards := quiz.Select(deck, options.Count, time.Now())
+	if len(cards) == 0 {
+		if len(deck.Cards) == 0 {
+			err = fmt.Errorf("deck %s has no questions, pipe the output of create_quiz or to_flashcards to fabric quiz",
+				deckName)
+			return
+		}
+		fmt.Printf("no questions due, the next one is due %s\n", quiz.NextDue(deck).Format("2006-01-02 15:04"))
+		return
+	}
+
+	if !registry.Db.Patterns.Exists(quiz.GradePattern) {
+		err = fmt.Errorf("pattern %s not found, run fabric --updatepatterns", quiz.GradePattern)
+		return
+	}
+
+	var chatter *core.Chatter
+	if chatter, err = registry.GetChatter(currentFlags.Model, false, currentFlags.DryRun); err != nil {
+		return
+	}
+
+	var terminal *os.File
+	if terminal, err = openTerminal(); err != nil {
+		return
+	}
+	if terminal != os.Stdin {
+		defer terminal.Close()
+	}
+	answers := bufio.NewReader(terminal)
+
+	fmt.Println("Answer in your own words, an empty answer shows the solution. Ctrl+D ends the quiz.")
+	run := &fsdb.QuizRun{Time: time.Now()}
+	for i, card := range cards {
+		fmt.Printf("\nQuestion %d/%d", i+1, len(cards))
+		if card.Subject != "" {
+			fmt.Printf(" (%s)", card.Subject)
+		}
+		fmt.Printf("\n%s\n> ", card.Question)
+
+		answer, readErr := answers.ReadString('\n')
+		if readErr != nil && (!errors.Is(readErr, io.EOF) || answer == "") {
+			fmt.Println()
+			break
+		}
+
+		var grade *quiz.Grade
+		if grade, err = gradeAnswer(chatter, currentFlags, card, strings.TrimSpace(answer)); err != nil {
+			return
+		}
+		fmt.Printf("score %d/5: %s\n", grade.Score, grade.Feedback)
+		if grade.Answer != "" {
+			fmt.Printf("answer: %s\n", grade.Answer)
+		}
+
+		if card.Answer == "" {
+			card.Answer = grade.Answer
+		}
+		quiz.Review(card, grade.Score, time.Now())
+		run.Asked++
+		if grade.Score >= quiz.PassingScore {
+			run.Correct++
+		}
+
+		// the progress is kept if the quiz is ended early
+		if err = saveDeck(registry.Db, deck); err != nil {
+			return
+		}
+	}
+
+	if run.Asked == 0 {
+		return
+	}
+	deck.Runs = append(deck.Runs, run)
+	if err = saveDeck(registry.Db, deck); err != nil {
+		return
+	}
+
+	fmt.Printf("\n%d/%d correct", run.Correct, run.Asked)
+	if len(deck.Runs) > 1 {
+		fmt.Printf(", last time %d/%d", deck.Runs[len(deck.Runs)-2].Correct, deck.Runs[len(deck.Runs)-2].Asked)
+	}
+	fmt.Printf(". The next question is due %s\n", quiz.NextDue(deck).Format("2006-01-02 15:04"))
+	return
+}
+
+// gradeAnswer lets the model grade the answer, an empty answer scores 0 without asking the model if the card has an
+// answer
+func gradeAnswer(chatter *core.Chatter, currentFlags *Flags, card *fsdb.QuizCard, answer string) (ret *quiz.Grade, err error) {
+	if answer == "" && card.Answer != "" {
+		ret = &quiz.Grade{Score: 0, Feedback: "no answer", Answer: card.Answer}
+		return
+	}
+
+	var session *fsdb.Session
+	if session, err = chatter.Send(
+		&common.ChatRequest{PatternName: quiz.GradePattern, Message: quiz.BuildGradeInput(card, answer)},
+		currentFlags.BuildChatOptions()); err != nil {
+		return
+	}
+	ret, err = quiz.ParseGrade(session.GetLastMessage().Content)
+	return
+}
+
+func getLastAnswer(db *fsdb.Db, sessionName string) (ret string, err error) {
+	var session *fsdb.Session
+	if session, err = db.Sessions.Get(sessionName); err != nil {
+		return
+	}
+	for i := len(session.Messages) - 1; i >= 0; i-- {
+		if session.Messages[i].Role == goopenai.ChatMessageRoleAssistant {
+			ret = session.Messages[i].Content
+			return
+		}
+	}
+	err = fmt.Errorf("session %s has no answer to take the questions from", sessionName)
+	return
+}
+
+// saveDeck doesn't store anything in ephemeral runs
+func saveDeck(db *fsdb.Db, deck *fsdb.QuizDeck) (err error) {
+	if db.Ephemeral {
+		return
+	}
+	err = db.Quizzes.SaveDeck(deck)
+	return
+}
+
+func printQuizStats(deck *fsdb.QuizDeck) {
+	if len(deck.Runs) == 0 {
+		fmt.Printf("no quizzes taken with deck %s\n", deck.Name)
+		return
+	}
+
+	var due, fresh int
+	now := time.Now()
+	for _, card := range deck.Cards {
+		if card.IsNew() {
+			fresh++
+		} else if !card.Due.After(now) {
+			due++
+		}
+	}
+	fmt.Printf("deck %s: %d questions, %d due, %d new\n", deck.Name, len(deck.Cards), due, fresh)
+	for _, run := range deck.Runs {
+		fmt.Println(run.String())
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/render"
+)
+
+// renderDocument executes the fabric blocks of a Markdown document and writes it back with their outputs
+func renderDocument(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	language := currentFlags.BuildChatRequest("").Language
+	if language == "" {
+		language = registry.Language.DefaultLanguage.Value
+	}
+
+	var chattersMutex sync.Mutex
+	chatters := map[string]*core.Chatter{}
+	getChatter := func(model string) (ret *core.Chatter, err error) {
+		chattersMutex.Lock()
+		defer chattersMutex.Unlock()
+
+		if ret = chatters[model]; ret == nil {
+			if ret, err = registry.GetChatter(model, false, currentFlags.DryRun); err == nil {
+				chatters[model] = ret
+			}
+		}
+		return
+	}
+
+	getBlockChatter := func(block *render.Block) (*core.Chatter, error) {
+		model := block.Model()
+		if model == "" {
+			model = currentFlags.Model
+		}
+		return getChatter(model)
+	}
+
+	renderer := &render.Renderer{
+		Jobs:    currentFlags.Render.Jobs,
+		Force:   currentFlags.Render.Force,
+		DryRun:  currentFlags.DryRun,
+		Cache:   registry.Db.RenderCache,
+		LoadURL: registry.Jina.ScrapeURL,
+		Fingerprint: func(block *render.Block) (ret string, err error) {
+			var chatter *core.Chatter
+			if chatter, err = getBlockChatter(block); err != nil {
+				return
+			}
+
+			var pattern *fsdb.Pattern
+			if pattern, err = registry.Db.Patterns.Get(block.Pattern()); err != nil {
+				return
+			}
+
+			var contextHash string
+			if contextHash, err = getContextHash(block.Context(), registry.Db); err != nil {
+				return
+			}
+			ret = fmt.Sprintf("%s/%s %s %s", chatter.GetVendorName(), chatter.GetModel(),
+				hashContent(pattern.Pattern+pattern.User), contextHash)
+			return
+		},
+		Execute: func(block *render.Block, input string) (ret string, err error) {
+			var chatter *core.Chatter
+			if chatter, err = getBlockChatter(block); err != nil {
+				return
+			}
+
+			opts := currentFlags.BuildChatOptions()
+			if temperature := block.Attributes["temperature"]; temperature != "" {
+				if opts.Temperature, err = strconv.ParseFloat(temperature, 64); err != nil {
+					err = fmt.Errorf("invalid temperature %s", temperature)
+					return
+				}
+			}
+
+			request := &common.ChatRequest{
+				ContextName:      block.Context(),
+				PatternName:      block.Pattern(),
+				PatternVariables: block.Variables(),
+				Message:          input,
+				Language:         language,
+			}
+
+			var session *fsdb.Session
+			if session, err = chatter.Send(request, opts); err != nil {
+				return
+			}
+			ret = session.GetLastMessage().Content
+			return
+		},
+	}
+
+	err = renderer.RenderFile(currentFlags.Render.Args.Document)
+	return
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// RequestFile is a fully resolved request, written with --save-request and run again with --request. The pattern and
+// the context are referenced by name with the hash of their content, so a changed one is noticed. The dynamic
+// variables keep the values they had, e.g. the date.
+type RequestFile struct {
+	FabricVersion string            `json:"fabric_version"`
+	Created       time.Time         `json:"created"`
+	Pattern       string            `json:"pattern,omitempty"`
+	PatternHash   string            `json:"pattern_hash,omitempty"`
+	System        string            `json:"system,omitempty"`
+	Context       string            `json:"context,omitempty"`
+	ContextHash   string            `json:"context_hash,omitempty"`
+	Variables     map[string]string `json:"variables,omitempty"`
+	Dynamic       map[string]string `json:"dynamic_variables,omitempty"`
+	Input         string            `json:"input"`
+	InputSource   string            `json:"input_source,omitempty"`
+	Language      string            `json:"language,omitempty"`
+	Vendor        string            `json:"vendor"`
+	Model         string            `json:"model"`
+	Options       *RequestOptions   `json:"options"`
+}
+
+// RequestOptions are the chat options of a request file
+type RequestOptions struct {
+	Temperature      float64 `json:"temperature"`
+	TopP             float64 `json:"top_p"`
+	PresencePenalty  float64 `json:"presence_penalty"`
+	FrequencyPenalty float64 `json:"frequency_penalty"`
+	Raw              bool    `json:"raw"`
+	Seed             int     `json:"seed"`
+}
+
+// buildRequestFile resolves the request as it is sent by the chatter. The values of the dynamic variables are set on
+// the request too, so that the request is sent as it is saved.
+func buildRequestFile(version string, currentFlags *Flags, chatReq *common.ChatRequest, chatter *core.Chatter,
+	registry *core.PluginRegistry, adHocPattern *fsdb.Pattern) (ret *RequestFile, err error) {
+
+	ret = &RequestFile{
+		FabricVersion: version,
+		Created:       time.Now(),
+		Pattern:       currentFlags.Pattern,
+		System:        currentFlags.System,
+		Context:       chatReq.ContextName,
+		Variables:     chatReq.PatternVariables,
+		Input:         chatReq.Message,
+		InputSource:   chatReq.InputSource,
+		Language:      chatReq.Language,
+		Vendor:        chatter.GetVendorName(),
+		Model:         chatter.GetModel(),
+		Options: &RequestOptions{
+			Temperature:      currentFlags.Temperature,
+			TopP:             currentFlags.TopP,
+			PresencePenalty:  currentFlags.PresencePenalty,
+			FrequencyPenalty: currentFlags.FrequencyPenalty,
+			Raw:              currentFlags.Raw,
+			Seed:             currentFlags.Seed,
+		},
+	}
+
+	if fsdb.IsPatternFile(ret.Pattern) {
+		// the request can be run again from another directory
+		if ret.Pattern, err = filepath.Abs(ret.Pattern); err != nil {
+			return
+		}
+	}
+
+	if currentFlags.DryRun {
+		// the vendor the request would be sent to
+		if vendorChatter, vendorErr := registry.GetChatter(currentFlags.Model, false, false); vendorErr == nil {
+			ret.Vendor, ret.Model = vendorChatter.GetVendorName(), vendorChatter.GetModel()
+		} else {
+			ret.Vendor = ""
+		}
+	}
+
+	if ret.Dynamic, err = chatter.ResolveVariables(chatReq); err != nil {
+		return
+	}
+	chatReq.DynamicVariables = ret.Dynamic
+
+	if ret.PatternHash, err = getPatternHash(currentFlags, registry.Db, adHocPattern); err != nil {
+		return
+	}
+	ret.ContextHash, err = getContextHash(chatReq.ContextName, registry.Db)
+	return
+}
+
+// saveRequestFile writes the request as indented JSON
+func saveRequestFile(path string, request *RequestFile) (err error) {
+	var content []byte
+	if content, err = json.MarshalIndent(request, "", "  "); err != nil {
+		return
+	}
+	if err = os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		err = fmt.Errorf("could not save the request to %s: %v", path, err)
+	}
+	return
+}
+
+// loadRequestFile reads the request file and sets the flags to run it again. The input of the file is used, it is not
+// fetched again.
+func loadRequestFile(currentFlags *Flags) (ret *RequestFile, err error) {
+	var content []byte
+	if content, err = os.ReadFile(currentFlags.Request); err != nil {
+		err = fmt.Errorf("could not read the request file %s: %v", currentFlags.Request, err)
+		return
+	}
+	ret = &RequestFile{}
+	if err = json.Unmarshal(content, ret); err != nil {
+		err = fmt.Errorf("invalid request file %s: %v", currentFlags.Request, err)
+		return
+	}
+	if ret.Pattern == "" && ret.System == "" {
+		err = fmt.Errorf("the request file %s has no pattern or system prompt", currentFlags.Request)
+		return
+	}
+
+	currentFlags.Pattern = ret.Pattern
+	currentFlags.System = ret.System
+	currentFlags.Context = ret.Context
+	currentFlags.PatternVariables = ret.Variables
+	currentFlags.dynamicVariables = ret.Dynamic
+	currentFlags.Message = ret.Input
+	currentFlags.messageSource = "request file " + currentFlags.Request
+	currentFlags.Language = ret.Language
+	currentFlags.Model = ret.Model
+	currentFlags.NoAutoInput = true
+	if ret.Options != nil {
+		currentFlags.Temperature = ret.Options.Temperature
+		currentFlags.TopP = ret.Options.TopP
+		currentFlags.PresencePenalty = ret.Options.PresencePenalty
+		currentFlags.FrequencyPenalty = ret.Options.FrequencyPenalty
+		currentFlags.Raw = ret.Options.Raw
+		currentFlags.Seed = ret.Options.Seed
+	}
+	return
+}
+
+// checkRequestFile returns warnings for everything of the request that differs from when it was saved
+func checkRequestFile(request *RequestFile, version string, currentFlags *Flags, fabricDb *fsdb.Db,
+	adHocPattern *fsdb.Pattern) (ret []string) {
+
+	if request.FabricVersion != "" && request.FabricVersion != version {
+		ret = append(ret, fmt.Sprintf("the request was saved with fabric %s, this is %s", request.FabricVersion, version))
+	}
+	if request.PatternHash != "" {
+		if hash, err := getPatternHash(currentFlags, fabricDb, adHocPattern); err != nil {
+			ret = append(ret, fmt.Sprintf("could not read pattern %s: %v", request.Pattern, err))
+		} else if hash != request.PatternHash {
+			ret = append(ret, fmt.Sprintf("pattern %s changed since the request was saved", request.Pattern))
+		}
+	}
+	if request.ContextHash != "" {
+		if hash, err := getContextHash(request.Context, fabricDb); err != nil {
+			ret = append(ret, fmt.Sprintf("could not read context %s: %v", request.Context, err))
+		} else if hash != request.ContextHash {
+			ret = append(ret, fmt.Sprintf("context %s changed since the request was saved", request.Context))
+		}
+	}
+	return
+}
+
+// getChatterOfRequest returns the chatter with the vendor and the model of the request file
+func getChatterOfRequest(request *RequestFile, registry *core.PluginRegistry, stream bool, dryRun bool) (
+	ret *core.Chatter, err error) {
+
+	if dryRun || request.Vendor == "" {
+		ret, err = registry.GetChatter(request.Model, stream, dryRun)
+		return
+	}
+	if ret, err = registry.GetChatterWithCredentials(request.Vendor, request.Model, nil, false); err != nil {
+		return
+	}
+	ret.Stream = stream
+	return
+}
+
+// getPatternHash returns the hash of the content of the pattern, the one of an installed pattern or of the ad-hoc
+// pattern. An inline system prompt is stored as it is, so it has no hash.
+func getPatternHash(currentFlags *Flags, fabricDb *fsdb.Db, adHocPattern *fsdb.Pattern) (ret string, err error) {
+	if currentFlags.System != "" || currentFlags.Pattern == "" {
+		return
+	}
+	pattern := adHocPattern
+	if pattern == nil {
+		if pattern, err = fabricDb.Patterns.Get(currentFlags.Pattern); err != nil {
+			return
+		}
+	}
+	ret = hashContent(pattern.Pattern + pattern.User)
+	return
+}
+
+func getContextHash(name string, fabricDb *fsdb.Db) (ret string, err error) {
+	if name == "" {
+		return
+	}
+	var context *fsdb.Context
+	if context, err = fabricDb.Contexts.Get(name); err != nil {
+		return
+	}
+	ret = hashContent(context.Content)
+	return
+}
+
+func hashContent(content string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+}
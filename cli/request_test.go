@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestFile(t *testing.T) {
+	fabricDb := fsdb.NewDb(t.TempDir())
+	assert.NoError(t, fabricDb.Patterns.Configure())
+	assert.NoError(t, fabricDb.Contexts.Configure())
+	assert.NoError(t, os.MkdirAll(filepath.Join(fabricDb.Patterns.Dir, "summarize"), os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(fabricDb.Patterns.Dir, "summarize", "system.md"), []byte("Summarize."), 0644))
+	assert.NoError(t, fabricDb.Contexts.Save("work", []byte("I am a developer.")))
+
+	saved := &Flags{Pattern: "summarize", Context: "work"}
+	patternHash, err := getPatternHash(saved, fabricDb, nil)
+	assert.NoError(t, err)
+	contextHash, err := getContextHash("work", fabricDb)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "req.json")
+	assert.NoError(t, saveRequestFile(path, &RequestFile{
+		FabricVersion: "v1.0.0", Pattern: "summarize", PatternHash: patternHash, Context: "work",
+		ContextHash: contextHash, Variables: map[string]string{"#points": "3"}, Input: "the input",
+		Dynamic: map[string]string{"date": "2024-03-09"},
+		Vendor:  "OpenAI", Model: "gpt-4o", Options: &RequestOptions{Temperature: 0.2, TopP: 0.9, Seed: 42},
+	}))
+
+	currentFlags := &Flags{Request: path, Message: "ignored", Temperature: 0.7}
+	request, err := loadRequestFile(currentFlags)
+	assert.NoError(t, err)
+	assert.Equal(t, "summarize", currentFlags.Pattern)
+	assert.Equal(t, "the input", currentFlags.Message)
+	assert.Equal(t, "gpt-4o", currentFlags.Model)
+	assert.Equal(t, 0.2, currentFlags.Temperature)
+	assert.Equal(t, 42, currentFlags.Seed)
+	assert.Equal(t, "3", currentFlags.PatternVariables["#points"])
+	assert.True(t, currentFlags.NoAutoInput)
+	assert.Equal(t, map[string]string{"date": "2024-03-09"}, currentFlags.BuildChatRequest("").DynamicVariables)
+
+	assert.Empty(t, checkRequestFile(request, "v1.0.0", currentFlags, fabricDb, nil))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(fabricDb.Patterns.Dir, "summarize", "system.md"), []byte("Summarize briefly."), 0644))
+	assert.NoError(t, fabricDb.Contexts.Save("work", []byte("I am a researcher.")))
+	assert.Len(t, checkRequestFile(request, "v1.1.0", currentFlags, fabricDb, nil), 3)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/shell"
+)
+
+// printShellInit prints the shell integration, it is evaluated by the shell on startup
+func printShellInit(currentFlags *Flags) (err error) {
+	fabricPath, executableErr := os.Executable()
+	if executableErr != nil {
+		fabricPath = "fabric"
+	}
+
+	var script string
+	if script, err = shell.Script(currentFlags.ShellInit.Args.Shell, fabricPath, currentFlags.ShellInit.Model); err != nil {
+		return
+	}
+	fmt.Print(script)
+	return
+}
+
+// runShellCommand generates a command from the description on the command line or explains the command line. The
+// generated command is printed for the widget, it is never executed.
+func runShellCommand(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	options := currentFlags.ShellCmd
+
+	patternName := shell.GeneratePattern
+	message := shell.BuildGenerateInput(options.Args.Line, options.Shell, currentFlags.Message)
+	if options.Explain {
+		patternName = shell.ExplainPattern
+		message = shell.BuildExplainInput(options.Args.Line, options.Shell)
+	}
+
+	if !registry.Db.Patterns.Exists(patternName) {
+		err = fmt.Errorf("pattern %s not found, run fabric --updatepatterns", patternName)
+		return
+	}
+
+	request := &common.ChatRequest{PatternName: patternName, Message: message}
+	opts := currentFlags.BuildChatOptions()
+
+	var chatter *core.Chatter
+	var cheap bool
+	if chatter, cheap, err = getShellChatter(currentFlags, registry); err != nil {
+		return
+	}
+
+	var session *fsdb.Session
+	if session, err = chatter.Send(request, opts); err != nil && cheap {
+		// the vendor may not serve its small model, e.g. an Ollama server without it, the default model is used then
+		if chatter, err = registry.GetChatter("", false, currentFlags.DryRun); err != nil {
+			return
+		}
+		session, err = chatter.Send(request, opts)
+	}
+	if err != nil {
+		return
+	}
+
+	output := session.GetLastMessage().Content
+	if !options.Explain {
+		output = shell.CleanCommand(output)
+	}
+	fmt.Println(output)
+	return
+}
+
+// getShellChatter uses the chosen model or a small model of the default vendor, cheap tells whether it is the small
+// one. The vendor is not asked for its models, it would delay the widget on every key press.
+func getShellChatter(currentFlags *Flags, registry *core.PluginRegistry) (ret *core.Chatter, cheap bool, err error) {
+	if currentFlags.Model != "" {
+		ret, err = registry.GetChatter(currentFlags.Model, false, currentFlags.DryRun)
+		return
+	}
+
+	defaults := registry.GetDefaultModel()
+	model := shell.CheapModel(defaults.Vendor)
+	if model == "" || currentFlags.DryRun {
+		ret, err = registry.GetChatter(model, false, currentFlags.DryRun)
+		return
+	}
+	if ret, err = registry.GetChatterWithCredentials(defaults.Vendor, model, nil, false); err != nil {
+		return
+	}
+	cheap = true
+	return
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/sweep"
+)
+
+// runSweep sends the request for every combination of the --sweep values and prints the labeled outputs or saves them
+// with --sweep-output. With --sweep-judge every output is scored by the judge pattern.
+func runSweep(currentFlags *Flags, registry *core.PluginRegistry, chatReq *common.ChatRequest) (err error) {
+	var axes []*sweep.Axis
+	if axes, err = sweep.ParseAxes(currentFlags.Sweep); err != nil {
+		return
+	}
+	runs := sweep.Combinations(axes)
+
+	var chattersMutex sync.Mutex
+	chatters := map[string]*core.Chatter{}
+	getChatter := func(model string) (ret *core.Chatter, err error) {
+		chattersMutex.Lock()
+		defer chattersMutex.Unlock()
+
+		if ret = chatters[model]; ret == nil {
+			if ret, err = registry.GetChatter(model, false, currentFlags.DryRun); err == nil {
+				chatters[model] = ret
+			}
+		}
+		return
+	}
+
+	execute := func(run *sweep.Run) (ret string, err error) {
+		model := currentFlags.Model
+		if value, ok := run.Values[sweep.ParamModel]; ok {
+			model = value
+		}
+		var chatter *core.Chatter
+		if chatter, err = getChatter(model); err != nil {
+			return
+		}
+
+		opts := currentFlags.BuildChatOptions()
+		for param, option := range map[string]*float64{
+			sweep.ParamTemperature:      &opts.Temperature,
+			sweep.ParamTopP:             &opts.TopP,
+			sweep.ParamPresencePenalty:  &opts.PresencePenalty,
+			sweep.ParamFrequencyPenalty: &opts.FrequencyPenalty,
+		} {
+			if value, ok := run.Float(param); ok {
+				*option = value
+			}
+		}
+		if value, ok := run.Float(sweep.ParamSeed); ok {
+			opts.Seed = int(value)
+		}
+
+		request := *chatReq
+		var session *fsdb.Session
+		if session, err = chatter.Send(&request, opts); err != nil {
+			return
+		}
+		ret = session.GetLastMessage().Content
+		return
+	}
+
+	var judge func(run *sweep.Run) (float64, string, error)
+	if currentFlags.SweepJudge != "" {
+		if judge, err = buildSweepJudge(currentFlags, registry, chatReq, getChatter); err != nil {
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "running %d combinations of %s\n", len(runs), sweepParams(axes))
+	sweep.Execute(runs, currentFlags.SweepJobs, execute, judge)
+
+	result := &sweep.Result{
+		Pattern: currentFlags.Pattern,
+		Judge:   currentFlags.SweepJudge,
+		Created: time.Now(),
+		Axes:    axes,
+		Runs:    runs,
+	}
+	if currentFlags.SweepOutput != "" {
+		if err = result.Save(currentFlags.SweepOutput); err != nil {
+			return
+		}
+		fmt.Printf("saved %d runs to %s\n", len(runs), currentFlags.SweepOutput)
+	} else {
+		printSweep(result)
+	}
+
+	if best := result.Best(); best != nil {
+		fmt.Printf("best: %s (score %g)\n", best.Label, *best.Score)
+	}
+
+	var failed int
+	for _, run := range runs {
+		if run.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		err = fmt.Errorf("%d of %d runs failed", failed, len(runs))
+	}
+	return
+}
+
+// buildSweepJudge returns the judge that scores an output with the judge pattern, it gets the prompt, the input and
+// the output. The judge uses the model of --model, also if the model is swept.
+func buildSweepJudge(currentFlags *Flags, registry *core.PluginRegistry, chatReq *common.ChatRequest,
+	getChatter func(model string) (*core.Chatter, error)) (ret func(run *sweep.Run) (float64, string, error), err error) {
+
+	judgeRequest := &common.ChatRequest{PatternName: currentFlags.SweepJudge, Language: chatReq.Language}
+	if fsdb.IsPatternFile(currentFlags.SweepJudge) {
+		var pattern *fsdb.Pattern
+		if pattern, err = registry.Db.Patterns.GetFromFile(currentFlags.SweepJudge); err != nil {
+			return
+		}
+		setAdHocPattern(judgeRequest, pattern)
+	} else if !registry.Db.Patterns.Exists(currentFlags.SweepJudge) {
+		err = fmt.Errorf("judge pattern %s not found", currentFlags.SweepJudge)
+		return
+	}
+
+	prompt := chatReq.SystemPrompt
+	if chatReq.PatternName != "" {
+		var pattern *fsdb.Pattern
+		if pattern, err = registry.Db.Patterns.GetApplyVariables(chatReq.PatternName, chatReq.PatternVariables); err != nil {
+			return
+		}
+		prompt = pattern.Pattern
+	}
+
+	ret = func(run *sweep.Run) (score float64, judgement string, err error) {
+		var chatter *core.Chatter
+		if chatter, err = getChatter(currentFlags.Model); err != nil {
+			return
+		}
+
+		request := *judgeRequest
+		request.Message = sweep.BuildJudgeInput(prompt, chatReq.Message, run.Output)
+		var session *fsdb.Session
+		if session, err = chatter.Send(&request, &common.ChatOptions{Temperature: 0, TopP: 1}); err != nil {
+			return
+		}
+		judgement = session.GetLastMessage().Content
+		score, err = sweep.ParseScore(judgement)
+		return
+	}
+	return
+}
+
+func printSweep(result *sweep.Result) {
+	for _, run := range result.Runs {
+		fmt.Printf("## %s\n\n", run.Label)
+		if run.Score != nil {
+			fmt.Printf("score: %g\n\n", *run.Score)
+		}
+		if run.Error != "" {
+			fmt.Printf("error: %s\n\n", run.Error)
+		}
+		if run.Output != "" {
+			fmt.Printf("%s\n\n", strings.TrimSpace(run.Output))
+		}
+	}
+}
+
+func sweepParams(axes []*sweep.Axis) string {
+	var params []string
+	for _, axis := range axes {
+		params = append(params, axis.Param)
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+)
+
+// openTerminal returns the terminal to talk to the user, stdin and stdout may be redirected
+func openTerminal() (ret *os.File, err error) {
+	if ret, err = os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		return
+	}
+	if info, statErr := os.Stdin.Stat(); statErr == nil && info.Mode()&os.ModeCharDevice != 0 {
+		ret, err = os.Stdin, nil
+		return
+	}
+	err = fmt.Errorf("there is no terminal to talk to the user: %v", err)
+	return
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/core"
+)
+
+// runVendorsCommand runs the vendor management commands, the changes are written to the .env file
+func runVendorsCommand(currentFlags *Flags, registry *core.PluginRegistry) (err error) {
+	options := currentFlags.Vendors
+
+	var result any
+	switch currentFlags.Command {
+	case "vendors list":
+		result = registry.GetVendorStatuses()
+	case "vendors enable":
+		result, err = registry.EnableVendor(options.Enable.Args.Name)
+	case "vendors disable":
+		result, err = registry.DisableVendor(options.Disable.Args.Name)
+	case "vendors remove":
+		result, err = registry.RemoveVendor(options.Remove.Args.Name)
+	case "vendors test":
+		var test *core.VendorTest
+		if test, err = registry.TestVendor(options.Test.Args.Name); err == nil && !test.Ok && !options.Json {
+			err = fmt.Errorf("vendor %s failed after %v: %s", test.Name, test.Duration.Round(time.Millisecond), test.Error)
+		}
+		result = test
+	case "vendors set-default":
+		result, err = registry.SetDefaultVendor(options.SetDefault.Args.Name, options.SetDefault.Args.Model)
+	default:
+		err = fmt.Errorf("unknown command %s", currentFlags.Command)
+	}
+	if err != nil {
+		return
+	}
+
+	if options.Json {
+		var output []byte
+		if output, err = json.MarshalIndent(result, "", "  "); err != nil {
+			return
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	switch value := result.(type) {
+	case []*core.VendorStatus:
+		for _, status := range value {
+			fmt.Println(formatVendorStatus(status))
+		}
+	case *core.VendorStatus:
+		fmt.Println(formatVendorStatus(value))
+	case *core.VendorTest:
+		fmt.Printf("%s: ok, %d models in %v\n", value.Name, value.Models, value.Duration.Round(time.Millisecond))
+	case *core.DefaultModel:
+		fmt.Printf("default: %s %s\n", value.Vendor, value.Model)
+	}
+	return
+}
+
+func formatVendorStatus(status *core.VendorStatus) string {
+	var states []string
+	if status.Configured {
+		states = append(states, "configured")
+	} else {
+		states = append(states, "not configured")
+	}
+	if !status.Enabled {
+		states = append(states, "disabled")
+	}
+	if status.Default {
+		states = append(states, "default")
+	}
+	return fmt.Sprintf("%-12s %s", status.Name, strings.Join(states, ", "))
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/danielmiessler/fabric/plugins/tools/converter"
+)
+
+const (
+	WatchOutputPrint     = "print"
+	WatchOutputClipboard = "clipboard"
+	WatchOutputJournal   = "journal"
+)
+
+// ClipboardWatcher polls the clipboard and calls OnContent for every new content that passes the filters
+type ClipboardWatcher struct {
+	Interval  time.Duration
+	MinLength int
+	URLsOnly  bool
+
+	Read func() (string, error)
+	// Load replaces the accepted content before the min length is checked, e.g. a URL by the page, it may be nil
+	Load      func(content string) (string, error)
+	OnContent func(content string) (result string, err error)
+	Output    func(result string) error
+
+	last string
+}
+
+func NewClipboardWatcher(interval time.Duration, minLength int, urlsOnly bool) (ret *ClipboardWatcher) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ret = &ClipboardWatcher{
+		Interval:  interval,
+		MinLength: minLength,
+		URLsOnly:  urlsOnly,
+		Read:      ReadFromClipboard,
+	}
+	return
+}
+
+// Watch runs the polling loop until the context is canceled
+func (o *ClipboardWatcher) Watch(ctx context.Context) (err error) {
+	// the content present at start is not considered new
+	o.last, _ = o.Read()
+
+	ticker := time.NewTicker(o.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if watchErr := o.check(); watchErr != nil {
+				fmt.Fprintf(os.Stderr, "clipboard watch: %v\n", watchErr)
+			}
+		}
+	}
+}
+
+func (o *ClipboardWatcher) check() (err error) {
+	var content string
+	if content, err = o.Read(); err != nil {
+		return
+	}
+
+	if content == o.last {
+		return
+	}
+	o.last = content
+
+	if !o.Accept(content) {
+		return
+	}
+
+	if o.Load != nil {
+		if content, err = o.Load(content); err != nil {
+			return
+		}
+		if len(strings.TrimSpace(content)) < o.MinLength {
+			return
+		}
+	}
+
+	var result string
+	if result, err = o.OnContent(content); err != nil {
+		return
+	}
+
+	// remember our own output to not process it again, when it is put back to the clipboard
+	o.last = result
+	err = o.Output(result)
+	return
+}
+
+// Accept checks the content against the configured filters, the min length of URLs is checked on the loaded content
+func (o *ClipboardWatcher) Accept(content string) (ret bool) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return
+	}
+
+	if o.URLsOnly {
+		ret = IsURL(content)
+		return
+	}
+
+	ret = len(content) >= o.MinLength
+	return
+}
+
+// IsURL reports whether the content is a single http(s) URL
+func IsURL(content string) (ret bool) {
+	content = strings.TrimSpace(content)
+	if strings.ContainsAny(content, " \n\t") {
+		return
+	}
+
+	parsed, err := url.Parse(content)
+	ret = err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+	return
+}
+
+// ReadFromClipboard returns the clipboard text. HTML content is converted into a clean, readable view.
+func ReadFromClipboard() (ret string, err error) {
+	if html := readHtmlFromClipboard(); html != "" {
+		if ret, err = converter.HtmlReadability(html); err == nil && strings.TrimSpace(ret) != "" {
+			return
+		}
+	}
+
+	if ret, err = clipboard.ReadAll(); err != nil {
+		err = fmt.Errorf("could not read from clipboard: %v", err)
+	}
+	return
+}
+
+// readHtmlFromClipboard asks the X11 or Wayland clipboard for the text/html target, if available
+func readHtmlFromClipboard() (ret string) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	var cmd *exec.Cmd
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if !hasHtmlTarget(exec.Command("wl-paste", "--list-types")) {
+			return
+		}
+		cmd = exec.Command("wl-paste", "--no-newline", "--type", "text/html")
+	} else {
+		if !hasHtmlTarget(exec.Command("xclip", "-out", "-selection", "clipboard", "-target", "TARGETS")) {
+			return
+		}
+		cmd = exec.Command("xclip", "-out", "-selection", "clipboard", "-target", "text/html")
+	}
+
+	if out, err := cmd.Output(); err == nil {
+		ret = string(out)
+	}
+	return
+}
+
+func hasHtmlTarget(cmd *exec.Cmd) (ret bool) {
+	if out, err := cmd.Output(); err == nil {
+		ret = strings.Contains(string(out), "text/html")
+	}
+	return
+}
+
+// AppendToJournal appends the result with a timestamp header to the journal file
+func AppendToJournal(result string, fileName string) (err error) {
+	var file *os.File
+	if file, err = os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		err = fmt.Errorf("error opening journal file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	if _, err = fmt.Fprintf(file, "## %s\n\n%s\n\n", time.Now().Format(time.RFC1123), strings.TrimSpace(result)); err != nil {
+		err = fmt.Errorf("error writing to journal file: %v", err)
+	}
+	return
+}
+
+// watchClipboard runs the pattern on every new clipboard content until interrupted. Streamed results are printed while
+// they are received. With --watch-urls-only, loadURL fetches the content of the copied URLs.
+func watchClipboard(currentFlags *Flags, streamed bool, loadURL func(url string) (string, error),
+	send func(message string) (string, error)) (err error) {
+	if currentFlags.Pattern == "" && currentFlags.System == "" {
+		err = fmt.Errorf("--watch-clipboard requires a pattern (-p) or a system prompt (--system)")
+		return
+	}
+
+	if currentFlags.WatchOutput == WatchOutputJournal && currentFlags.WatchJournal == "" {
+		err = fmt.Errorf("--watch-output=journal requires a journal file (--watch-journal)")
+		return
+	}
+
+	watcher := NewClipboardWatcher(currentFlags.WatchInterval, currentFlags.WatchMinLength, currentFlags.WatchURLsOnly)
+	watcher.OnContent = send
+	if currentFlags.WatchURLsOnly {
+		watcher.Load = loadURL
+	}
+
+	switch currentFlags.WatchOutput {
+	case WatchOutputClipboard:
+		watcher.Output = CopyToClipboard
+	case WatchOutputJournal:
+		watcher.Output = func(result string) error {
+			return AppendToJournal(result, currentFlags.WatchJournal)
+		}
+	default:
+		watcher.Output = func(result string) (err error) {
+			if !streamed {
+				fmt.Println(result)
+			}
+			fmt.Println("---")
+			return
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Watching the clipboard for pattern %s, press Ctrl+C to stop\n", currentFlags.Pattern)
+	err = watcher.Watch(ctx)
+	return
+}
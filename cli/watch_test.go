@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClipboardWatcher_Accept(t *testing.T) {
+	watcher := NewClipboardWatcher(0, 10, false)
+	assert.False(t, watcher.Accept("   "))
+	assert.False(t, watcher.Accept("short"))
+	assert.True(t, watcher.Accept("long enough content"))
+
+	watcher.URLsOnly = true
+	assert.False(t, watcher.Accept("long enough content"))
+	assert.True(t, watcher.Accept(" https://example.com/article "))
+}
+
+func TestIsURL(t *testing.T) {
+	assert.True(t, IsURL("https://example.com/a?b=c"))
+	assert.True(t, IsURL("http://localhost:8080"))
+	assert.False(t, IsURL("ftp://example.com"))
+	assert.False(t, IsURL("see https://example.com"))
+	assert.False(t, IsURL("example.com"))
+}
+
+func TestClipboardWatcher_Check(t *testing.T) {
+	clipboardContent := "first content"
+	var processed, outputs []string
+
+	watcher := NewClipboardWatcher(0, 0, false)
+	watcher.Read = func() (string, error) { return clipboardContent, nil }
+	watcher.OnContent = func(content string) (string, error) {
+		processed = append(processed, content)
+		return strings.ToUpper(content), nil
+	}
+	watcher.Output = func(result string) error {
+		outputs = append(outputs, result)
+		// simulate the clipboard output mode
+		clipboardContent = result
+		return nil
+	}
+
+	watcher.last = clipboardContent
+	assert.NoError(t, watcher.check())
+	assert.Empty(t, processed)
+
+	clipboardContent = "second content"
+	assert.NoError(t, watcher.check())
+	assert.NoError(t, watcher.check())
+	assert.Equal(t, []string{"second content"}, processed)
+	assert.Equal(t, []string{"SECOND CONTENT"}, outputs)
+
+	// the min length of URLs applies to the loaded page
+	watcher.URLsOnly, watcher.MinLength = true, 10
+	watcher.Load = func(url string) (string, error) { return "page of " + strings.TrimSpace(url), nil }
+	clipboardContent = "https://a.b"
+	assert.NoError(t, watcher.check())
+	assert.Equal(t, []string{"second content", "page of https://a.b"}, processed)
+
+	watcher.Load = func(url string) (string, error) { return "short", nil }
+	clipboardContent = "https://example.com/article"
+	assert.NoError(t, watcher.check())
+	assert.Len(t, processed, 2)
+}
+
+func TestAppendToJournal(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "journal.md")
+	assert.NoError(t, AppendToJournal("first", fileName))
+	assert.NoError(t, AppendToJournal("second", fileName))
+
+	content, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "first\n")
+	assert.Contains(t, string(content), "second\n")
+	assert.Equal(t, 2, strings.Count(string(content), "## "))
+}
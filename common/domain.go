@@ -14,9 +14,18 @@ type ChatRequest struct {
 	SessionName      string
 	PatternName      string
 	PatternVariables map[string]string
-	Message          string
-	Language         string
-	Meta             string
+	// SystemPrompt is used like the pattern of PatternName, e.g. the one of a pattern file or an inline prompt
+	SystemPrompt string
+	// UserPrompt is put before the message, e.g. the user.md of a pattern file
+	UserPrompt string
+	Message    string
+	Language   string
+	Meta       string
+	// InputSource describes where the message comes from, e.g. stdin or a URL
+	InputSource string
+	// DynamicVariables are the values of dynamic variables like {{date}}, they are used instead of resolving them, e.g.
+	// to run a saved request again
+	DynamicVariables map[string]string
 }
 
 type ChatOptions struct {
@@ -6,6 +6,8 @@ import (
 	"github.com/danielmiessler/fabric/common"
 	"github.com/danielmiessler/fabric/plugins/ai"
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/privacy"
+	"github.com/danielmiessler/fabric/plugins/tools/variables"
 	goopenai "github.com/sashabaranov/go-openai"
 	"strings"
 )
@@ -18,11 +20,19 @@ type Chatter struct {
 	Stream bool
 	DryRun bool
 
-	model  string
-	vendor ai.Vendor
+	model   string
+	vendor  ai.Vendor
+	privacy *privacy.Privacy
+	// variables resolves the dynamic variables of the patterns and contexts, e.g. {{date}}
+	variables *variables.Resolver
 }
 
 func (o *Chatter) Send(request *common.ChatRequest, opts *common.ChatOptions) (session *fsdb.Session, err error) {
+	if request.SessionName != "" {
+		unlock := o.db.Sessions.Lock(request.SessionName)
+		defer unlock()
+	}
+
 	if session, err = o.BuildSession(request, opts.Raw); err != nil {
 		return
 	}
@@ -58,30 +68,95 @@ func (o *Chatter) Send(request *common.ChatRequest, opts *common.ChatOptions) (s
 	}
 
 	session.Append(&common.Message{Role: goopenai.ChatMessageRoleAssistant, Content: message})
+	err = o.saveSession(session, request)
+	return
+}
 
-	if session.Name != "" {
-		err = o.db.Sessions.SaveSession(session)
+// Picker chooses the answer from the candidates, it may also edit or merge them
+type Picker func(candidates []string) (string, error)
+
+// SendCandidates requests n answers and stores only the picked one as assistant message. The candidates that were not
+// picked are kept in a meta message before it, meta messages are not sent to the vendor.
+func (o *Chatter) SendCandidates(request *common.ChatRequest, opts *common.ChatOptions, n int, pick Picker) (
+	session *fsdb.Session, err error) {
+	if request.SessionName != "" {
+		unlock := o.db.Sessions.Lock(request.SessionName)
+		defer unlock()
+	}
+
+	if session, err = o.BuildSession(request, opts.Raw); err != nil {
+		return
+	}
+
+	if opts.Model == "" {
+		opts.Model = o.model
+	}
+
+	var candidates []string
+	if candidates, err = ai.SendCandidates(context.Background(), o.vendor, session.GetVendorMessages(), opts, n); err != nil {
+		session = nil
+		return
+	}
+
+	var message string
+	if message, err = pick(candidates); err != nil {
+		session = nil
+		return
+	}
+	if strings.TrimSpace(message) == "" {
+		session = nil
+		err = fmt.Errorf("no candidate picked")
+		return
 	}
+
+	if alternatives := Alternatives(candidates, message); alternatives != "" {
+		session.Append(&common.Message{Role: common.ChatMessageRoleMeta, Content: alternatives})
+	}
+	session.Append(&common.Message{Role: goopenai.ChatMessageRoleAssistant, Content: message})
+	err = o.saveSession(session, request)
 	return
 }
 
-func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *fsdb.Session, err error) {
-	if request.SessionName != "" {
-		var sess *fsdb.Session
-		if sess, err = o.db.Sessions.Get(request.SessionName); err != nil {
-			err = fmt.Errorf("could not find session %s: %v", request.SessionName, err)
-			return
+// Alternatives describes the candidates that were not picked, empty if there are none
+func Alternatives(candidates []string, picked string) string {
+	var alternatives []string
+	for _, candidate := range candidates {
+		if candidate != picked {
+			alternatives = append(alternatives, strings.TrimSpace(candidate))
 		}
-		session = sess
-	} else {
-		session = &fsdb.Session{}
 	}
+	if len(alternatives) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("alternatives not picked (%d):\n\n%s", len(alternatives), strings.Join(alternatives, "\n\n---\n\n"))
+}
 
-	if request.Meta != "" {
-		session.Append(&common.Message{Role: common.ChatMessageRoleMeta, Content: request.Meta})
+func (o *Chatter) saveSession(session *fsdb.Session, request *common.ChatRequest) (err error) {
+	// ephemeral runs use the session, but don't save it
+	if session.Name != "" && !o.db.Ephemeral {
+		if o.privacy != nil {
+			o.privacy.ProtectSession(session, request.InputSource)
+		}
+		err = o.db.Sessions.SaveSession(session)
 	}
+	return
+}
 
-	var contextContent string
+// ResolveVariables returns the values of the dynamic variables the request references, e.g. to record them with it
+func (o *Chatter) ResolveVariables(request *common.ChatRequest) (ret map[string]string, err error) {
+	if o.variables == nil {
+		return
+	}
+	var contextContent, patternContent string
+	if contextContent, patternContent, err = o.loadPrompts(request); err != nil {
+		return
+	}
+	ret = o.variables.Resolve(o.variablesRequest(request), contextContent, patternContent, request.UserPrompt)
+	return
+}
+
+// loadPrompts returns the contents of the context and the pattern of the request, before any variable is applied
+func (o *Chatter) loadPrompts(request *common.ChatRequest) (contextContent string, patternContent string, err error) {
 	if request.ContextName != "" {
 		var ctx *fsdb.Context
 		if ctx, err = o.db.Contexts.Get(request.ContextName); err != nil {
@@ -91,24 +166,63 @@ func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *
 		contextContent = ctx.Content
 	}
 
-	var patternContent string
 	if request.PatternName != "" {
 		var pattern *fsdb.Pattern
-		if pattern, err = o.db.Patterns.GetApplyVariables(request.PatternName, request.PatternVariables); err != nil {
+		if pattern, err = o.db.Patterns.Get(request.PatternName); err != nil {
 			err = fmt.Errorf("could not find pattern %s: %v", request.PatternName, err)
 			return
 		}
+		patternContent = pattern.Pattern
+	} else {
+		patternContent = request.SystemPrompt
+	}
+	return
+}
 
-		if pattern.Pattern != "" {
-			patternContent = pattern.Pattern
+func (o *Chatter) variablesRequest(request *common.ChatRequest) *variables.Request {
+	return &variables.Request{Model: o.model, Input: request.Message, InputSource: request.InputSource,
+		Values: request.DynamicVariables}
+}
+
+func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *fsdb.Session, err error) {
+	if request.SessionName != "" {
+		var sess *fsdb.Session
+		if sess, err = o.db.Sessions.Get(request.SessionName); err != nil {
+			err = fmt.Errorf("could not find session %s: %v", request.SessionName, err)
+			return
 		}
+		session = sess
+	} else {
+		session = &fsdb.Session{}
+	}
+
+	if request.Meta != "" {
+		session.Append(&common.Message{Role: common.ChatMessageRoleMeta, Content: request.Meta})
 	}
 
+	var contextContent, patternContent string
+	if contextContent, patternContent, err = o.loadPrompts(request); err != nil {
+		return
+	}
+
+	// the dynamic variables are resolved before the variables of the request are applied, so that values given by the
+	// user, e.g. {{hostname}}, are never resolved
+	userPrompt := request.UserPrompt
+	if o.variables != nil {
+		resolved := o.variables.Apply(o.variablesRequest(request), contextContent, patternContent, userPrompt)
+		contextContent, patternContent, userPrompt = resolved[0], resolved[1], resolved[2]
+	}
+	patternContent = fsdb.ApplyVariables(patternContent, request.PatternVariables)
+	userPrompt = fsdb.ApplyVariables(userPrompt, request.PatternVariables)
+
 	systemMessage := strings.TrimSpace(contextContent) + strings.TrimSpace(patternContent)
 	if request.Language != "" {
 		systemMessage = fmt.Sprintf("%s. Please use the language '%s' for the output.", systemMessage, request.Language)
 	}
 	userMessage := strings.TrimSpace(request.Message)
+	if userPrompt = strings.TrimSpace(userPrompt); userPrompt != "" {
+		userMessage = strings.TrimSpace(userPrompt + "\n" + userMessage)
+	}
 
 	if raw {
 		// use the user role instead of the system role in raw mode
@@ -131,3 +245,13 @@ func (o *Chatter) BuildSession(request *common.ChatRequest, raw bool) (session *
 	}
 	return
 }
+
+// GetModel returns the model the requests are sent to
+func (o *Chatter) GetModel() string {
+	return o.model
+}
+
+// GetVendorName returns the name of the vendor the requests are sent to
+func (o *Chatter) GetVendorName() string {
+	return o.vendor.GetName()
+}
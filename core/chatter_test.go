@@ -0,0 +1,66 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/variables"
+)
+
+func TestBuildSession_Variables(t *testing.T) {
+	dir := t.TempDir()
+	db := fsdb.NewDb(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "patterns", "greet"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "patterns", "greet", "system.md"),
+		[]byte("Greet #name, it is {{model}} and {{secret}}."), 0644); err != nil {
+		t.Fatal(err)
+	}
+	providers := filepath.Join(dir, variables.ProvidersFile)
+	if err := os.WriteFile(providers, []byte(`{"secret": "echo resolved"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chatter := &Chatter{db: db, model: "llama3", variables: variables.NewResolver(providers)}
+	session, err := chatter.BuildSession(&common.ChatRequest{
+		PatternName:      "greet",
+		PatternVariables: map[string]string{"#name": "{{secret}} on {{hostname}}"},
+		Message:          "hi",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the values given by the user are not resolved
+	want := "Greet {{secret}} on {{hostname}}, it is llama3 and resolved."
+	if got := session.Messages[0].Content; !strings.Contains(got, want) {
+		t.Errorf("system message = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSession_RecordedVariables(t *testing.T) {
+	dir := t.TempDir()
+	db := fsdb.NewDb(dir)
+	chatter := &Chatter{db: db, model: "llama3", variables: variables.NewResolver("")}
+	request := &common.ChatRequest{SystemPrompt: "Today is {{date}}, the model is {{model}}.", Message: "hi"}
+
+	resolved, err := chatter.ResolveVariables(request)
+	if err != nil || len(resolved) != 2 {
+		t.Fatalf("ResolveVariables() = %v, %v", resolved, err)
+	}
+
+	// a saved request is sent again with the recorded values
+	request.DynamicVariables = map[string]string{"date": "2024-03-09", "model": "gpt-4o"}
+	session, err := chatter.BuildSession(request, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := session.Messages[0].Content, "Today is 2024-03-09, the model is gpt-4o."; got != want {
+		t.Errorf("system message = %q, want %q", got, want)
+	}
+}
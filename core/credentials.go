@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/ai"
+)
+
+// VendorCredentials are vendor settings given with a single request, e.g. by a user of a shared server who is billed
+// on the own account. They are used for that request only and never saved.
+type VendorCredentials struct {
+	Vendor     string
+	ApiKey     string
+	ApiBaseURL string
+}
+
+// vendorConfigurableWith is implemented by the vendors based on plugins.PluginBase
+type vendorConfigurableWith interface {
+	ConfigureWith(values map[string]string) error
+}
+
+// GetChatterWithCredentials creates a chatter like GetChatter, the vendors with credentials are new instances that are
+// used by this chatter only. The vendor is chosen by name, or by the model, or it is the only vendor with credentials.
+// If credentialsRequired is set, the chatter never uses a vendor with the credentials of the server.
+func (o *PluginRegistry) GetChatterWithCredentials(
+	vendorName string, model string, credentials []*VendorCredentials, credentialsRequired bool) (ret *Chatter, err error) {
+
+	requestVendors := map[string]ai.Vendor{}
+	for _, vendorCredentials := range credentials {
+		var vendor ai.Vendor
+		if vendor, err = o.newVendorWithCredentials(vendorCredentials); err != nil {
+			return
+		}
+		requestVendors[vendor.GetName()] = vendor
+	}
+
+	if model == "" {
+		defaults := o.GetDefaultModel()
+		model = defaults.Model
+		if vendorName == "" {
+			vendorName = defaults.Vendor
+		}
+	}
+
+	if vendorName == "" {
+		// the models of the configured vendors are cached, the vendors of the request are not asked for their models
+		if models, modelsErr := o.VendorManager.GetModels(); modelsErr == nil {
+			vendorName = models.FindGroupsByItemFirst(model)
+		}
+		if vendorName == "" && len(requestVendors) == 1 {
+			for name := range requestVendors {
+				vendorName = name
+			}
+		}
+	}
+
+	vendor := requestVendors[vendorName]
+	if vendor == nil {
+		for name, requestVendor := range requestVendors {
+			if strings.EqualFold(name, vendorName) {
+				vendor = requestVendor
+			}
+		}
+	}
+
+	if vendor == nil {
+		if credentialsRequired {
+			err = fmt.Errorf("credentials for vendor '%s' are required", vendorName)
+			return
+		}
+		vendor = o.VendorManager.FindByName(vendorName)
+	}
+
+	if vendor == nil {
+		err = fmt.Errorf("could not find vendor.\n Model = %s\n Vendor = %s", model, vendorName)
+		return
+	}
+
+	ret = &Chatter{db: o.Db, model: model, vendor: vendor, privacy: o.privacy.Load(), variables: o.Variables}
+	return
+}
+
+func (o *PluginRegistry) newVendorWithCredentials(credentials *VendorCredentials) (ret ai.Vendor, err error) {
+	for _, vendor := range o.VendorsFactory() {
+		if strings.EqualFold(vendor.GetName(), credentials.Vendor) {
+			ret = vendor
+			break
+		}
+	}
+	if ret == nil {
+		err = fmt.Errorf("vendor %s not found", credentials.Vendor)
+		return
+	}
+	if o.isVendorDisabled(ret.GetName()) {
+		err = fmt.Errorf("vendor %s is disabled", ret.GetName())
+		return
+	}
+
+	configurable, ok := ret.(vendorConfigurableWith)
+	if !ok {
+		err = fmt.Errorf("vendor %s does not support request credentials", ret.GetName())
+		return
+	}
+
+	prefix := plugins.BuildEnvVariablePrefix(ret.GetName())
+	values := map[string]string{}
+	if credentials.ApiKey != "" {
+		values[prefix+"API_KEY"] = credentials.ApiKey
+	}
+	if credentials.ApiBaseURL != "" {
+		values[prefix+"API_BASE_URL"] = credentials.ApiBaseURL
+		values[prefix+"API_URL"] = credentials.ApiBaseURL
+		// never send the key of the server to a URL of the request
+		values[prefix+"API_KEY"] = credentials.ApiKey
+	}
+
+	if err = configurable.ConfigureWith(values); err != nil {
+		err = fmt.Errorf("vendor %s is not usable with the request credentials: %v", ret.GetName(), err)
+		ret = nil
+	}
+	return
+}
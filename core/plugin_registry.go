@@ -7,7 +7,10 @@ import (
 	"github.com/danielmiessler/fabric/plugins/ai/azure"
 	"github.com/danielmiessler/fabric/plugins/tools"
 	"github.com/samber/lo"
+	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/danielmiessler/fabric/plugins"
 	"github.com/danielmiessler/fabric/plugins/ai"
@@ -15,6 +18,7 @@ import (
 	"github.com/danielmiessler/fabric/plugins/ai/dryrun"
 	"github.com/danielmiessler/fabric/plugins/ai/gemini"
 	"github.com/danielmiessler/fabric/plugins/ai/groq"
+	"github.com/danielmiessler/fabric/plugins/ai/huggingface"
 	"github.com/danielmiessler/fabric/plugins/ai/mistral"
 	"github.com/danielmiessler/fabric/plugins/ai/ollama"
 	"github.com/danielmiessler/fabric/plugins/ai/openai"
@@ -22,41 +26,74 @@ import (
 	"github.com/danielmiessler/fabric/plugins/ai/siliconcloud"
 	"github.com/danielmiessler/fabric/plugins/ai/nebius"
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/danielmiessler/fabric/plugins/tools/forge"
 	"github.com/danielmiessler/fabric/plugins/tools/jina"
 	"github.com/danielmiessler/fabric/plugins/tools/lang"
+	"github.com/danielmiessler/fabric/plugins/tools/privacy"
+	"github.com/danielmiessler/fabric/plugins/tools/variables"
 	"github.com/danielmiessler/fabric/plugins/tools/youtube"
 )
 
 func NewPluginRegistry(db *fsdb.Db) (ret *PluginRegistry) {
 	ret = &PluginRegistry{
-		Db:             db,
-		VendorManager:  ai.NewVendorsManager(),
-		VendorsAll:     ai.NewVendorsManager(),
-		PatternsLoader: tools.NewPatternsLoader(db.Patterns),
+		Db:              db,
+		VendorManager:   ai.NewVendorsManager(),
+		VendorsAll:      ai.NewVendorsManager(),
+		VendorsSettings: NewVendorsSettings(),
+		PatternsLoader: tools.NewPatternsLoader(db.Patterns, db.RemotePatterns),
 		YouTube:        youtube.NewYouTube(),
 		Language:       lang.NewLanguage(),
 		Jina:           jina.NewClient(),
+		Forge:          forge.NewClient(),
+		Privacy:        privacy.NewPrivacy(),
+		Variables:      variables.NewResolver(db.FilePath(variables.ProvidersFile)),
 	}
 
 	ret.Defaults = tools.NeeDefaults(ret.VendorManager.GetModels)
 
-	ret.VendorsAll.AddVendors(openai.NewClient(), ollama.NewClient(), azure.NewClient(), groq.NewClient(), nebius.NewClient(),
-		gemini.NewClient(), anthropic.NewClient(), siliconcloud.NewClient(), openrouter.NewClient(), mistral.NewClient())
+	ret.VendorsFactory = NewVendors
+	ret.VendorsAll.AddVendors(ret.VendorsFactory()...)
 	_ = ret.Configure()
 
 	return
 }
 
+// NewVendors creates new, not configured instances of all supported vendors
+func NewVendors() []ai.Vendor {
+	return []ai.Vendor{openai.NewClient(), ollama.NewClient(), azure.NewClient(), groq.NewClient(), nebius.NewClient(),
+		gemini.NewClient(), anthropic.NewClient(), siliconcloud.NewClient(), openrouter.NewClient(), mistral.NewClient(),
+		huggingface.NewClient()}
+}
+
 type PluginRegistry struct {
 	Db *fsdb.Db
 
-	VendorManager  *ai.VendorsManager
-	VendorsAll     *ai.VendorsManager
+	VendorManager   *ai.VendorsManager
+	VendorsAll      *ai.VendorsManager
+	VendorsSettings *VendorsSettings
 	Defaults       *tools.Defaults
 	PatternsLoader *tools.PatternsLoader
 	YouTube        *youtube.YouTube
 	Language       *lang.Language
 	Jina           *jina.Client
+	Forge          *forge.Client
+	Privacy        *privacy.Privacy
+	Variables      *variables.Resolver
+
+	// VendorsFactory creates the vendor instances on reconfiguration
+	VendorsFactory func() []ai.Vendor
+
+	// defaultModel is read by concurrent requests, it is replaced as a whole on reconfiguration
+	defaultModel atomic.Pointer[DefaultModel]
+	// privacy is the configured Privacy, it is replaced on reconfiguration
+	privacy          atomic.Pointer[privacy.Privacy]
+	reconfigureMutex sync.Mutex
+}
+
+// DefaultModel is the vendor and model used when a request does not choose a model
+type DefaultModel struct {
+	Vendor string `json:"vendor"`
+	Model  string `json:"model"`
 }
 
 func (o *PluginRegistry) SaveEnvFile() (err error) {
@@ -66,13 +103,19 @@ func (o *PluginRegistry) SaveEnvFile() (err error) {
 	o.Defaults.Settings.FillEnvFileContent(&envFileContent)
 	o.PatternsLoader.SetupFillEnvFileContent(&envFileContent)
 
-	for _, vendor := range o.VendorManager.Vendors {
-		vendor.SetupFillEnvFileContent(&envFileContent)
+	o.VendorsSettings.SetupFillEnvFileContent(&envFileContent)
+	for _, vendor := range o.VendorsAll.GetVendors() {
+		// disabled vendors keep their settings
+		if o.VendorManager.FindByName(vendor.GetName()) != nil || o.VendorsSettings.IsDisabled(vendor.GetName()) {
+			vendor.SetupFillEnvFileContent(&envFileContent)
+		}
 	}
 
 	o.YouTube.SetupFillEnvFileContent(&envFileContent)
 	o.Jina.SetupFillEnvFileContent(&envFileContent)
+	o.Forge.SetupFillEnvFileContent(&envFileContent)
 	o.Language.SetupFillEnvFileContent(&envFileContent)
+	o.Privacy.SetupFillEnvFileContent(&envFileContent)
 
 	err = o.Db.SaveEnv(envFileContent.String())
 	return
@@ -91,12 +134,12 @@ func (o *PluginRegistry) Setup() (err error) {
 			return fmt.Sprintf("%v%v", plugin.GetSetupDescription(), configuredLabel)
 		})
 
-	groupsPlugins.AddGroupItems("AI Vendors [at least one, required]", lo.Map(o.VendorsAll.Vendors,
+	groupsPlugins.AddGroupItems("AI Vendors [at least one, required]", lo.Map(o.VendorsAll.GetVendors(),
 		func(vendor ai.Vendor, _ int) plugins.Plugin {
 			return vendor
 		})...)
 
-	groupsPlugins.AddGroupItems("Tools", o.Defaults, o.PatternsLoader, o.YouTube, o.Language, o.Jina)
+	groupsPlugins.AddGroupItems("Tools", o.Defaults, o.PatternsLoader, o.YouTube, o.Language, o.Jina, o.Forge, o.Privacy)
 
 	for {
 		groupsPlugins.Print()
@@ -125,7 +168,7 @@ func (o *PluginRegistry) Setup() (err error) {
 				}
 			}
 
-			if _, ok := o.VendorManager.VendorsByName[plugin.GetName()]; !ok {
+			if o.VendorManager.FindByName(plugin.GetName()) == nil {
 				if vendor, ok := plugin.(ai.Vendor); ok {
 					o.VendorManager.AddVendors(vendor)
 				}
@@ -141,7 +184,7 @@ func (o *PluginRegistry) Setup() (err error) {
 }
 
 func (o *PluginRegistry) SetupVendor(vendorName string) (err error) {
-	if err = o.VendorsAll.SetupVendor(vendorName, o.VendorManager.VendorsByName); err != nil {
+	if err = o.VendorsAll.SetupVendor(vendorName, o.VendorManager); err != nil {
 		return
 	}
 	err = o.SaveEnvFile()
@@ -150,31 +193,98 @@ func (o *PluginRegistry) SetupVendor(vendorName string) (err error) {
 
 // Configure buildClient VendorsController based on the environment variables
 func (o *PluginRegistry) Configure() (err error) {
-	for _, vendor := range o.VendorsAll.Vendors {
-		if vendorErr := vendor.Configure(); vendorErr == nil {
-			o.VendorManager.AddVendors(vendor)
-		}
-	}
+	_ = o.VendorsSettings.Configure()
+	o.VendorManager.SetVendors(configureVendors(o.VendorsAll.GetVendors(), o.VendorsSettings)...)
 	_ = o.Defaults.Configure()
+	o.storeDefaultModel(o.Defaults)
 	_ = o.PatternsLoader.Configure()
 
-	//YouTube and Jina are not mandatory, so ignore not configured error
+	//YouTube, Jina and the forges are not mandatory, so ignore not configured error
 	_ = o.YouTube.Configure()
 	_ = o.Jina.Configure()
+	_ = o.Forge.Configure()
 	_ = o.Language.Configure()
+
+	// the privacy settings are optional too, but an invalid one must not go unnoticed, meta is not stored then
+	if privacyErr := o.Privacy.Configure(); privacyErr != nil {
+		fmt.Fprintf(os.Stderr, "warning: privacy settings: %v, the command line is not stored in sessions\n", privacyErr)
+	}
+	o.privacy.Store(o.Privacy)
+	return
+}
+
+// Reconfigure reloads the .env file and replaces the vendors and the default model with new instances configured from
+// it. Requests in flight keep the vendors they started with. The tools are used by the command line only and are not
+// reconfigured, except the privacy settings.
+func (o *PluginRegistry) Reconfigure() (err error) {
+	o.reconfigureMutex.Lock()
+	defer o.reconfigureMutex.Unlock()
+
+	if err = o.Db.ReloadEnvFile(); err != nil {
+		return
+	}
+
+	vendorsSettings := NewVendorsSettings()
+	_ = vendorsSettings.Configure()
+
+	vendors := o.VendorsFactory()
+	configuredVendors := configureVendors(vendors, vendorsSettings)
+
+	defaults := tools.NeeDefaults(o.VendorManager.GetModels)
+	_ = defaults.Configure()
+
+	privacySettings := privacy.NewPrivacy()
+	privacyErr := privacySettings.Configure()
+
+	o.VendorsSettings = vendorsSettings
+	o.VendorsAll.SetVendors(vendors...)
+	o.VendorManager.SetVendors(configuredVendors...)
+	o.storeDefaultModel(defaults)
+	o.privacy.Store(privacySettings)
+
+	if privacyErr != nil {
+		err = fmt.Errorf("privacy settings: %v, the command line is not stored in sessions", privacyErr)
+	}
+	return
+}
+
+// GetDefaultModel returns the current default vendor and model
+func (o *PluginRegistry) GetDefaultModel() (ret *DefaultModel) {
+	if ret = o.defaultModel.Load(); ret == nil {
+		ret = &DefaultModel{}
+	}
+	return
+}
+
+func (o *PluginRegistry) storeDefaultModel(defaults *tools.Defaults) {
+	o.defaultModel.Store(&DefaultModel{Vendor: defaults.Vendor.Value, Model: defaults.Model.Value})
+}
+
+// configureVendors returns the enabled vendors whose settings are valid, their clients are created on first use. The
+// settings of the disabled vendors are read too, so that saving the .env file keeps them.
+func configureVendors(vendors []ai.Vendor, settings *VendorsSettings) (ret []ai.Vendor) {
+	for _, vendor := range vendors {
+		lazyVendor := ai.NewLazyVendor(vendor)
+		if vendorErr := lazyVendor.Configure(); vendorErr == nil && !settings.IsDisabled(vendor.GetName()) {
+			ret = append(ret, lazyVendor)
+		}
+	}
 	return
 }
 
 func (o *PluginRegistry) GetChatter(model string, stream bool, dryRun bool) (ret *Chatter, err error) {
 	ret = &Chatter{
-		db:     o.Db,
-		Stream: stream,
-		DryRun: dryRun,
+		db:        o.Db,
+		Stream:    stream,
+		DryRun:    dryRun,
+		privacy:   o.privacy.Load(),
+		variables: o.Variables,
 	}
 
-	defaultModel := o.Defaults.Model.Value
-	defaultVendor := o.Defaults.Vendor.Value
-	vendorManager := o.VendorManager
+	defaults := o.GetDefaultModel()
+	defaultModel := defaults.Model
+	defaultVendor := defaults.Vendor
+	vendorManager := o.VendorManager.Snapshot()
 
 	if dryRun {
 		ret.vendor = dryrun.NewClient()
@@ -186,11 +296,9 @@ func (o *PluginRegistry) GetChatter(model string, stream bool, dryRun bool) (ret
 		ret.vendor = vendorManager.FindByName(defaultVendor)
 		ret.model = defaultModel
 	} else {
-		var models *ai.VendorsModels
-		if models, err = vendorManager.GetModels(); err != nil {
+		if ret.vendor, err = vendorManager.FindByModel(model, defaultVendor); err != nil {
 			return
 		}
-		ret.vendor = vendorManager.FindByName(models.FindGroupsByItemFirst(model))
 		ret.model = model
 	}
 
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/ai"
+)
+
+func NewVendorsSettings() (ret *VendorsSettings) {
+	label := "Vendors"
+	ret = &VendorsSettings{
+		PluginBase: &plugins.PluginBase{
+			Name:          label,
+			EnvNamePrefix: plugins.BuildEnvVariablePrefix(label),
+		},
+	}
+	ret.Disabled = ret.AddSetting("Disabled", false)
+	return
+}
+
+// VendorsSettings are the settings of the vendors as a whole. Disabled vendors keep their credentials, but are not
+// used for model listing and routing.
+type VendorsSettings struct {
+	*plugins.PluginBase
+	Disabled *plugins.Setting
+}
+
+func (o *VendorsSettings) IsDisabled(vendorName string) bool {
+	return slices.Contains(o.GetDisabled(), vendorName)
+}
+
+// GetDisabled returns the names of the disabled vendors
+func (o *VendorsSettings) GetDisabled() (ret []string) {
+	for _, name := range strings.Split(o.Disabled.Value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ret = append(ret, name)
+		}
+	}
+	return
+}
+
+func (o *VendorsSettings) SetDisabled(vendorName string, disabled bool) {
+	names := slices.DeleteFunc(o.GetDisabled(), func(name string) bool { return name == vendorName })
+	if disabled {
+		names = append(names, vendorName)
+	}
+	o.Disabled.Value = strings.Join(names, ",")
+}
+
+// VendorStatus is a vendor as listed by the vendors command
+type VendorStatus struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+	Enabled    bool   `json:"enabled"`
+	Default    bool   `json:"default"`
+}
+
+// VendorTest is the result of testing the connection of a vendor by listing its models
+type VendorTest struct {
+	Name     string        `json:"name"`
+	Ok       bool          `json:"ok"`
+	Models   int           `json:"models"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// GetVendorStatuses returns all supported vendors with their state
+func (o *PluginRegistry) GetVendorStatuses() (ret []*VendorStatus) {
+	defaultVendor := o.GetDefaultModel().Vendor
+	for _, vendor := range o.VendorsAll.GetVendors() {
+		ret = append(ret, &VendorStatus{
+			Name:       vendor.GetName(),
+			Configured: vendor.IsConfigured(),
+			Enabled:    !o.VendorsSettings.IsDisabled(vendor.GetName()),
+			Default:    vendor.GetName() == defaultVendor,
+		})
+	}
+	return
+}
+
+// EnableVendor uses the vendor again for model listing and routing
+func (o *PluginRegistry) EnableVendor(name string) (ret *VendorStatus, err error) {
+	var vendor ai.Vendor
+	if vendor, err = o.findVendor(name); err != nil {
+		return
+	}
+
+	o.VendorsSettings.SetDisabled(vendor.GetName(), false)
+	if vendor.IsConfigured() {
+		o.VendorManager.AddVendors(ai.NewLazyVendor(vendor))
+	}
+	if err = o.SaveEnvFile(); err != nil {
+		return
+	}
+	ret = o.getVendorStatus(vendor.GetName())
+	return
+}
+
+// DisableVendor excludes the vendor from model listing and routing, its credentials are kept
+func (o *PluginRegistry) DisableVendor(name string) (ret *VendorStatus, err error) {
+	var vendor ai.Vendor
+	if vendor, err = o.findNotDefaultVendor(name, "disable"); err != nil {
+		return
+	}
+
+	o.VendorsSettings.SetDisabled(vendor.GetName(), true)
+	o.VendorManager.RemoveVendor(vendor.GetName())
+	if err = o.SaveEnvFile(); err != nil {
+		return
+	}
+	ret = o.getVendorStatus(vendor.GetName())
+	return
+}
+
+// RemoveVendor removes the credentials and settings of the vendor
+func (o *PluginRegistry) RemoveVendor(name string) (ret *VendorStatus, err error) {
+	var vendor ai.Vendor
+	if vendor, err = o.findNotDefaultVendor(name, "remove"); err != nil {
+		return
+	}
+
+	resettable, ok := vendor.(interface{ ResetSettings() })
+	if !ok {
+		err = fmt.Errorf("the settings of vendor %s can't be removed", vendor.GetName())
+		return
+	}
+	resettable.ResetSettings()
+
+	o.VendorsSettings.SetDisabled(vendor.GetName(), false)
+	o.VendorManager.RemoveVendor(vendor.GetName())
+	if err = o.SaveEnvFile(); err != nil {
+		return
+	}
+	ret = o.getVendorStatus(vendor.GetName())
+	return
+}
+
+// TestVendor configures the vendor and lists its models, also if it is disabled
+func (o *PluginRegistry) TestVendor(name string) (ret *VendorTest, err error) {
+	var vendor ai.Vendor
+	if vendor, err = o.findVendor(name); err != nil {
+		return
+	}
+
+	ret = &VendorTest{Name: vendor.GetName()}
+	start := time.Now()
+	testErr := vendor.Configure()
+	if testErr == nil {
+		var models []string
+		if models, testErr = vendor.ListModels(); testErr == nil {
+			ret.Ok = true
+			ret.Models = len(models)
+		}
+	}
+	ret.Duration = time.Since(start)
+	if testErr != nil {
+		ret.Error = testErr.Error()
+	}
+	return
+}
+
+// SetDefaultVendor makes the vendor the default one. Without a model, the current default model is kept if the vendor
+// serves it, otherwise the first model of the vendor is used.
+func (o *PluginRegistry) SetDefaultVendor(name string, model string) (ret *DefaultModel, err error) {
+	var vendor ai.Vendor
+	if vendor, err = o.findVendor(name); err != nil {
+		return
+	}
+
+	if vendor = o.VendorManager.FindByName(vendor.GetName()); vendor == nil {
+		err = fmt.Errorf("vendor %s is not configured or disabled", name)
+		return
+	}
+
+	var models []string
+	if models, err = vendor.ListModels(); err != nil {
+		return
+	}
+
+	if model == "" {
+		if model = o.GetDefaultModel().Model; !slices.Contains(models, model) && len(models) > 0 {
+			model = models[0]
+		}
+	}
+	if !slices.Contains(models, model) {
+		err = fmt.Errorf("vendor %s doesn't serve the model %s", vendor.GetName(), model)
+		return
+	}
+
+	o.Defaults.Vendor.Value = vendor.GetName()
+	o.Defaults.Model.Value = model
+	o.storeDefaultModel(o.Defaults)
+	if err = o.SaveEnvFile(); err != nil {
+		return
+	}
+	ret = o.GetDefaultModel()
+	return
+}
+
+// isVendorDisabled is safe for concurrent requests, the settings are replaced on reconfiguration
+func (o *PluginRegistry) isVendorDisabled(name string) bool {
+	o.reconfigureMutex.Lock()
+	defer o.reconfigureMutex.Unlock()
+	return o.VendorsSettings.IsDisabled(name)
+}
+
+// findVendor returns the vendor with the name, ignoring the case
+func (o *PluginRegistry) findVendor(name string) (ret ai.Vendor, err error) {
+	var names []string
+	for _, vendor := range o.VendorsAll.GetVendors() {
+		if strings.EqualFold(vendor.GetName(), name) {
+			ret = vendor
+			return
+		}
+		names = append(names, vendor.GetName())
+	}
+	err = fmt.Errorf("vendor %s not found, the vendors are %s", name, strings.Join(names, ", "))
+	return
+}
+
+func (o *PluginRegistry) findNotDefaultVendor(name string, action string) (ret ai.Vendor, err error) {
+	if ret, err = o.findVendor(name); err != nil {
+		return
+	}
+	if ret.GetName() == o.GetDefaultModel().Vendor {
+		err = fmt.Errorf("can't %s %s, it is the default vendor. Choose another one with fabric vendors set-default",
+			action, ret.GetName())
+	}
+	return
+}
+
+func (o *PluginRegistry) getVendorStatus(name string) *VendorStatus {
+	for _, status := range o.GetVendorStatuses() {
+		if status.Name == name {
+			return status
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+func TestVendorsSettings_SetDisabled(t *testing.T) {
+	settings := NewVendorsSettings()
+	settings.SetDisabled("Groq", true)
+	settings.SetDisabled("Mistral", true)
+	settings.SetDisabled("Groq", true)
+
+	if settings.Disabled.Value != "Mistral,Groq" {
+		t.Fatalf("Disabled = %s, want Mistral,Groq", settings.Disabled.Value)
+	}
+	if !settings.IsDisabled("Groq") || settings.IsDisabled("OpenAI") {
+		t.Fatalf("IsDisabled doesn't match %s", settings.Disabled.Value)
+	}
+
+	settings.SetDisabled("Groq", false)
+	if settings.Disabled.Value != "Mistral" {
+		t.Fatalf("Disabled = %s, want Mistral", settings.Disabled.Value)
+	}
+}
+
+func TestDisableVendor(t *testing.T) {
+	db := fsdb.NewDb(t.TempDir())
+	registry := NewPluginRegistry(db)
+
+	status, err := registry.DisableVendor("groq")
+	if err != nil {
+		t.Fatalf("DisableVendor() error = %v", err)
+	}
+	if status.Name != "Groq" || status.Enabled {
+		t.Fatalf("DisableVendor() = %+v, want disabled Groq", status)
+	}
+
+	content, err := os.ReadFile(db.EnvFilePath)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if !strings.Contains(string(content), "VENDORS_DISABLED=Groq") {
+		t.Fatalf(".env doesn't contain the disabled vendor:\n%s", content)
+	}
+
+	if status, err = registry.EnableVendor("Groq"); err != nil || !status.Enabled {
+		t.Fatalf("EnableVendor() = %+v, %v", status, err)
+	}
+	if _, err = registry.DisableVendor("unknown"); err == nil {
+		t.Fatal("DisableVendor() of an unknown vendor didn't fail")
+	}
+}
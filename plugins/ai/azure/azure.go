@@ -11,6 +11,7 @@ import (
 func NewClient() (ret *Client) {
 	ret = &Client{}
 	ret.Client = openai.NewClientCompatible("Azure", "", ret.configure)
+	ret.NativeCandidates = true
 	ret.ApiDeployments = ret.AddSetupQuestionCustom("deployments", true,
 		"Enter your Azure deployments (comma separated)")
 
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+// CandidatesSender is implemented by the vendors that can generate several completions in one request
+type CandidatesSender interface {
+	// SendCandidates returns n completions, native is false if the vendor doesn't support it for the request
+	SendCandidates(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions, n int) (
+		ret []string, native bool, err error)
+}
+
+// SendCandidates returns up to n completions of the messages. Vendors that support it generate them in one request,
+// for the others the requests are sent concurrently. Failed requests are skipped as long as one succeeds.
+func SendCandidates(ctx context.Context, vendor Vendor, msgs []*common.Message, opts *common.ChatOptions, n int) (
+	ret []string, err error) {
+
+	if sender, ok := vendor.(CandidatesSender); ok {
+		var native bool
+		if ret, native, err = sender.SendCandidates(ctx, msgs, opts, n); native || err != nil {
+			return
+		}
+	}
+
+	results := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = vendor.Send(ctx, msgs, opts)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result != "" {
+			ret = append(ret, result)
+		}
+	}
+	if len(ret) == 0 {
+		if err = errors.Join(errs...); err == nil {
+			err = errors.New("empty response")
+		}
+	}
+	return
+}
+
+func (o *LazyVendor) SendCandidates(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions, n int) (
+	ret []string, native bool, err error) {
+	if err = o.configure(); err != nil {
+		return
+	}
+	if sender, ok := o.Vendor.(CandidatesSender); ok {
+		return sender.SendCandidates(ctx, msgs, opts, n)
+	}
+	return
+}
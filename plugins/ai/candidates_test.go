@@ -0,0 +1,35 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type nativeCandidatesVendor struct {
+	*testVendor
+}
+
+func (o *nativeCandidatesVendor) SendCandidates(_ context.Context, _ []*common.Message, _ *common.ChatOptions, n int) (
+	ret []string, native bool, err error) {
+	for i := 0; i < n; i++ {
+		ret = append(ret, "native")
+	}
+	native = true
+	return
+}
+
+func TestSendCandidates(t *testing.T) {
+	vendor := newTestVendor("Test", "model")
+	candidates, err := SendCandidates(context.Background(), NewLazyVendor(vendor), nil, &common.ChatOptions{}, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"answer", "answer", "answer"}, candidates)
+	assert.Equal(t, 1, vendor.configured)
+
+	native := &nativeCandidatesVendor{newTestVendor("Native", "model")}
+	candidates, err = SendCandidates(context.Background(), NewLazyVendor(native), nil, &common.ChatOptions{}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"native", "native"}, candidates)
+}
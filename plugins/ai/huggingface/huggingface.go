@@ -0,0 +1,314 @@
+package huggingface
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/ai/openai"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	ApiNative   = "native"
+	ApiMessages = "messages"
+)
+
+// NewClient creates a vendor for text-generation-inference servers and Hugging Face Inference Endpoints
+func NewClient() (ret *Client) {
+	ret = &Client{}
+	ret.Client = openai.NewClientCompatible("HuggingFace", "", ret.configure)
+	ret.ApiKey.Required = false
+	ret.ApiKey.Question = "Enter your Hugging Face token (leave empty for a local TGI server)"
+	ret.ApiBaseURL.Required = true
+	ret.ApiBaseURL.Question = "Enter your TGI server or Inference Endpoint URL (e.g. http://localhost:8080)"
+
+	ret.Api = ret.AddSetupQuestionCustom("API", false,
+		"Enter the API to use, 'native' (/generate_stream) or 'messages' (/v1/chat/completions)")
+	ret.Api.Value = ApiNative
+	ret.ChatTemplate = ret.AddSetupQuestionCustom("Chat Template", false,
+		"Enter the chat template for the native API: auto, chatml, llama3, mistral, zephyr, gemma or plain")
+	ret.ChatTemplate.Value = TemplateAuto
+
+	return
+}
+
+type Client struct {
+	*openai.Client
+	Api          *plugins.SetupQuestion
+	ChatTemplate *plugins.SetupQuestion
+
+	baseUrl    string
+	httpClient *http.Client
+
+	infoMutex sync.Mutex
+	info      *Info
+}
+
+// Info is the model information served by /info
+type Info struct {
+	ModelId        string `json:"model_id"`
+	MaxInputTokens int    `json:"max_input_tokens"`
+	// MaxInputLength is the name of MaxInputTokens before TGI 2.1
+	MaxInputLength int `json:"max_input_length"`
+	MaxTotalTokens int `json:"max_total_tokens"`
+}
+
+// ContextLength returns the maximum number of input tokens of the model
+func (o *Info) ContextLength() (ret int) {
+	if ret = o.MaxInputTokens; ret == 0 {
+		ret = o.MaxInputLength
+	}
+	return
+}
+
+type generateRequest struct {
+	Inputs     string             `json:"inputs"`
+	Parameters generateParameters `json:"parameters"`
+}
+
+type generateParameters struct {
+	DoSample         bool     `json:"do_sample"`
+	Temperature      float64  `json:"temperature,omitempty"`
+	TopP             float64  `json:"top_p,omitempty"`
+	FrequencyPenalty float64  `json:"frequency_penalty,omitempty"`
+	Seed             int      `json:"seed,omitempty"`
+	MaxNewTokens     int      `json:"max_new_tokens,omitempty"`
+	Truncate         int      `json:"truncate,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	ReturnFullText   bool     `json:"return_full_text"`
+}
+
+type generateResponse struct {
+	GeneratedText string `json:"generated_text"`
+}
+
+type streamResponse struct {
+	Token struct {
+		Text    string `json:"text"`
+		Special bool   `json:"special"`
+	} `json:"token"`
+	Error string `json:"error"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (o *Client) configure() (err error) {
+	if o.Api.Value != ApiNative && o.Api.Value != ApiMessages {
+		err = fmt.Errorf("%v=%v, must be '%v' or '%v'", o.Api.EnvVariable, o.Api.Value, ApiNative, ApiMessages)
+		return
+	}
+
+	o.baseUrl = strings.TrimSuffix(strings.TrimSuffix(o.ApiBaseURL.Value, "/"), "/v1")
+	o.httpClient = &http.Client{}
+	o.info = nil
+
+	config := goopenai.DefaultConfig(o.ApiKey.Value)
+	config.BaseURL = o.baseUrl + "/v1"
+	o.ApiClient = goopenai.NewClientWithConfig(config)
+	return
+}
+
+// GetInfo loads the model information once, a TGI server serves a single model
+func (o *Client) GetInfo() (ret *Info, err error) {
+	o.infoMutex.Lock()
+	defer o.infoMutex.Unlock()
+
+	if o.info == nil {
+		var resp *http.Response
+		if resp, err = o.request(context.Background(), http.MethodGet, "/info", nil); err != nil {
+			return
+		}
+		defer resp.Body.Close()
+
+		info := &Info{}
+		if err = json.NewDecoder(resp.Body).Decode(info); err != nil {
+			err = fmt.Errorf("can't decode %s/info: %w", o.baseUrl, err)
+			return
+		}
+		o.info = info
+	}
+	ret = o.info
+	return
+}
+
+func (o *Client) ListModels() (ret []string, err error) {
+	var info *Info
+	if info, err = o.GetInfo(); err != nil {
+		return
+	}
+	ret = []string{info.ModelId}
+	return
+}
+
+func (o *Client) SendStream(msgs []*common.Message, opts *common.ChatOptions, channel chan string) (err error) {
+	if o.Api.Value == ApiMessages {
+		return o.Client.SendStream(msgs, opts, channel)
+	}
+
+	var template *ChatTemplate
+	var req *generateRequest
+	if template, req, err = o.buildGenerateRequest(msgs, opts); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = o.requestJson(context.Background(), "/generate_stream", req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		if !found {
+			continue
+		}
+
+		var event streamResponse
+		if err = json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return
+		}
+		if event.Error != "" {
+			err = fmt.Errorf("text generation error: %s", event.Error)
+			return
+		}
+		if !event.Token.Special && !isStopSequence(template, event.Token.Text) {
+			channel <- event.Token.Text
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	channel <- "\n"
+	close(channel)
+	return
+}
+
+func (o *Client) Send(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions) (ret string, err error) {
+	if o.Api.Value == ApiMessages {
+		return o.Client.Send(ctx, msgs, opts)
+	}
+
+	var template *ChatTemplate
+	var req *generateRequest
+	if template, req, err = o.buildGenerateRequest(msgs, opts); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = o.requestJson(ctx, "/generate", req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var generated generateResponse
+	if err = json.NewDecoder(resp.Body).Decode(&generated); err != nil {
+		return
+	}
+
+	ret = generated.GeneratedText
+	for _, stop := range template.Stop {
+		ret = strings.TrimSuffix(ret, stop)
+	}
+	return
+}
+
+// buildGenerateRequest renders the messages with the chat template and limits the input to the context length of the
+// model, TGI truncates longer prompts from the start instead of rejecting them
+func (o *Client) buildGenerateRequest(msgs []*common.Message, opts *common.ChatOptions) (
+	template *ChatTemplate, ret *generateRequest, err error) {
+
+	var info *Info
+	if info, err = o.GetInfo(); err != nil {
+		return
+	}
+
+	if template, err = GetChatTemplate(o.ChatTemplate.Value, info.ModelId); err != nil {
+		return
+	}
+
+	ret = &generateRequest{
+		Inputs: template.Format(msgs),
+		Parameters: generateParameters{
+			Truncate: info.ContextLength(),
+			Stop:     template.Stop,
+		},
+	}
+	if info.MaxTotalTokens > info.ContextLength() {
+		ret.Parameters.MaxNewTokens = info.MaxTotalTokens - info.ContextLength()
+	}
+
+	if !opts.Raw {
+		// TGI rejects a temperature of 0 and a top P outside of (0, 1), these values mean greedy decoding
+		if opts.Temperature > 0 {
+			ret.Parameters.DoSample = true
+			ret.Parameters.Temperature = opts.Temperature
+		}
+		if opts.TopP > 0 && opts.TopP < 1 {
+			ret.Parameters.TopP = opts.TopP
+		}
+		ret.Parameters.FrequencyPenalty = opts.FrequencyPenalty
+		ret.Parameters.Seed = opts.Seed
+	}
+	return
+}
+
+func (o *Client) requestJson(ctx context.Context, path string, payload any) (ret *http.Response, err error) {
+	var body []byte
+	if body, err = json.Marshal(payload); err != nil {
+		return
+	}
+	ret, err = o.request(ctx, http.MethodPost, path, bytes.NewReader(body))
+	return
+}
+
+func (o *Client) request(ctx context.Context, method string, path string, body io.Reader) (ret *http.Response, err error) {
+	var req *http.Request
+	if req, err = http.NewRequestWithContext(ctx, method, o.baseUrl+path, body); err != nil {
+		return
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if o.ApiKey.Value != "" {
+		req.Header.Set("Authorization", "Bearer "+o.ApiKey.Value)
+	}
+
+	if ret, err = o.httpClient.Do(req); err != nil {
+		return
+	}
+
+	if ret.StatusCode != http.StatusOK {
+		defer ret.Body.Close()
+		var errResp errorResponse
+		if decodeErr := json.NewDecoder(ret.Body).Decode(&errResp); decodeErr == nil && errResp.Error != "" {
+			err = fmt.Errorf("%s %s: %s: %s", method, o.baseUrl+path, ret.Status, errResp.Error)
+		} else {
+			err = fmt.Errorf("%s %s: %s", method, o.baseUrl+path, ret.Status)
+		}
+		ret = nil
+	}
+	return
+}
+
+func isStopSequence(template *ChatTemplate, text string) bool {
+	for _, stop := range template.Stop {
+		if text == stop {
+			return true
+		}
+	}
+	return false
+}
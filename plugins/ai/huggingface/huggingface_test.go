@@ -0,0 +1,137 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMessages = []*common.Message{
+	{Role: goopenai.ChatMessageRoleSystem, Content: "Be brief."},
+	{Role: goopenai.ChatMessageRoleUser, Content: "Hi"},
+}
+
+func newTestServer(t *testing.T, requests *[]generateRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer hf_token", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/info":
+			fmt.Fprint(w, `{"model_id":"HuggingFaceH4/zephyr-7b-beta","max_input_tokens":3000,"max_total_tokens":4096}`)
+		case "/generate":
+			var req generateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*requests = append(*requests, req)
+			fmt.Fprint(w, `{"generated_text":"Hello!</s>"}`)
+		case "/generate_stream":
+			var req generateRequest
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*requests = append(*requests, req)
+			fmt.Fprint(w, "data:{\"token\":{\"text\":\"Hel\",\"special\":false}}\n\n")
+			fmt.Fprint(w, "data:{\"token\":{\"text\":\"lo\",\"special\":false}}\n\n")
+			fmt.Fprint(w, "data:{\"token\":{\"text\":\"</s>\",\"special\":true},\"generated_text\":\"Hello\"}\n\n")
+		case "/v1/chat/completions":
+			fmt.Fprint(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"From messages"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"not found"}`)
+		}
+	}))
+}
+
+func newTestClient(t *testing.T, baseUrl string, api string) *Client {
+	client := NewClient()
+	client.ApiKey.Value = "hf_token"
+	client.ApiBaseURL.Value = baseUrl
+	client.Api.Value = api
+	assert.NoError(t, client.configure())
+	return client
+}
+
+func TestClient_Native(t *testing.T) {
+	var requests []generateRequest
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client := newTestClient(t, server.URL+"/", ApiNative)
+
+	models, err := client.ListModels()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HuggingFaceH4/zephyr-7b-beta"}, models)
+
+	result, err := client.Send(context.Background(), testMessages, &common.ChatOptions{Temperature: 0, TopP: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", result)
+
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "<|system|>\nBe brief.</s>\n<|user|>\nHi</s>\n<|assistant|>\n", requests[0].Inputs)
+	assert.Equal(t, 3000, requests[0].Parameters.Truncate)
+	assert.Equal(t, 1096, requests[0].Parameters.MaxNewTokens)
+	assert.False(t, requests[0].Parameters.DoSample)
+	assert.Zero(t, requests[0].Parameters.TopP)
+}
+
+func TestClient_NativeStream(t *testing.T) {
+	var requests []generateRequest
+	server := newTestServer(t, &requests)
+	defer server.Close()
+
+	client := newTestClient(t, server.URL, ApiNative)
+	client.ChatTemplate.Value = TemplateChatML
+
+	channel := make(chan string)
+	go func() {
+		assert.NoError(t, client.SendStream(testMessages, &common.ChatOptions{Temperature: 0.7, TopP: 0.9}, channel))
+	}()
+
+	result := ""
+	for token := range channel {
+		result += token
+	}
+	assert.Equal(t, "Hello\n", result)
+	assert.Equal(t, "<|im_start|>system\nBe brief.<|im_end|>\n<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n",
+		requests[0].Inputs)
+	assert.True(t, requests[0].Parameters.DoSample)
+	assert.Equal(t, 0.9, requests[0].Parameters.TopP)
+}
+
+func TestClient_Messages(t *testing.T) {
+	server := newTestServer(t, nil)
+	defer server.Close()
+
+	client := newTestClient(t, server.URL+"/v1", ApiMessages)
+	result, err := client.Send(context.Background(), testMessages, &common.ChatOptions{Model: "tgi"})
+	assert.NoError(t, err)
+	assert.Equal(t, "From messages", result)
+}
+
+func TestClient_ConfigureInvalidApi(t *testing.T) {
+	client := NewClient()
+	client.ApiBaseURL.Value = "http://localhost:8080"
+	client.Api.Value = "grpc"
+	assert.Error(t, client.configure())
+}
+
+func TestGetChatTemplate(t *testing.T) {
+	template, err := GetChatTemplate(TemplateAuto, "meta-llama/Meta-Llama-3-8B-Instruct")
+	assert.NoError(t, err)
+	assert.Equal(t, TemplateLlama3, template.Name)
+
+	template, err = GetChatTemplate("", "mistralai/Mistral-7B-Instruct-v0.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "<s>[INST] Be brief.\n\nHi [/INST]", template.Format(testMessages))
+
+	template, err = GetChatTemplate(TemplateGemma, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "<bos><start_of_turn>user\nBe brief.\n\nHi<end_of_turn>\n<start_of_turn>model\n", template.Format(testMessages))
+
+	_, err = GetChatTemplate("vicuna", "")
+	assert.Error(t, err)
+}
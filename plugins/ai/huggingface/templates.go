@@ -0,0 +1,160 @@
+package huggingface
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielmiessler/fabric/common"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+const (
+	TemplateAuto    = "auto"
+	TemplateChatML  = "chatml"
+	TemplateLlama3  = "llama3"
+	TemplateMistral = "mistral"
+	TemplateZephyr  = "zephyr"
+	TemplateGemma   = "gemma"
+	TemplatePlain   = "plain"
+)
+
+// ChatTemplate turns chat messages into the prompt string expected by the native generate API
+type ChatTemplate struct {
+	Name   string
+	Stop   []string
+	Format func(msgs []*common.Message) string
+}
+
+var chatTemplates = map[string]*ChatTemplate{
+	TemplateChatML: {
+		Name: TemplateChatML,
+		Stop: []string{"<|im_end|>"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			for _, msg := range msgs {
+				builder.WriteString(fmt.Sprintf("<|im_start|>%s\n%s<|im_end|>\n", msg.Role, msg.Content))
+			}
+			builder.WriteString("<|im_start|>assistant\n")
+			return builder.String()
+		},
+	},
+	TemplateLlama3: {
+		Name: TemplateLlama3,
+		Stop: []string{"<|eot_id|>"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			builder.WriteString("<|begin_of_text|>")
+			for _, msg := range msgs {
+				builder.WriteString(fmt.Sprintf("<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", msg.Role, msg.Content))
+			}
+			builder.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+			return builder.String()
+		},
+	},
+	TemplateMistral: {
+		Name: TemplateMistral,
+		Stop: []string{"</s>"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			builder.WriteString("<s>")
+			for _, msg := range mergeSystemIntoUser(msgs) {
+				if msg.Role == goopenai.ChatMessageRoleAssistant {
+					builder.WriteString(fmt.Sprintf(" %s</s>", msg.Content))
+				} else {
+					builder.WriteString(fmt.Sprintf("[INST] %s [/INST]", msg.Content))
+				}
+			}
+			return builder.String()
+		},
+	},
+	TemplateZephyr: {
+		Name: TemplateZephyr,
+		Stop: []string{"</s>"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			for _, msg := range msgs {
+				builder.WriteString(fmt.Sprintf("<|%s|>\n%s</s>\n", msg.Role, msg.Content))
+			}
+			builder.WriteString("<|assistant|>\n")
+			return builder.String()
+		},
+	},
+	TemplateGemma: {
+		Name: TemplateGemma,
+		Stop: []string{"<end_of_turn>"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			builder.WriteString("<bos>")
+			for _, msg := range mergeSystemIntoUser(msgs) {
+				role := msg.Role
+				if role == goopenai.ChatMessageRoleAssistant {
+					role = "model"
+				}
+				builder.WriteString(fmt.Sprintf("<start_of_turn>%s\n%s<end_of_turn>\n", role, msg.Content))
+			}
+			builder.WriteString("<start_of_turn>model\n")
+			return builder.String()
+		},
+	},
+	TemplatePlain: {
+		Name: TemplatePlain,
+		Stop: []string{"\nUser:"},
+		Format: func(msgs []*common.Message) string {
+			var builder strings.Builder
+			for _, msg := range msgs {
+				builder.WriteString(fmt.Sprintf("%s%s: %s\n\n", strings.ToUpper(msg.Role[:1]), msg.Role[1:], msg.Content))
+			}
+			builder.WriteString("Assistant:")
+			return builder.String()
+		},
+	},
+}
+
+// GetChatTemplate returns the template by name, "auto" or an empty name detect it from the model id
+func GetChatTemplate(name string, modelId string) (ret *ChatTemplate, err error) {
+	if name == "" || name == TemplateAuto {
+		name = DetectChatTemplate(modelId)
+	}
+	if ret = chatTemplates[strings.ToLower(name)]; ret == nil {
+		err = fmt.Errorf("unknown chat template %s, supported: chatml, llama3, mistral, zephyr, gemma, plain", name)
+	}
+	return
+}
+
+// DetectChatTemplate guesses the chat template of the model family
+func DetectChatTemplate(modelId string) (ret string) {
+	modelId = strings.ToLower(modelId)
+	switch {
+	case strings.Contains(modelId, "llama-3") || strings.Contains(modelId, "llama3"):
+		ret = TemplateLlama3
+	case strings.Contains(modelId, "mistral") || strings.Contains(modelId, "mixtral"):
+		ret = TemplateMistral
+	case strings.Contains(modelId, "zephyr"):
+		ret = TemplateZephyr
+	case strings.Contains(modelId, "gemma"):
+		ret = TemplateGemma
+	default:
+		ret = TemplateChatML
+	}
+	return
+}
+
+// mergeSystemIntoUser prepends system messages to the next user message, for templates without a system role
+func mergeSystemIntoUser(msgs []*common.Message) (ret []*common.Message) {
+	var system []string
+	for _, msg := range msgs {
+		if msg.Role == goopenai.ChatMessageRoleSystem {
+			system = append(system, msg.Content)
+			continue
+		}
+		if msg.Role == goopenai.ChatMessageRoleUser && len(system) > 0 {
+			msg = &common.Message{Role: msg.Role, Content: strings.Join(append(system, msg.Content), "\n\n")}
+			system = nil
+		}
+		ret = append(ret, msg)
+	}
+	if len(system) > 0 {
+		ret = append(ret, &common.Message{Role: goopenai.ChatMessageRoleUser, Content: strings.Join(system, "\n\n")})
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+// settingsConfigurer is implemented by the vendors based on plugins.PluginBase
+type settingsConfigurer interface {
+	ConfigureSettings() error
+}
+
+// LazyVendor defers the configuration of a vendor, like creating its API client, to the first use. Configure only
+// reads and validates the settings, so a command pays only for the vendors it actually uses.
+type LazyVendor struct {
+	Vendor
+
+	once         sync.Once
+	configureErr error
+}
+
+func NewLazyVendor(vendor Vendor) *LazyVendor {
+	return &LazyVendor{Vendor: vendor}
+}
+
+func (o *LazyVendor) Configure() (err error) {
+	if settings, ok := o.Vendor.(settingsConfigurer); ok {
+		err = settings.ConfigureSettings()
+	} else {
+		err = o.configure()
+	}
+	return
+}
+
+func (o *LazyVendor) configure() error {
+	o.once.Do(func() {
+		start := time.Now()
+		o.configureErr = o.Vendor.Configure()
+		slog.Debug("vendor configured", "vendor", o.GetName(), "duration", time.Since(start))
+	})
+	return o.configureErr
+}
+
+// Configured configures the vendor and returns it, for the features of a specific vendor
+func (o *LazyVendor) Configured() (ret Vendor, err error) {
+	if err = o.configure(); err != nil {
+		return
+	}
+	ret = o.Vendor
+	return
+}
+
+func (o *LazyVendor) ListModels() (ret []string, err error) {
+	if err = o.configure(); err != nil {
+		return
+	}
+	return o.Vendor.ListModels()
+}
+
+func (o *LazyVendor) SendStream(msgs []*common.Message, opts *common.ChatOptions, channel chan string) (err error) {
+	if err = o.configure(); err != nil {
+		return
+	}
+	return o.Vendor.SendStream(msgs, opts, channel)
+}
+
+func (o *LazyVendor) Send(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions) (ret string, err error) {
+	if err = o.configure(); err != nil {
+		return
+	}
+	return o.Vendor.Send(ctx, msgs, opts)
+}
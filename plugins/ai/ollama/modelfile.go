@@ -0,0 +1,96 @@
+package ollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BuildModelfile returns the Modelfile of a model with the system prompt on top of the base model. The parameters are
+// Ollama parameters like temperature or num_ctx, a list gives a parameter several times, e.g. stop.
+func BuildModelfile(base string, system string, parameters map[string]any) string {
+	var ret strings.Builder
+	ret.WriteString(fmt.Sprintf("FROM %s\n", base))
+
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values, isList := parameters[name].([]any)
+		if !isList {
+			values = []any{parameters[name]}
+		}
+		for _, value := range values {
+			ret.WriteString(fmt.Sprintf("PARAMETER %s %s\n", name, formatParameter(value)))
+		}
+	}
+
+	// a triple quote would end the system prompt early
+	system = strings.ReplaceAll(strings.TrimSpace(system), `"""`, `'''`)
+	ret.WriteString(fmt.Sprintf("SYSTEM \"\"\"\n%s\n\"\"\"\n", system))
+	return ret.String()
+}
+
+func formatParameter(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return strconv.Quote(typed)
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		return fmt.Sprint(typed)
+	}
+}
+
+// createRequest is the request of /api/create of current Ollama servers, they create models from a base model instead
+// of a Modelfile. The pinned API client still sends the Modelfile, which these servers reject.
+type createRequest struct {
+	Model      string         `json:"model"`
+	From       string         `json:"from"`
+	System     string         `json:"system,omitempty"`
+	Parameters map[string]any `json:"parameters,omitempty"`
+	Stream     bool           `json:"stream"`
+}
+
+// CreateModel creates or replaces the model with the system prompt and the parameters on top of the base model
+func (o *Client) CreateModel(name string, base string, system string, parameters map[string]any) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("could not create Ollama model %s: %v", name, err)
+		}
+	}()
+
+	var payload []byte
+	if payload, err = json.Marshal(&createRequest{
+		Model: name, From: base, System: strings.TrimSpace(system), Parameters: parameters}); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = o.httpClient.Post(o.apiUrl.JoinPath("/api/create").String(), "application/json",
+		bytes.NewReader(payload)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var status struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(body, &status)
+	if status.Error != "" {
+		err = errors.New(status.Error)
+	} else if resp.StatusCode >= http.StatusBadRequest {
+		err = fmt.Errorf("%s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package ollama
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildModelfile(t *testing.T) {
+	var parameters map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(`{"temperature": 0.2, "num_ctx": 8192, "stop": ["<|end|>", "###"]}`), &parameters))
+
+	modelfile := BuildModelfile("llama3", "# IDENTITY\n\nYou use \"\"\"quotes\"\"\".\n", parameters)
+	assert.Equal(t, "FROM llama3\n"+
+		"PARAMETER num_ctx 8192\n"+
+		"PARAMETER stop \"<|end|>\"\n"+
+		"PARAMETER stop \"###\"\n"+
+		"PARAMETER temperature 0.2\n"+
+		"SYSTEM \"\"\"\n# IDENTITY\n\nYou use '''quotes'''.\n\"\"\"\n", modelfile)
+}
+
+func TestCreateModel(t *testing.T) {
+	var request map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/create", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &request)
+		if request["from"] == "missing" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "neither 'from' or 'files' was specified"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.ApiUrl.Value = server.URL
+	assert.NoError(t, client.configure())
+	assert.NoError(t, client.CreateModel("fabric-summarize", "llama3", "# IDENTITY\n", map[string]any{"temperature": 0.2}))
+	assert.Equal(t, map[string]any{"model": "fabric-summarize", "from": "llama3", "system": "# IDENTITY",
+		"parameters": map[string]any{"temperature": 0.2}, "stream": false}, request)
+
+	err := client.CreateModel("fabric-summarize", "missing", "", nil)
+	assert.ErrorContains(t, err, "neither 'from' or 'files' was specified")
+}
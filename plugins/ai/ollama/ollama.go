@@ -34,8 +34,9 @@ type Client struct {
 	*plugins.PluginBase
 	ApiUrl *plugins.SetupQuestion
 
-	apiUrl *url.URL
-	client *ollamaapi.Client
+	apiUrl     *url.URL
+	client     *ollamaapi.Client
+	httpClient *http.Client
 }
 
 func (o *Client) configure() (err error) {
@@ -44,7 +45,8 @@ func (o *Client) configure() (err error) {
 		return
 	}
 
-	o.client = ollamaapi.NewClient(o.apiUrl, &http.Client{Timeout: 1200000 * time.Millisecond})
+	o.httpClient = &http.Client{Timeout: 1200000 * time.Millisecond}
+	o.client = ollamaapi.NewClient(o.apiUrl, o.httpClient)
 	return
 }
 
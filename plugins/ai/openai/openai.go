@@ -15,7 +15,9 @@ import (
 )
 
 func NewClient() (ret *Client) {
-	return NewClientCompatible("OpenAI", "https://api.openai.com/v1", nil)
+	ret = NewClientCompatible("OpenAI", "https://api.openai.com/v1", nil)
+	ret.NativeCandidates = true
+	return
 }
 
 func NewClientCompatible(vendorName string, defaultBaseUrl string, configureCustom func() error) (ret *Client) {
@@ -43,6 +45,10 @@ type Client struct {
 	ApiKey     *plugins.SetupQuestion
 	ApiBaseURL *plugins.SetupQuestion
 	ApiClient  *openai.Client
+
+	// NativeCandidates is set for the vendors that support several completions per request with n, many compatible
+	// APIs reject it
+	NativeCandidates bool
 }
 
 func (o *Client) configure() (ret error) {
@@ -118,6 +124,31 @@ func (o *Client) Send(ctx context.Context, msgs []*common.Message, opts *common.
 	return
 }
 
+func (o *Client) SendCandidates(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions, n int) (
+	ret []string, native bool, err error) {
+	if !o.NativeCandidates {
+		return
+	}
+	native = true
+
+	req := o.buildChatCompletionRequest(msgs, opts)
+	req.N = n
+
+	var resp goopenai.ChatCompletionResponse
+	if resp, err = o.ApiClient.CreateChatCompletion(ctx, req); err != nil {
+		return
+	}
+	for _, choice := range resp.Choices {
+		if choice.Message.Content != "" {
+			ret = append(ret, choice.Message.Content)
+		}
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("empty response")
+	}
+	return
+}
+
 func (o *Client) buildChatCompletionRequest(
 	msgs []*common.Message, opts *common.ChatOptions,
 ) (ret goopenai.ChatCompletionRequest) {
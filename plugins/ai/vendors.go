@@ -5,76 +5,181 @@ import (
 	"context"
 	"fmt"
 	"github.com/danielmiessler/fabric/plugins"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
-func NewVendorsManager() *VendorsManager {
-	return &VendorsManager{
-		Vendors:       []Vendor{},
-		VendorsByName: map[string]Vendor{},
-	}
+func NewVendorsManager() (ret *VendorsManager) {
+	ret = &VendorsManager{}
+	ret.snapshot.Store(newVendorsSnapshot(nil))
+	return
 }
 
+// VendorsManager is safe for concurrent use. The vendors are kept in an immutable snapshot, changes build a new
+// snapshot and swap it atomically, so requests keep the vendors and models they started with.
 type VendorsManager struct {
 	*plugins.PluginBase
-	Vendors       []Vendor
-	VendorsByName map[string]Vendor
-	Models        *VendorsModels
+
+	snapshot atomic.Pointer[VendorsSnapshot]
+	// mutex serializes the changes of the snapshot
+	mutex sync.Mutex
 }
 
-func (o *VendorsManager) AddVendors(vendors ...Vendor) {
+// VendorsSnapshot is an immutable set of vendors, the models are read once on first use
+type VendorsSnapshot struct {
+	vendors       []Vendor
+	vendorsByName map[string]Vendor
+
+	modelsOnce sync.Once
+	models     *VendorsModels
+	modelsErr  error
+}
+
+func newVendorsSnapshot(vendors []Vendor) (ret *VendorsSnapshot) {
+	ret = &VendorsSnapshot{vendors: vendors, vendorsByName: map[string]Vendor{}}
 	for _, vendor := range vendors {
-		o.VendorsByName[vendor.GetName()] = vendor
-		o.Vendors = append(o.Vendors, vendor)
+		ret.vendorsByName[vendor.GetName()] = vendor
 	}
+	return
+}
+
+// Snapshot returns the current vendors, use it to look up vendors and models consistently
+func (o *VendorsManager) Snapshot() *VendorsSnapshot {
+	return o.snapshot.Load()
+}
+
+// GetVendors returns the current vendors, the slice must not be modified
+func (o *VendorsManager) GetVendors() []Vendor {
+	return o.Snapshot().vendors
+}
+
+// AddVendors adds the vendors, a vendor with the same name as an existing one replaces it
+func (o *VendorsManager) AddVendors(vendors ...Vendor) {
+	o.update(func(current []Vendor) (ret []Vendor) {
+		ret = append([]Vendor{}, current...)
+		for _, vendor := range vendors {
+			replaced := false
+			for i, existing := range ret {
+				if existing.GetName() == vendor.GetName() {
+					ret[i] = vendor
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				ret = append(ret, vendor)
+			}
+		}
+		return
+	})
+}
+
+// RemoveVendor removes the vendor with the given name
+func (o *VendorsManager) RemoveVendor(name string) {
+	o.update(func(current []Vendor) (ret []Vendor) {
+		for _, vendor := range current {
+			if vendor.GetName() != name {
+				ret = append(ret, vendor)
+			}
+		}
+		return
+	})
+}
+
+// SetVendors replaces all vendors
+func (o *VendorsManager) SetVendors(vendors ...Vendor) {
+	o.update(func([]Vendor) []Vendor {
+		return append([]Vendor{}, vendors...)
+	})
+}
+
+func (o *VendorsManager) update(change func(current []Vendor) []Vendor) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.snapshot.Store(newVendorsSnapshot(change(o.Snapshot().vendors)))
 }
 
 func (o *VendorsManager) SetupFillEnvFileContent(envFileContent *bytes.Buffer) {
-	for _, vendor := range o.Vendors {
+	for _, vendor := range o.GetVendors() {
 		vendor.SetupFillEnvFileContent(envFileContent)
 	}
 }
 
 func (o *VendorsManager) GetModels() (ret *VendorsModels, err error) {
-	if o.Models == nil {
-		err = o.readModels()
-	}
-	ret = o.Models
-	return
+	return o.Snapshot().GetModels()
 }
 
 func (o *VendorsManager) Configure() (err error) {
-	for _, vendor := range o.Vendors {
+	for _, vendor := range o.GetVendors() {
 		_ = vendor.Configure()
 	}
 	return
 }
 
 func (o *VendorsManager) HasVendors() bool {
-	return len(o.Vendors) > 0
+	return o.Snapshot().HasVendors()
 }
 
 func (o *VendorsManager) FindByName(name string) Vendor {
-	return o.VendorsByName[name]
+	return o.Snapshot().FindByName(name)
+}
+
+func (o *VendorsSnapshot) Vendors() []Vendor {
+	return o.vendors
+}
+
+func (o *VendorsSnapshot) HasVendors() bool {
+	return len(o.vendors) > 0
+}
+
+func (o *VendorsSnapshot) FindByName(name string) Vendor {
+	return o.vendorsByName[name]
 }
 
-func (o *VendorsManager) readModels() (err error) {
-	if len(o.Vendors) == 0 {
+// FindByModel returns the vendor of the model. The preferred vendor, usually the default one, is asked first, so that
+// the models of all vendors are only listed if it doesn't serve the model.
+func (o *VendorsSnapshot) FindByModel(model string, preferredVendor string) (ret Vendor, err error) {
+	if preferred := o.FindByName(preferredVendor); preferred != nil {
+		if models, listErr := preferred.ListModels(); listErr == nil && slices.Contains(models, model) {
+			ret = preferred
+			return
+		}
+	}
+
+	var models *VendorsModels
+	if models, err = o.GetModels(); err != nil {
+		return
+	}
+	ret = o.FindByName(models.FindGroupsByItemFirst(model))
+	return
+}
+
+func (o *VendorsSnapshot) GetModels() (ret *VendorsModels, err error) {
+	o.modelsOnce.Do(func() {
+		o.models, o.modelsErr = o.readModels()
+	})
+	ret, err = o.models, o.modelsErr
+	return
+}
+
+func (o *VendorsSnapshot) readModels() (ret *VendorsModels, err error) {
+	if len(o.vendors) == 0 {
 
 		err = fmt.Errorf("no AI vendors configured to read models from. Please configure at least one AI vendor")
 		return
 	}
 
-	o.Models = NewVendorsModels()
+	ret = NewVendorsModels()
 
 	var wg sync.WaitGroup
-	resultsChan := make(chan modelResult, len(o.Vendors))
+	resultsChan := make(chan modelResult, len(o.vendors))
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	for _, vendor := range o.Vendors {
+	for _, vendor := range o.vendors {
 		wg.Add(1)
-		go o.fetchVendorModels(ctx, &wg, vendor, resultsChan)
+		go fetchVendorModels(ctx, &wg, vendor, resultsChan)
 	}
 
 	// Wait for all goroutines to finish
@@ -89,13 +194,13 @@ func (o *VendorsManager) readModels() (err error) {
 			fmt.Println(result.vendorName, result.err)
 			cancel() // Cancel remaining goroutines if needed
 		} else {
-			o.Models.AddGroupItems(result.vendorName, result.models...)
+			ret.AddGroupItems(result.vendorName, result.models...)
 		}
 	}
 	return
 }
 
-func (o *VendorsManager) fetchVendorModels(
+func fetchVendorModels(
 	ctx context.Context, wg *sync.WaitGroup, vendor Vendor, resultsChan chan<- modelResult) {
 
 	defer wg.Done()
@@ -112,29 +217,35 @@ func (o *VendorsManager) fetchVendorModels(
 
 func (o *VendorsManager) Setup() (ret map[string]Vendor, err error) {
 	ret = map[string]Vendor{}
-	for _, vendor := range o.Vendors {
+	for _, vendor := range o.GetVendors() {
 		fmt.Println()
-		o.setupVendorTo(vendor, ret)
+		if o.setupVendor(vendor) {
+			ret[vendor.GetName()] = vendor
+		}
 	}
 	return
 }
 
-func (o *VendorsManager) SetupVendor(vendorName string, configuredVendors map[string]Vendor) (err error) {
+// SetupVendor runs the setup of the vendor and adds it to the configured vendors, or removes it if the setup failed
+func (o *VendorsManager) SetupVendor(vendorName string, configuredVendors *VendorsManager) (err error) {
 	vendor := o.FindByName(vendorName)
 	if vendor == nil {
 		err = fmt.Errorf("vendor %s not found", vendorName)
 		return
 	}
-	o.setupVendorTo(vendor, configuredVendors)
+	if o.setupVendor(vendor) {
+		configuredVendors.AddVendors(vendor)
+	} else {
+		configuredVendors.RemoveVendor(vendor.GetName())
+	}
 	return
 }
 
-func (o *VendorsManager) setupVendorTo(vendor Vendor, configuredVendors map[string]Vendor) {
+func (o *VendorsManager) setupVendor(vendor Vendor) (ret bool) {
 	if vendorErr := vendor.Setup(); vendorErr == nil {
 		fmt.Printf("[%v] configured\n", vendor.GetName())
-		configuredVendors[vendor.GetName()] = vendor
+		ret = true
 	} else {
-		delete(configuredVendors, vendor.GetName())
 		fmt.Printf("[%v] skipped\n", vendor.GetName())
 	}
 	return
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+type testVendor struct {
+	*plugins.PluginBase
+	models     []string
+	configured int
+	listed     int
+}
+
+func newTestVendor(name string, models ...string) (ret *testVendor) {
+	ret = &testVendor{models: models}
+	ret.PluginBase = &plugins.PluginBase{
+		Name: name,
+		ConfigureCustom: func() error {
+			ret.configured++
+			return nil
+		},
+	}
+	return
+}
+
+func (o *testVendor) ListModels() ([]string, error) {
+	o.listed++
+	return o.models, nil
+}
+
+func (o *testVendor) SendStream([]*common.Message, *common.ChatOptions, chan string) error {
+	return nil
+}
+
+func (o *testVendor) Send(context.Context, []*common.Message, *common.ChatOptions) (string, error) {
+	return "answer", nil
+}
+
+func TestLazyVendor(t *testing.T) {
+	vendor := newTestVendor("Test", "model")
+	lazyVendor := NewLazyVendor(vendor)
+
+	assert.NoError(t, lazyVendor.Configure())
+	assert.Equal(t, 0, vendor.configured)
+
+	_, err := lazyVendor.Send(context.Background(), nil, &common.ChatOptions{})
+	assert.NoError(t, err)
+	_, err = lazyVendor.ListModels()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, vendor.configured)
+}
+
+func TestVendorsSnapshot_FindByModel(t *testing.T) {
+	first := newTestVendor("First", "shared", "first-only")
+	preferred := newTestVendor("Preferred", "shared")
+
+	manager := NewVendorsManager()
+	manager.AddVendors(first, preferred)
+
+	vendor, err := manager.Snapshot().FindByModel("shared", "Preferred")
+	assert.NoError(t, err)
+	assert.Equal(t, "Preferred", vendor.GetName())
+	assert.Equal(t, 0, first.listed)
+
+	vendor, err = manager.Snapshot().FindByModel("first-only", "Preferred")
+	assert.NoError(t, err)
+	assert.Equal(t, "First", vendor.GetName())
+	assert.Equal(t, 1, first.listed)
+}
+
+func TestVendorsManager_AddVendorsReplaces(t *testing.T) {
+	manager := NewVendorsManager()
+	manager.AddVendors(newTestVendor("Test"))
+	snapshot := manager.Snapshot()
+
+	replacement := newTestVendor("Test")
+	manager.AddVendors(replacement)
+	assert.Len(t, manager.GetVendors(), 1)
+	assert.Same(t, replacement, manager.FindByName("Test"))
+
+	// the old snapshot is not changed
+	assert.NotSame(t, replacement, snapshot.FindByName("Test"))
+
+	manager.RemoveVendor("Test")
+	assert.False(t, manager.HasVendors())
+}
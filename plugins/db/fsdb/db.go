@@ -5,6 +5,7 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -21,11 +22,23 @@ func NewDb(dir string) (db *Db) {
 	}
 
 	db.Sessions = &SessionsEntity{
-		&StorageEntity{Label: "Sessions", Dir: db.FilePath("sessions"), FileExtension: ".json"}}
+		StorageEntity: &StorageEntity{Label: "Sessions", Dir: db.FilePath("sessions"), FileExtension: SessionLogExtension}}
 
 	db.Contexts = &ContextsEntity{
 		&StorageEntity{Label: "Contexts", Dir: db.FilePath("contexts")}}
 
+	db.Quizzes = &QuizzesEntity{
+		&StorageEntity{Label: "Quizzes", Dir: db.FilePath("quizzes"), FileExtension: ".json"}}
+
+	db.RenderCache = &StorageEntity{Label: "Render cache", Dir: db.FilePath("cache/render"), FileExtension: ".md"}
+	db.LastOutputs = &StorageEntity{Label: "Last outputs", Dir: db.FilePath("cache/last"), FileExtension: ".md"}
+	db.RemotePatterns = &StorageEntity{Label: "Remote patterns", Dir: db.FilePath("cache/patterns"), FileExtension: ".md"}
+
+	db.Trash = NewTrash(db.FilePath("trash"))
+	db.Trash.Register(db.Patterns.StorageEntity)
+	db.Trash.Register(db.Sessions.StorageEntity, legacySessionExtension)
+	db.Trash.Register(db.Contexts.StorageEntity)
+
 	return
 }
 
@@ -35,11 +48,42 @@ type Db struct {
 	Patterns *PatternsEntity
 	Sessions *SessionsEntity
 	Contexts *ContextsEntity
+	Quizzes  *QuizzesEntity
+
+	RenderCache *StorageEntity
+	// LastOutputs are the last outputs per pattern and input, used to show what changed since the previous run
+	LastOutputs *StorageEntity
+	// RemotePatterns caches the patterns that are given by URL
+	RemotePatterns *StorageEntity
+	Trash          *Trash
 
 	EnvFilePath string
+
+	// Ephemeral runs don't write anything to disk
+	Ephemeral bool
+
+	envFileKeys []string
+	envMutex    sync.Mutex
+}
+
+// SetEphemeral makes the database read-only, sessions are used but not saved and nothing is written to disk
+func (o *Db) SetEphemeral() {
+	o.Ephemeral = true
+	for _, entity := range []*StorageEntity{
+		o.Patterns.StorageEntity, o.Sessions.StorageEntity, o.Contexts.StorageEntity, o.Quizzes.StorageEntity,
+		o.RenderCache, o.LastOutputs, o.RemotePatterns} {
+		entity.ReadOnly = true
+	}
+	o.Trash.ReadOnly = true
 }
 
 func (o *Db) Configure() (err error) {
+	// an ephemeral run only reads, so the directories are not created
+	if o.Ephemeral {
+		err = o.LoadEnvFile()
+		return
+	}
+
 	if err = os.MkdirAll(o.Dir, os.ModePerm); err != nil {
 		return
 	}
@@ -60,12 +104,72 @@ func (o *Db) Configure() (err error) {
 		return
 	}
 
+	if err = o.Quizzes.Configure(); err != nil {
+		return
+	}
+
+	if err = o.RenderCache.Configure(); err != nil {
+		return
+	}
+
+	if err = o.LastOutputs.Configure(); err != nil {
+		return
+	}
+
+	if err = o.RemotePatterns.Configure(); err != nil {
+		return
+	}
+
+	if err = o.Trash.Configure(); err != nil {
+		return
+	}
+
 	return
 }
 
 func (o *Db) LoadEnvFile() (err error) {
 	if err = godotenv.Load(o.EnvFilePath); err != nil {
 		err = fmt.Errorf("error loading .env file: %s", err)
+		return
+	}
+	o.envFileKeys = o.readEnvFileKeys()
+	return
+}
+
+// ReloadEnvFile applies the current content of the .env file to the environment. Unlike LoadEnvFile it overrides
+// variables that are already set and unsets the variables that were removed from the file since the last load.
+func (o *Db) ReloadEnvFile() (err error) {
+	o.envMutex.Lock()
+	defer o.envMutex.Unlock()
+
+	var env map[string]string
+	if env, err = godotenv.Read(o.EnvFilePath); err != nil {
+		err = fmt.Errorf("error loading .env file: %s", err)
+		return
+	}
+
+	for _, key := range o.envFileKeys {
+		if _, exists := env[key]; !exists {
+			if err = os.Unsetenv(key); err != nil {
+				return
+			}
+		}
+	}
+
+	o.envFileKeys = nil
+	for key, value := range env {
+		if err = os.Setenv(key, value); err != nil {
+			return
+		}
+		o.envFileKeys = append(o.envFileKeys, key)
+	}
+	return
+}
+
+func (o *Db) readEnvFileKeys() (ret []string) {
+	env, _ := godotenv.Read(o.EnvFilePath)
+	for key := range env {
+		ret = append(ret, key)
 	}
 	return
 }
@@ -77,6 +181,10 @@ func (o *Db) IsEnvFileExists() (ret bool) {
 }
 
 func (o *Db) SaveEnv(content string) (err error) {
+	if o.Ephemeral {
+		err = ErrReadOnly
+		return
+	}
 	err = os.WriteFile(o.EnvFilePath, []byte(content), 0644)
 	return
 }
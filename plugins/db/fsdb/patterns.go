@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+var versionRegex = regexp.MustCompile(`^(.+)_v(\d+)$`)
+
 type PatternsEntity struct {
 	*StorageEntity
 	SystemPatternFile      string
@@ -26,6 +30,10 @@ func (o *PatternsEntity) Get(name string) (ret *Pattern, err error) {
 		Name:    name,
 		Pattern: patternStr,
 	}
+
+	if metadata, metadataErr := o.GetMetadata(name); metadataErr == nil {
+		ret.Description = metadata.Description
+	}
 	return
 }
 
@@ -36,9 +44,38 @@ func (o *PatternsEntity) GetApplyVariables(name string, variables map[string]str
 		return
 	}
 
-	if variables != nil && len(variables) > 0 {
-		for variableName, value := range variables {
-			ret.Pattern = strings.ReplaceAll(ret.Pattern, variableName, value)
+	ret.Pattern = ApplyVariables(ret.Pattern, variables)
+	return
+}
+
+// NextVersionName returns the name of the next local version of the pattern that doesn't exist yet, e.g. summarize_v2
+// for summarize and summarize_v3 for summarize_v2
+func (o *PatternsEntity) NextVersionName(name string) string {
+	base, version := name, 1
+	if match := versionRegex.FindStringSubmatch(name); match != nil {
+		base = match[1]
+		version, _ = strconv.Atoi(match[2])
+	}
+	version++
+	for o.Exists(fmt.Sprintf("%s_v%d", base, version)) {
+		version++
+	}
+	return fmt.Sprintf("%s_v%d", base, version)
+}
+
+// SaveFiles writes the files of the pattern, e.g. its system.md, the directory of the pattern is created
+func (o *PatternsEntity) SaveFiles(name string, files map[string][]byte) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	dir := filepath.Join(o.Dir, name)
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	}
+	for fileName, content := range files {
+		if err = o.writeFileAtomic(filepath.Join(dir, fileName), content); err != nil {
+			err = fmt.Errorf("could not save %s of pattern %s: %v", fileName, name, err)
+			return
 		}
 	}
 	return
@@ -65,4 +102,6 @@ type Pattern struct {
 	Name        string
 	Description string
 	Pattern     string
+	// User is the user prompt of a pattern file, see UserPatternFile
+	User string `json:",omitempty"`
 }
@@ -0,0 +1,87 @@
+package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UserPatternFile is the optional user prompt of a pattern file, it is put before the input
+const UserPatternFile = "user.md"
+
+// IsPatternURL returns whether the pattern is given by the URL of its system prompt instead of by name
+func IsPatternURL(name string) bool {
+	return strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://")
+}
+
+// IsPatternFile returns whether the pattern is given by a path to its system prompt or its directory instead of by
+// name, e.g. ./draft/system.md
+func IsPatternFile(name string) bool {
+	if name == "" || IsPatternURL(name) {
+		return false
+	}
+	if filepath.IsAbs(name) || strings.HasSuffix(name, ".md") || name == "." || name == ".." {
+		return true
+	}
+	for _, prefix := range []string{".", "..", "~"} {
+		if strings.HasPrefix(name, prefix+"/") || strings.HasPrefix(name, prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFromFile returns the pattern of a system prompt file, or of a directory with a system.md, that is not installed
+// in the patterns directory. A user.md next to the system prompt is read too.
+func (o *PatternsEntity) GetFromFile(path string) (ret *Pattern, err error) {
+	var systemFile string
+	if systemFile, err = o.patternFilePath(path); err != nil {
+		return
+	}
+
+	var system []byte
+	if system, err = os.ReadFile(systemFile); err != nil {
+		return
+	}
+
+	ret = &Pattern{Name: path, Pattern: string(system)}
+	if user, userErr := os.ReadFile(filepath.Join(filepath.Dir(systemFile), UserPatternFile)); userErr == nil {
+		ret.User = string(user)
+	}
+	if metadata, metadataErr := o.GetMetadata(path); metadataErr == nil {
+		ret.Description = metadata.Description
+	}
+	return
+}
+
+// patternFilePath returns the path of the system prompt of a pattern file or directory
+func (o *PatternsEntity) patternFilePath(path string) (ret string, err error) {
+	if ret, err = expandHome(path); err != nil {
+		return
+	}
+	if info, statErr := os.Stat(ret); statErr == nil && info.IsDir() {
+		ret = filepath.Join(ret, o.SystemPatternFile)
+	}
+	return
+}
+
+// ApplyVariables replaces the variables in the content of a pattern
+func ApplyVariables(content string, variables map[string]string) string {
+	for variableName, value := range variables {
+		content = strings.ReplaceAll(content, variableName, value)
+	}
+	return content
+}
+
+func expandHome(path string) (ret string, err error) {
+	ret = path
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return
+	}
+	var home string
+	if home, err = os.UserHomeDir(); err != nil {
+		return
+	}
+	ret = filepath.Join(home, path[1:])
+	return
+}
@@ -0,0 +1,60 @@
+package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsPatternFile(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"summarize":                     false,
+		"./draft/system.md":             true,
+		"../draft":                      true,
+		"/tmp/draft":                    true,
+		"~/draft/system.md":             true,
+		"draft/system.md":               true,
+		"https://example.com/system.md": false,
+		"summarize/dmiessler/summarize": false,
+	} {
+		if IsPatternFile(name) != expected {
+			t.Errorf("IsPatternFile(%s) should be %v", name, expected)
+		}
+	}
+}
+
+func TestPatterns_GetFromFile(t *testing.T) {
+	patterns := &PatternsEntity{
+		StorageEntity:     &StorageEntity{Dir: t.TempDir(), ItemIsDir: true},
+		SystemPatternFile: "system.md",
+	}
+
+	draft := filepath.Join(t.TempDir(), "draft")
+	_ = os.MkdirAll(draft, os.ModePerm)
+	_ = os.WriteFile(filepath.Join(draft, "system.md"), []byte("You are a #role."), 0644)
+	_ = os.WriteFile(filepath.Join(draft, UserPatternFile), []byte("CONTENT:"), 0644)
+	_ = os.WriteFile(filepath.Join(draft, PatternMetadataFile), []byte(`{"candidates": 2}`), 0644)
+
+	for _, path := range []string{draft, filepath.Join(draft, "system.md")} {
+		pattern, err := patterns.GetFromFile(path)
+		if err != nil {
+			t.Fatalf("could not read the pattern file %s: %v", path, err)
+		}
+		if pattern.Pattern != "You are a #role." || pattern.User != "CONTENT:" {
+			t.Errorf("unexpected pattern %v", pattern)
+		}
+	}
+
+	metadata, err := patterns.GetMetadata(filepath.Join(draft, "system.md"))
+	if err != nil || metadata.Candidates != 2 {
+		t.Errorf("expected the pattern.json next to the file, got %v %v", metadata, err)
+	}
+
+	if _, err = patterns.GetFromFile(filepath.Join(draft, "missing.md")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+
+	if ret := ApplyVariables("You are a #role.", map[string]string{"#role": "editor"}); ret != "You are a editor." {
+		t.Errorf("unexpected variables applied %s", ret)
+	}
+}
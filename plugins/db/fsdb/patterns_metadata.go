@@ -0,0 +1,117 @@
+package fsdb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PatternMetadataFile is an optional file of a pattern directory that describes the pattern
+const PatternMetadataFile = "pattern.json"
+
+const patternDescriptionMaxLength = 160
+
+// PatternMetadata is read from the pattern.json file of a pattern, missing values are derived from the system prompt
+type PatternMetadata struct {
+	Description string        `json:"description,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	Input       *PatternInput `json:"input,omitempty"`
+	// Candidates is the number of answers to pick from, for patterns whose outputs are a matter of taste
+	Candidates int `json:"candidates,omitempty"`
+	// Jina are the default options of Jina AI for the pattern, e.g. {"target_selector": "article"}
+	Jina json.RawMessage `json:"jina,omitempty"`
+	// Parameters are the recommended model parameters of the pattern in the names of Ollama, e.g. {"temperature": 0.2},
+	// they are used for the exported Ollama models
+	Parameters map[string]any `json:"parameters,omitempty"`
+}
+
+// PatternInput is the contract of the input a pattern expects, e.g. {"type": "git-diff", "max_size": 100000}
+type PatternInput struct {
+	// Type is one of youtube-transcript, url-article, git-diff, code, email or text
+	Type string `json:"type"`
+	// MaxSize is the size in bytes of the largest input the pattern handles well, 0 for no limit
+	MaxSize int `json:"max_size,omitempty"`
+}
+
+// GetMetadata returns the metadata in the pattern.json of the pattern, or next to the file of a pattern file.
+// Without a description, the first sentence of the IDENTITY and PURPOSE section of the system prompt is used.
+func (o *PatternsEntity) GetMetadata(name string) (ret *PatternMetadata, err error) {
+	ret = &PatternMetadata{}
+	if IsPatternURL(name) {
+		// the metadata of remote patterns is not fetched
+		return
+	}
+
+	systemFile := filepath.Join(o.Dir, name, o.SystemPatternFile)
+	if IsPatternFile(name) {
+		if systemFile, err = o.patternFilePath(name); err != nil {
+			return
+		}
+	}
+
+	if content, readErr := os.ReadFile(filepath.Join(filepath.Dir(systemFile), PatternMetadataFile)); readErr == nil {
+		if err = json.Unmarshal(content, ret); err != nil {
+			return
+		}
+	}
+
+	if ret.Description == "" {
+		var system []byte
+		if system, err = os.ReadFile(systemFile); err != nil {
+			return
+		}
+		ret.Description = DescribePattern(string(system))
+	}
+	return
+}
+
+// GetInstalledNames returns the names of the directories that contain a pattern, other directories like the one of
+// helper scripts are skipped
+func (o *PatternsEntity) GetInstalledNames() (ret []string, err error) {
+	var names []string
+	if names, err = o.GetNames(); err != nil {
+		return
+	}
+	for _, name := range names {
+		if _, statErr := os.Stat(filepath.Join(o.Dir, name, o.SystemPatternFile)); statErr == nil {
+			ret = append(ret, name)
+		}
+	}
+	return
+}
+
+// DescribePattern returns the first sentence of the purpose of a system prompt
+func DescribePattern(system string) (ret string) {
+	var paragraph []string
+	inPurpose := !strings.Contains(strings.ToUpper(system), "# IDENTITY")
+	for _, line := range strings.Split(system, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			if len(paragraph) > 0 {
+				break
+			}
+			inPurpose = inPurpose || strings.Contains(strings.ToUpper(line), "IDENTITY")
+			continue
+		}
+		if !inPurpose {
+			continue
+		}
+		if line == "" {
+			if len(paragraph) > 0 {
+				break
+			}
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+
+	ret = strings.Join(paragraph, " ")
+	if end := strings.Index(ret, ". "); end > 0 {
+		ret = ret[:end+1]
+	}
+	if runes := []rune(ret); len(runes) > patternDescriptionMaxLength {
+		ret = string(runes[:patternDescriptionMaxLength]) + "..."
+	}
+	return
+}
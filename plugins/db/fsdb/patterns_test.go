@@ -1 +1,74 @@
 package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPatterns_GetMetadata(t *testing.T) {
+	dir := t.TempDir()
+	patterns := &PatternsEntity{
+		StorageEntity:     &StorageEntity{Dir: dir, ItemIsDir: true},
+		SystemPatternFile: "system.md",
+	}
+
+	_ = os.MkdirAll(filepath.Join(dir, "summarize"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "summarize", "system.md"), []byte(
+		"# IDENTITY and PURPOSE\n\nYou are an expert content summarizer. You take content in.\n\n# STEPS\n\n- Read it.\n"), 0644)
+	_ = os.MkdirAll(filepath.Join(dir, "described"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "described", "system.md"), []byte("Answer questions."), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "described", PatternMetadataFile), []byte(
+		`{"description": "From metadata", "input": {"type": "git-diff", "max_size": 1000}}`), 0644)
+	_ = os.MkdirAll(filepath.Join(dir, "raycast"), os.ModePerm)
+
+	metadata, err := patterns.GetMetadata("summarize")
+	if err != nil || metadata.Description != "You are an expert content summarizer." {
+		t.Errorf("unexpected description %v %v", metadata, err)
+	}
+
+	pattern, err := patterns.Get("described")
+	if err != nil || pattern.Description != "From metadata" {
+		t.Errorf("unexpected description %v %v", pattern, err)
+	}
+
+	metadata, err = patterns.GetMetadata("described")
+	if err != nil || metadata.Input == nil || metadata.Input.Type != "git-diff" || metadata.Input.MaxSize != 1000 {
+		t.Errorf("unexpected input contract %v %v", metadata, err)
+	}
+
+	names, _ := patterns.GetInstalledNames()
+	if len(names) != 2 {
+		t.Errorf("expected the directory without a system prompt to be skipped, got %v", names)
+	}
+}
+
+func TestPatterns_NextVersionName(t *testing.T) {
+	dir := t.TempDir()
+	patterns := &PatternsEntity{
+		StorageEntity:     &StorageEntity{Dir: dir, ItemIsDir: true},
+		SystemPatternFile: "system.md",
+	}
+
+	if name := patterns.NextVersionName("summarize"); name != "summarize_v2" {
+		t.Errorf("unexpected version %s", name)
+	}
+
+	if err := patterns.SaveFiles("summarize_v2", map[string][]byte{"system.md": []byte("Summarize.")}); err != nil {
+		t.Fatalf("could not save the pattern: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(dir, "summarize_v2", "system.md")); err != nil || string(content) != "Summarize." {
+		t.Errorf("unexpected content %s %v", content, err)
+	}
+
+	for _, name := range []string{"summarize", "summarize_v2"} {
+		if next := patterns.NextVersionName(name); next != "summarize_v3" {
+			t.Errorf("unexpected version %s of %s", next, name)
+		}
+	}
+
+	patterns.ReadOnly = true
+	if err := patterns.SaveFiles("summarize_v3", map[string][]byte{"system.md": nil}); err != ErrReadOnly {
+		t.Errorf("expected the read-only error, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package fsdb
+
+import (
+	"fmt"
+	"time"
+)
+
+type QuizzesEntity struct {
+	*StorageEntity
+}
+
+// Get loads the deck, a deck that doesn't exist yet is empty
+func (o *QuizzesEntity) Get(name string) (ret *QuizDeck, err error) {
+	ret = &QuizDeck{Name: name}
+	if !o.Exists(name) {
+		return
+	}
+	if err = o.LoadAsJson(name, ret); err != nil {
+		return
+	}
+	ret.Name = name
+	return
+}
+
+func (o *QuizzesEntity) SaveDeck(deck *QuizDeck) (err error) {
+	return o.SaveAsJson(deck.Name, deck)
+}
+
+// QuizDeck holds the questions of a quiz with their repetition schedule and the scores of the runs
+type QuizDeck struct {
+	Name  string      `json:"-"`
+	Cards []*QuizCard `json:"cards"`
+	Runs  []*QuizRun  `json:"runs,omitempty"`
+}
+
+// AddCards adds the cards whose questions are not in the deck yet and returns the number of added cards
+func (o *QuizDeck) AddCards(cards []*QuizCard) (ret int) {
+	known := map[string]bool{}
+	for _, card := range o.Cards {
+		known[card.Question] = true
+	}
+	for _, card := range cards {
+		if !known[card.Question] {
+			known[card.Question] = true
+			o.Cards = append(o.Cards, card)
+			ret++
+		}
+	}
+	return
+}
+
+// QuizCard is a question with the state of the SM-2 spaced repetition, a card without reviews is new
+type QuizCard struct {
+	Subject  string `json:"subject,omitempty"`
+	Question string `json:"question"`
+	// Answer is the generated answer the answers are graded against, empty if the pattern didn't generate one
+	Answer string `json:"answer,omitempty"`
+
+	Repetitions int           `json:"repetitions"`
+	Interval    int           `json:"interval_days"`
+	Ease        float64       `json:"ease,omitempty"`
+	Due         time.Time     `json:"due"`
+	Reviews     []*QuizReview `json:"reviews,omitempty"`
+}
+
+func (o *QuizCard) IsNew() bool {
+	return len(o.Reviews) == 0
+}
+
+type QuizReview struct {
+	Time time.Time `json:"time"`
+	// Score is the grade of the answer from 0, no idea, to 5, perfect
+	Score int `json:"score"`
+}
+
+// QuizRun is the result of taking the quiz once
+type QuizRun struct {
+	Time    time.Time `json:"time"`
+	Asked   int       `json:"asked"`
+	Correct int       `json:"correct"`
+}
+
+func (o *QuizRun) String() string {
+	return fmt.Sprintf("%s  %d/%d correct", o.Time.Format("2006-01-02 15:04"), o.Correct, o.Asked)
+}
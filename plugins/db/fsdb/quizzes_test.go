@@ -0,0 +1,32 @@
+package fsdb
+
+import (
+	"testing"
+)
+
+func TestQuizzes_SaveDeck(t *testing.T) {
+	quizzes := &QuizzesEntity{
+		StorageEntity: &StorageEntity{Dir: t.TempDir(), FileExtension: ".json"},
+	}
+
+	deck, err := quizzes.Get("networking")
+	if err != nil || len(deck.Cards) != 0 {
+		t.Fatalf("expected an empty new deck, got %v %v", deck, err)
+	}
+
+	added := deck.AddCards([]*QuizCard{{Question: "What is TCP?"}, {Question: "What is UDP?"}, {Question: "What is TCP?"}})
+	if added != 2 {
+		t.Errorf("expected duplicate questions to be skipped, added %d", added)
+	}
+	deck.Cards[0].Reviews = append(deck.Cards[0].Reviews, &QuizReview{Score: 4})
+	if err = quizzes.SaveDeck(deck); err != nil {
+		t.Fatalf("failed to save deck: %v", err)
+	}
+
+	if deck, err = quizzes.Get("networking"); err != nil {
+		t.Fatalf("failed to get deck: %v", err)
+	}
+	if deck.Name != "networking" || len(deck.Cards) != 2 || deck.Cards[0].IsNew() || !deck.Cards[1].IsNew() {
+		t.Errorf("unexpected deck %+v", deck)
+	}
+}
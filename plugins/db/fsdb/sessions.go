@@ -3,17 +3,39 @@ package fsdb
 import (
 	"fmt"
 	"github.com/danielmiessler/fabric/common"
+	"slices"
+	"sort"
+	"sync"
 )
 
 type SessionsEntity struct {
 	*StorageEntity
+
+	// locks holds a *sync.Mutex per session name
+	locks sync.Map
+}
+
+// Lock serializes the requests of a session, from loading it to saving the answer, so that concurrent requests don't
+// lose messages. It returns the function to unlock the session.
+func (o *SessionsEntity) Lock(name string) (unlock func()) {
+	value, _ := o.locks.LoadOrStore(name, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
 }
 
+// Get loads the session from its log, or from the legacy JSON array file, or creates a new session
 func (o *SessionsEntity) Get(name string) (session *Session, err error) {
-	session = &Session{Name: name}
+	session = &Session{Name: name, loaded: true}
 
-	if o.Exists(name) {
-		err = o.LoadAsJson(name, &session.Messages)
+	if path := o.findPath(name); path != "" {
+		var compact bool
+		if session.Messages, compact, err = readSessionFile(path); err != nil {
+			err = fmt.Errorf("could not load %s: %v", name, err)
+			return
+		}
+		session.stored = len(session.Messages)
+		session.compact = compact || path != o.BuildFilePathByName(name)
 	} else {
 		fmt.Printf("Creating new session: %s\n", name)
 	}
@@ -22,16 +44,131 @@ func (o *SessionsEntity) Get(name string) (session *Session, err error) {
 
 func (o *SessionsEntity) PrintSession(name string) (err error) {
 	if o.Exists(name) {
-		var session Session
-		if err = o.LoadAsJson(name, &session.Messages); err == nil {
+		var session *Session
+		if session, err = o.Get(name); err == nil {
 			fmt.Println(session.String())
 		}
 	}
 	return
 }
 
+// SaveSession appends the messages added since the session was loaded to its log. Sessions that were not loaded from
+// the log, like legacy or damaged ones, are written completely.
 func (o *SessionsEntity) SaveSession(session *Session) (err error) {
-	return o.SaveAsJson(session.Name, session.Messages)
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+
+	if !session.loaded || session.compact || session.stored > len(session.Messages) {
+		err = o.writeLog(session.Name, session.messagesToStore(session.Messages))
+	} else {
+		err = o.appendLog(session.Name, session.messagesToStore(session.Unsaved()))
+	}
+	if err != nil {
+		return
+	}
+
+	session.loaded, session.compact, session.stored = true, false, len(session.Messages)
+	err = o.updateIndex(session)
+	return
+}
+
+// Save replaces the session with the content, a JSON array of messages or a log with one message per line
+func (o *SessionsEntity) Save(name string, content []byte) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	var messages []*common.Message
+	if messages, _, err = parseSession(content); err != nil {
+		err = fmt.Errorf("could not save %s: %v", name, err)
+		return
+	}
+	err = o.SaveSession(&Session{Name: name, Messages: messages})
+	return
+}
+
+// Compact rewrites the log of the session, it converts legacy sessions and drops a damaged last line
+func (o *SessionsEntity) Compact(name string) (err error) {
+	var session *Session
+	if session, err = o.Get(name); err != nil {
+		return
+	}
+	session.compact = true
+	err = o.SaveSession(session)
+	return
+}
+
+func (o *SessionsEntity) Exists(name string) bool {
+	return o.findPath(name) != ""
+}
+
+// GetNames returns the names of the sessions in both the log and the legacy format
+func (o *SessionsEntity) GetNames() (ret []string, err error) {
+	if ret, err = o.StorageEntity.GetNames(); err != nil || o.legacy().FileExtension == o.FileExtension {
+		return
+	}
+
+	var legacyNames []string
+	if legacyNames, err = o.legacy().GetNames(); err != nil {
+		return
+	}
+	for _, name := range legacyNames {
+		if !slices.Contains(ret, name) {
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return
+}
+
+func (o *SessionsEntity) Delete(name string) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	if err = o.migrateLegacy(name); err != nil {
+		return
+	}
+	if err = o.StorageEntity.Delete(name); err != nil {
+		return
+	}
+	err = o.removeFromIndex(name)
+	return
+}
+
+func (o *SessionsEntity) Rename(oldName, newName string) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	if err = o.migrateLegacy(oldName); err != nil {
+		return
+	}
+	if err = o.StorageEntity.Rename(oldName, newName); err != nil {
+		return
+	}
+	err = o.removeFromIndex(oldName)
+	return
+}
+
+// legacy is the storage of sessions saved as one JSON array per file
+func (o *SessionsEntity) legacy() *StorageEntity {
+	return &StorageEntity{Label: o.Label, Dir: o.Dir, FileExtension: legacySessionExtension}
+}
+
+// findPath returns the file of the session, preferring the log over the legacy file, or an empty string
+func (o *SessionsEntity) findPath(name string) (ret string) {
+	if o.StorageEntity.Exists(name) {
+		ret = o.BuildFilePathByName(name)
+	} else if legacy := o.legacy(); legacy.FileExtension != o.FileExtension && legacy.Exists(name) {
+		ret = legacy.BuildFilePathByName(name)
+	}
+	return
+}
+
+func (o *SessionsEntity) migrateLegacy(name string) (err error) {
+	if !o.StorageEntity.Exists(name) && o.Exists(name) {
+		err = o.Compact(name)
+	}
+	return
 }
 
 type Session struct {
@@ -39,6 +176,50 @@ type Session struct {
 	Messages []*common.Message
 
 	vendorMessages []*common.Message
+
+	// loaded is set for sessions read by SessionsEntity.Get, stored is the number of their messages in the log
+	loaded  bool
+	stored  int
+	compact bool
+
+	// storedContents replace the content of messages in the log, see StoreAs
+	storedContents map[*common.Message]string
+}
+
+// StoreAs writes the given content to the log instead of the content of the message, e.g. a reference to an input that
+// must not be persisted. The message itself is sent to the vendor unchanged.
+func (o *Session) StoreAs(message *common.Message, content string) {
+	if o.storedContents == nil {
+		o.storedContents = map[*common.Message]string{}
+	}
+	o.storedContents[message] = content
+}
+
+// Unsaved returns the messages added since the session was loaded or saved
+func (o *Session) Unsaved() (ret []*common.Message) {
+	if o.stored <= len(o.Messages) {
+		ret = o.Messages[o.stored:]
+	} else {
+		ret = o.Messages
+	}
+	return
+}
+
+func (o *Session) messagesToStore(messages []*common.Message) (ret []*common.Message) {
+	ret = messages
+	if len(o.storedContents) == 0 {
+		return
+	}
+
+	ret = make([]*common.Message, len(messages))
+	for i, message := range messages {
+		if content, replaced := o.storedContents[message]; replaced {
+			ret[i] = &common.Message{Role: message.Role, Content: content}
+		} else {
+			ret[i] = message
+		}
+	}
+	return
 }
 
 func (o *Session) IsEmpty() bool {
@@ -0,0 +1,245 @@
+package fsdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/common"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// the index and its lock have no session file extension, so they are not listed as sessions
+const (
+	sessionsIndexFileName     = ".index"
+	sessionsIndexLockFileName = ".index.lock"
+)
+
+// sessionsIndexMaxOutdated is the number of outdated records of the index that triggers its compaction
+const sessionsIndexMaxOutdated = 100
+
+const sessionTitleMaxLength = 80
+
+// SessionInfo is the metadata of a session. It is kept in an index, so that listing sessions doesn't read them.
+type SessionInfo struct {
+	Name      string    `json:"name"`
+	Messages  int       `json:"messages"`
+	Title     string    `json:"title"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// sessionsIndexRecord is a line of the index. The index is only appended to, the last record of a session wins and
+// a removed session has a record with the removed flag.
+type sessionsIndexRecord struct {
+	SessionInfo
+	Removed bool `json:"removed,omitempty"`
+}
+
+// GetInfo returns the metadata of the session from the index, it is rebuilt if the session file changed
+func (o *SessionsEntity) GetInfo(name string) (ret *SessionInfo, err error) {
+	ret, err = o.getInfo(name, o.loadIndex())
+	return
+}
+
+// GetInfos returns the metadata of all sessions
+func (o *SessionsEntity) GetInfos() (ret []*SessionInfo, err error) {
+	var names []string
+	if names, err = o.GetNames(); err != nil {
+		return
+	}
+
+	index := o.loadIndex()
+	for _, name := range names {
+		var info *SessionInfo
+		if info, err = o.getInfo(name, index); err != nil {
+			return
+		}
+		ret = append(ret, info)
+	}
+	return
+}
+
+func (o *SessionsEntity) getInfo(name string, index map[string]*SessionInfo) (ret *SessionInfo, err error) {
+	path := o.findPath(name)
+	if path == "" {
+		err = os.ErrNotExist
+		return
+	}
+
+	var stat os.FileInfo
+	if stat, err = os.Stat(path); err != nil {
+		return
+	}
+
+	if ret = index[name]; ret != nil && ret.Size == stat.Size() && ret.UpdatedAt.Equal(stat.ModTime()) {
+		return
+	}
+
+	var session *Session
+	if session, err = o.Get(name); err != nil {
+		return
+	}
+	ret = newSessionInfo(session, stat)
+	if !o.ReadOnly {
+		err = o.appendIndex(&sessionsIndexRecord{SessionInfo: *ret})
+	}
+	return
+}
+
+func newSessionInfo(session *Session, stat os.FileInfo) (ret *SessionInfo) {
+	ret = &SessionInfo{
+		Name:      session.Name,
+		Messages:  len(session.Messages),
+		Size:      stat.Size(),
+		UpdatedAt: stat.ModTime(),
+	}
+	for _, message := range session.Messages {
+		if message.Role == goopenai.ChatMessageRoleUser {
+			// the index must not contain what the log doesn't, e.g. the title of an input that was not stored
+			ret.Title = buildSessionTitle(session.messagesToStore([]*common.Message{message})[0])
+			break
+		}
+	}
+	return
+}
+
+func buildSessionTitle(message *common.Message) (ret string) {
+	ret = strings.Join(strings.Fields(message.Content), " ")
+	if runes := []rune(ret); len(runes) > sessionTitleMaxLength {
+		ret = string(runes[:sessionTitleMaxLength]) + "..."
+	}
+	return
+}
+
+func (o *SessionsEntity) updateIndex(session *Session) (err error) {
+	var stat os.FileInfo
+	if stat, err = os.Stat(o.BuildFilePathByName(session.Name)); err != nil {
+		return
+	}
+	err = o.appendIndex(&sessionsIndexRecord{SessionInfo: *newSessionInfo(session, stat)})
+	return
+}
+
+func (o *SessionsEntity) removeFromIndex(name string) (err error) {
+	err = o.appendIndex(&sessionsIndexRecord{SessionInfo: SessionInfo{Name: name}, Removed: true})
+	return
+}
+
+// appendIndex adds the record to the end of the index, the file lock serializes it with other fabric processes
+func (o *SessionsEntity) appendIndex(record *sessionsIndexRecord) (err error) {
+	var line []byte
+	if line, err = json.Marshal(record); err != nil {
+		return
+	}
+
+	var unlock func()
+	if unlock, err = o.lockIndex(); err != nil {
+		return
+	}
+	defer unlock()
+
+	var file *os.File
+	if file, err = os.OpenFile(o.BuildFilePath(sessionsIndexFileName), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644); err != nil {
+		return
+	}
+	defer file.Close()
+
+	// a record cut off by a crash, or the index of older versions, doesn't end with a new line
+	if stat, statErr := file.Stat(); statErr == nil && stat.Size() > 0 {
+		last := make([]byte, 1)
+		if _, readErr := file.ReadAt(last, stat.Size()-1); readErr == nil && last[0] != '\n' {
+			line = append([]byte("\n"), line...)
+		}
+	}
+	_, err = file.Write(append(line, '\n'))
+	return
+}
+
+// loadIndex reads the index, a missing or damaged index is empty and gets rebuilt on demand. An index with many
+// outdated records is compacted.
+func (o *SessionsEntity) loadIndex() (ret map[string]*SessionInfo) {
+	var outdated int
+	ret, outdated = o.readIndex()
+	if outdated > sessionsIndexMaxOutdated && !o.ReadOnly {
+		_ = o.compactIndex()
+	}
+	return
+}
+
+// readIndex replays the records of the index and returns how many of them are outdated or damaged
+func (o *SessionsEntity) readIndex() (ret map[string]*SessionInfo, outdated int) {
+	ret = map[string]*SessionInfo{}
+	content, err := os.ReadFile(o.BuildFilePath(sessionsIndexFileName))
+	if err != nil {
+		return
+	}
+
+	records := 0
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		records++
+		var record sessionsIndexRecord
+		if json.Unmarshal(line, &record) != nil || record.Name == "" {
+			continue
+		}
+		if record.Removed {
+			delete(ret, record.Name)
+		} else {
+			info := record.SessionInfo
+			ret[record.Name] = &info
+		}
+	}
+	outdated = records - len(ret)
+	return
+}
+
+// compactIndex replaces the index by one record per session
+func (o *SessionsEntity) compactIndex() (err error) {
+	var unlock func()
+	if unlock, err = o.lockIndex(); err != nil {
+		return
+	}
+	defer unlock()
+
+	// the index is read again, other processes may have appended records in the meantime
+	index, _ := o.readIndex()
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var content []byte
+	for _, name := range names {
+		var line []byte
+		if line, err = json.Marshal(&sessionsIndexRecord{SessionInfo: *index[name]}); err != nil {
+			return
+		}
+		content = append(append(content, line...), '\n')
+	}
+	err = o.writeFileAtomic(o.BuildFilePath(sessionsIndexFileName), content)
+	return
+}
+
+// lockIndex locks the index file against the other fabric processes, e.g. a command line run and the server
+func (o *SessionsEntity) lockIndex() (unlock func(), err error) {
+	var file *os.File
+	if file, err = os.OpenFile(o.BuildFilePath(sessionsIndexLockFileName), os.O_CREATE|os.O_RDWR, 0644); err != nil {
+		return
+	}
+	if err = lockFile(file); err != nil {
+		_ = file.Close()
+		return
+	}
+	unlock = func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}
+	return
+}
@@ -0,0 +1,118 @@
+package fsdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/danielmiessler/fabric/common"
+)
+
+// Sessions are stored as logs with one JSON message per line, so that a turn is saved by appending its messages.
+// Sessions saved before are JSON arrays, they are read and converted to logs on their next save.
+const (
+	SessionLogExtension    = ".jsonl"
+	legacySessionExtension = ".json"
+)
+
+func readSessionFile(path string) (ret []*common.Message, compact bool, err error) {
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+	ret, compact, err = parseSession(content)
+	return
+}
+
+// parseSession reads a log or a legacy JSON array. A damaged last line, e.g. of a crash while appending, is skipped and
+// compact is set to rewrite the log.
+func parseSession(content []byte) (ret []*common.Message, compact bool, err error) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 {
+		return
+	}
+
+	if trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &ret)
+		compact = true
+		return
+	}
+
+	lines := bytes.Split(trimmed, []byte("\n"))
+	for i, line := range lines {
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		message := &common.Message{}
+		if lineErr := json.Unmarshal(line, message); lineErr != nil {
+			if i == len(lines)-1 {
+				compact = true
+				break
+			}
+			err = fmt.Errorf("line %d: %v", i+1, lineErr)
+			return
+		}
+		ret = append(ret, message)
+	}
+	return
+}
+
+func buildSessionLog(messages []*common.Message) (ret []byte, err error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	for _, message := range messages {
+		if err = encoder.Encode(message); err != nil {
+			return
+		}
+	}
+	ret = buffer.Bytes()
+	return
+}
+
+// writeLog replaces the log of the session and removes its legacy file
+func (o *SessionsEntity) writeLog(name string, messages []*common.Message) (err error) {
+	var content []byte
+	if content, err = buildSessionLog(messages); err != nil {
+		return
+	}
+	if err = o.StorageEntity.Save(name, content); err != nil {
+		return
+	}
+
+	if legacy := o.legacy(); legacy.FileExtension != o.FileExtension && legacy.Exists(name) {
+		err = os.Remove(legacy.BuildFilePathByName(name))
+	}
+	return
+}
+
+// appendLog writes the messages to the end of the log with a single write and syncs it to the disk
+func (o *SessionsEntity) appendLog(name string, messages []*common.Message) (err error) {
+	if len(messages) == 0 {
+		return
+	}
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+
+	var content []byte
+	if content, err = buildSessionLog(messages); err != nil {
+		return
+	}
+
+	var file *os.File
+	if file, err = os.OpenFile(o.BuildFilePathByName(name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		err = fmt.Errorf("could not save %s: %v", name, err)
+		return
+	}
+	defer file.Close()
+
+	if _, err = file.Write(content); err != nil {
+		err = fmt.Errorf("could not save %s: %v", name, err)
+		return
+	}
+	err = file.Sync()
+	return
+}
@@ -1,6 +1,12 @@
 package fsdb
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/danielmiessler/fabric/common"
@@ -36,3 +42,158 @@ func TestSessions_SaveSession(t *testing.T) {
 		t.Errorf("expected session to be saved")
 	}
 }
+
+func TestSessions_AppendOnly(t *testing.T) {
+	dir := t.TempDir()
+	sessions := &SessionsEntity{
+		StorageEntity: &StorageEntity{Dir: dir, FileExtension: SessionLogExtension},
+	}
+	session, _ := sessions.Get("log")
+	session.Append(&common.Message{Role: "user", Content: "question 1"}, &common.Message{Role: "assistant", Content: "answer 1"})
+	if err := sessions.SaveSession(session); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	firstTurn, _ := os.ReadFile(filepath.Join(dir, "log.jsonl"))
+
+	session, _ = sessions.Get("log")
+	session.Append(&common.Message{Role: "user", Content: "question 2"}, &common.Message{Role: "assistant", Content: "answer 2"})
+	if err := sessions.SaveSession(session); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	bothTurns, _ := os.ReadFile(filepath.Join(dir, "log.jsonl"))
+
+	if !strings.HasPrefix(string(bothTurns), string(firstTurn)) {
+		t.Errorf("expected the second turn to be appended, got %s", bothTurns)
+	}
+	if lines := strings.Count(string(bothTurns), "\n"); lines != 4 {
+		t.Errorf("expected 4 lines, got %d", lines)
+	}
+
+	session, _ = sessions.Get("log")
+	if len(session.Messages) != 4 || session.Messages[3].Content != "answer 2" {
+		t.Errorf("unexpected messages %v", session.Messages)
+	}
+}
+
+func TestSessions_LegacyAndDamaged(t *testing.T) {
+	dir := t.TempDir()
+	sessions := &SessionsEntity{
+		StorageEntity: &StorageEntity{Dir: dir, FileExtension: SessionLogExtension},
+	}
+	_ = os.WriteFile(filepath.Join(dir, "legacy.json"), []byte(`[{"role":"user","content":"old"}]`), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "damaged.jsonl"), []byte("{\"role\":\"user\",\"content\":\"kept\"}\n{\"role\":\"assi"), 0644)
+
+	names, _ := sessions.GetNames()
+	if strings.Join(names, ",") != "damaged,legacy" {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	session, err := sessions.Get("legacy")
+	if err != nil || len(session.Messages) != 1 {
+		t.Fatalf("failed to read legacy session: %v %v", err, session.Messages)
+	}
+	session.Append(&common.Message{Role: "assistant", Content: "new"})
+	if err = sessions.SaveSession(session); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "legacy.json")); !os.IsNotExist(statErr) {
+		t.Errorf("expected the legacy file to be converted")
+	}
+
+	if err = sessions.Compact("damaged"); err != nil {
+		t.Fatalf("failed to compact session: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "damaged.jsonl"))
+	if string(content) != "{\"role\":\"user\",\"content\":\"kept\"}\n" {
+		t.Errorf("unexpected compacted log %q", content)
+	}
+}
+
+func TestSessions_GetInfo(t *testing.T) {
+	dir := t.TempDir()
+	sessions := &SessionsEntity{
+		StorageEntity: &StorageEntity{Dir: dir, FileExtension: SessionLogExtension},
+	}
+	session := &Session{Name: "research", Messages: []*common.Message{
+		{Role: "system", Content: "pattern"}, {Role: "user", Content: "What is\nthe answer?"}}}
+	if err := sessions.SaveSession(session); err != nil {
+		t.Fatalf("failed to save session: %v", err)
+	}
+
+	infos, err := sessions.GetInfos()
+	if err != nil || len(infos) != 1 {
+		t.Fatalf("failed to get infos: %v %v", err, infos)
+	}
+	if infos[0].Messages != 2 || infos[0].Title != "What is the answer?" {
+		t.Errorf("unexpected info %+v", infos[0])
+	}
+
+	// a change outside of fabric is detected
+	_ = os.WriteFile(filepath.Join(dir, "research.jsonl"), []byte("{\"role\":\"user\",\"content\":\"changed\"}\n"), 0644)
+	info, _ := sessions.GetInfo("research")
+	if info.Messages != 1 || info.Title != "changed" {
+		t.Errorf("expected the index to be rebuilt, got %+v", info)
+	}
+}
+
+func TestSessions_IndexAppendOnly(t *testing.T) {
+	dir := t.TempDir()
+	// a command line run and the server share the index, they are separate entities
+	newSessions := func() *SessionsEntity {
+		return &SessionsEntity{StorageEntity: &StorageEntity{Dir: dir, FileExtension: SessionLogExtension}}
+	}
+	_ = os.WriteFile(filepath.Join(dir, sessionsIndexFileName), []byte(`{"old":{"name":"old"}}`), 0644)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			session := &Session{Name: fmt.Sprintf("session%d", i), Messages: []*common.Message{{Role: "user", Content: "hi"}}}
+			if err := newSessions().SaveSession(session); err != nil {
+				t.Errorf("failed to save session: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sessions := newSessions()
+	if err := sessions.Delete("session0"); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	index, outdated := sessions.readIndex()
+	if len(index) != 19 || outdated != 3 || index["session1"].Title != "hi" {
+		t.Errorf("unexpected index %d entries, %d outdated", len(index), outdated)
+	}
+
+	for i := 0; i < sessionsIndexMaxOutdated; i++ {
+		_ = sessions.removeFromIndex("session1")
+	}
+	sessions.loadIndex()
+	content, _ := os.ReadFile(filepath.Join(dir, sessionsIndexFileName))
+	if lines := strings.Count(string(content), "\n"); lines != 18 {
+		t.Errorf("expected the compacted index to have 18 lines, got %d", lines)
+	}
+}
+
+func TestSessions_Ephemeral(t *testing.T) {
+	db := NewDb(t.TempDir())
+	db.SetEphemeral()
+	if err := db.Configure(); err == nil {
+		t.Fatalf("expected an error without .env file")
+	}
+
+	session := &Session{Name: "private", Messages: []*common.Message{{Role: "user", Content: "secret"}}}
+	if err := db.Sessions.SaveSession(session); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := db.Contexts.Save("context", []byte("secret")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := db.SaveEnv("KEY=value"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if entries, _ := os.ReadDir(db.Dir); len(entries) != 0 {
+		t.Errorf("expected nothing written, got %v", entries)
+	}
+}
@@ -2,6 +2,7 @@ package fsdb
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,22 @@ type StorageEntity struct {
 	Dir           string
 	ItemIsDir     bool
 	FileExtension string
+
+	// Trash receives the deleted items, without it they are removed
+	Trash *Trash
+
+	// ReadOnly rejects all changes, see Db.SetEphemeral
+	ReadOnly bool
+}
+
+// ErrReadOnly is returned for changes of read-only storage, e.g. in an ephemeral run
+var ErrReadOnly = errors.New("nothing is written to disk in an ephemeral run")
+
+func (o *StorageEntity) checkWritable() (err error) {
+	if o.ReadOnly {
+		err = ErrReadOnly
+	}
+	return
 }
 
 func (o *StorageEntity) Configure() (err error) {
@@ -60,6 +77,14 @@ func (o *StorageEntity) GetNames() (ret []string, err error) {
 }
 
 func (o *StorageEntity) Delete(name string) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	if o.Trash != nil {
+		err = o.Trash.Put(o, name)
+		return
+	}
+
 	if err = os.Remove(o.BuildFilePathByName(name)); err != nil {
 		err = fmt.Errorf("could not delete %s: %v", name, err)
 	}
@@ -73,19 +98,47 @@ func (o *StorageEntity) Exists(name string) (ret bool) {
 }
 
 func (o *StorageEntity) Rename(oldName, newName string) (err error) {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
 	if err = os.Rename(o.BuildFilePathByName(oldName), o.BuildFilePathByName(newName)); err != nil {
 		err = fmt.Errorf("could not rename %s to %s: %v", oldName, newName, err)
 	}
 	return
 }
 
+// Save writes the content to a temporary file and renames it, so that concurrent readers never see a partial item
 func (o *StorageEntity) Save(name string, content []byte) (err error) {
-	if err = os.WriteFile(o.BuildFilePathByName(name), content, 0644); err != nil {
+	if err = o.checkWritable(); err != nil {
+		return
+	}
+	if err = o.writeFileAtomic(o.BuildFilePathByName(name), content); err != nil {
 		err = fmt.Errorf("could not save %s: %v", name, err)
 	}
 	return
 }
 
+func (o *StorageEntity) writeFileAtomic(path string, content []byte) (err error) {
+	var file *os.File
+	if file, err = os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp"); err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(content); err != nil {
+		_ = file.Close()
+		return
+	}
+	if err = file.Close(); err != nil {
+		return
+	}
+	if err = os.Chmod(file.Name(), 0644); err != nil {
+		return
+	}
+	err = os.Rename(file.Name(), path)
+	return
+}
+
 func (o *StorageEntity) Load(name string) (ret []byte, err error) {
 	if ret, err = os.ReadFile(o.BuildFilePathByName(name)); err != nil {
 		err = fmt.Errorf("could not load %s: %v", name, err)
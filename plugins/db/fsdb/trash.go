@@ -0,0 +1,228 @@
+package fsdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const trashTimeLayout = "20060102-150405.000000000"
+
+// Trash keeps deleted items of the registered storage entities until they are restored or purged.
+// An item is moved to <trash dir>/<kind>/<deletion time>/<file name>.
+type Trash struct {
+	Dir string
+	// ReadOnly rejects restoring and purging, see Db.SetEphemeral
+	ReadOnly bool
+
+	entities map[string]*StorageEntity
+	// extensions are the file extensions of the items by kind, the current one first, then the ones of older versions
+	extensions map[string][]string
+}
+
+// TrashItem is a deleted item
+type TrashItem struct {
+	Kind      string
+	Name      string
+	DeletedAt time.Time
+	Path      string
+}
+
+func (o *TrashItem) String() string {
+	return fmt.Sprintf("%s  %s/%s", o.DeletedAt.Format(time.DateTime), o.Kind, o.Name)
+}
+
+func NewTrash(dir string) *Trash {
+	return &Trash{Dir: dir, entities: map[string]*StorageEntity{}, extensions: map[string][]string{}}
+}
+
+// Register makes the deletions of the entity go to the trash, its kind is the lower case label. The legacy extensions
+// are the ones of items deleted by older versions, e.g. sessions saved as .json.
+func (o *Trash) Register(entity *StorageEntity, legacyExtensions ...string) {
+	entity.Trash = o
+	kind := o.kind(entity)
+	o.entities[kind] = entity
+	o.extensions[kind] = append([]string{entity.FileExtension}, legacyExtensions...)
+}
+
+func (o *Trash) Configure() (err error) {
+	err = os.MkdirAll(o.Dir, os.ModePerm)
+	return
+}
+
+func (o *Trash) kind(entity *StorageEntity) string {
+	return strings.ToLower(entity.Label)
+}
+
+// Put moves the item of the entity to the trash
+func (o *Trash) Put(entity *StorageEntity, name string) (err error) {
+	source := entity.BuildFilePathByName(name)
+	if _, err = os.Stat(source); err != nil {
+		err = fmt.Errorf("could not delete %s: %v", name, err)
+		return
+	}
+
+	dir := filepath.Join(o.Dir, o.kind(entity), time.Now().Format(trashTimeLayout))
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return
+	}
+
+	if err = os.Rename(source, filepath.Join(dir, filepath.Base(source))); err != nil {
+		err = fmt.Errorf("could not move %s to the trash: %v", name, err)
+	}
+	return
+}
+
+// List returns the items in the trash, the latest deleted first
+func (o *Trash) List() (ret []*TrashItem, err error) {
+	for kind := range o.entities {
+		var deletions []os.DirEntry
+		if deletions, err = os.ReadDir(filepath.Join(o.Dir, kind)); err != nil {
+			if os.IsNotExist(err) {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		for _, deletion := range deletions {
+			deletedAt, parseErr := time.ParseInLocation(trashTimeLayout, deletion.Name(), time.Local)
+			if !deletion.IsDir() || parseErr != nil {
+				continue
+			}
+
+			dir := filepath.Join(o.Dir, kind, deletion.Name())
+			var files []os.DirEntry
+			if files, err = os.ReadDir(dir); err != nil {
+				return
+			}
+			for _, file := range files {
+				ret = append(ret, &TrashItem{
+					Kind:      kind,
+					Name:      o.itemName(kind, file.Name()),
+					DeletedAt: deletedAt,
+					Path:      filepath.Join(dir, file.Name()),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].DeletedAt.Equal(ret[j].DeletedAt) {
+			return ret[i].Kind+ret[i].Name < ret[j].Kind+ret[j].Name
+		}
+		return ret[i].DeletedAt.After(ret[j].DeletedAt)
+	})
+	return
+}
+
+// itemName trims the extension the item was deleted with, it may be the one of an older version
+func (o *Trash) itemName(kind string, fileName string) string {
+	for _, extension := range o.extensions[kind] {
+		if extension != "" && strings.HasSuffix(fileName, extension) {
+			return strings.TrimSuffix(fileName, extension)
+		}
+	}
+	return fileName
+}
+
+func (o *Trash) PrintList() (err error) {
+	var items []*TrashItem
+	if items, err = o.List(); err != nil {
+		return
+	}
+
+	if len(items) == 0 {
+		fmt.Printf("\nTrash is empty\n")
+		return
+	}
+
+	for _, item := range items {
+		fmt.Println(item)
+	}
+	return
+}
+
+// Restore moves the latest deleted item back, the reference is "<kind>/<name>" or a name that is unique in the trash
+func (o *Trash) Restore(ref string) (ret *TrashItem, err error) {
+	if o.ReadOnly {
+		err = ErrReadOnly
+		return
+	}
+
+	kind, name, hasKind := strings.Cut(ref, "/")
+	if !hasKind {
+		kind, name = "", ref
+	}
+
+	var items []*TrashItem
+	if items, err = o.List(); err != nil {
+		return
+	}
+
+	for _, item := range items {
+		if item.Name != name || (kind != "" && item.Kind != kind) {
+			continue
+		}
+		if ret == nil {
+			ret = item
+		} else if ret.Kind != item.Kind {
+			err = fmt.Errorf("%s is in the trash as %s/%s and %s/%s, please specify which one to restore",
+				name, ret.Kind, name, item.Kind, name)
+			return
+		}
+	}
+
+	if ret == nil {
+		err = fmt.Errorf("%s is not in the trash", ref)
+		return
+	}
+
+	// the item is restored with the file name it was deleted with, the storage of sessions still reads older formats
+	entity := o.entities[ret.Kind]
+	target := filepath.Join(entity.Dir, filepath.Base(ret.Path))
+	if _, statErr := os.Stat(target); entity.Exists(ret.Name) || statErr == nil {
+		err = fmt.Errorf("can't restore %s/%s, an item with the same name exists", ret.Kind, ret.Name)
+		return
+	}
+
+	if err = os.Rename(ret.Path, target); err != nil {
+		err = fmt.Errorf("could not restore %s/%s: %v", ret.Kind, ret.Name, err)
+		return
+	}
+	_ = os.Remove(filepath.Dir(ret.Path))
+	return
+}
+
+// Purge deletes the items that have been in the trash for longer than maxAge
+func (o *Trash) Purge(maxAge time.Duration) (ret []*TrashItem, err error) {
+	if o.ReadOnly {
+		err = ErrReadOnly
+		return
+	}
+
+	var items []*TrashItem
+	if items, err = o.List(); err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(-maxAge)
+	for _, item := range items {
+		if item.DeletedAt.After(deadline) {
+			continue
+		}
+		if err = os.RemoveAll(filepath.Dir(item.Path)); err != nil {
+			return
+		}
+		ret = append(ret, item)
+	}
+	return
+}
+
+// Empty deletes all items in the trash
+func (o *Trash) Empty() (ret []*TrashItem, err error) {
+	return o.Purge(0)
+}
@@ -0,0 +1,122 @@
+package fsdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTrash(t *testing.T) (trash *Trash, sessions *StorageEntity, contexts *StorageEntity) {
+	dir := t.TempDir()
+	sessions = &StorageEntity{Label: "Sessions", Dir: filepath.Join(dir, "sessions"), FileExtension: ".json"}
+	contexts = &StorageEntity{Label: "Contexts", Dir: filepath.Join(dir, "contexts")}
+	trash = NewTrash(filepath.Join(dir, "trash"))
+	trash.Register(sessions)
+	trash.Register(contexts)
+	assert.NoError(t, sessions.Configure())
+	assert.NoError(t, contexts.Configure())
+	assert.NoError(t, trash.Configure())
+	return
+}
+
+func TestTrash_DeleteAndRestore(t *testing.T) {
+	trash, sessions, _ := newTestTrash(t)
+
+	assert.NoError(t, sessions.Save("research", []byte("[]")))
+	assert.NoError(t, sessions.Delete("research"))
+	assert.False(t, sessions.Exists("research"))
+
+	items, err := trash.List()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "sessions", items[0].Kind)
+	assert.Equal(t, "research", items[0].Name)
+
+	item, err := trash.Restore("sessions/research")
+	assert.NoError(t, err)
+	assert.Equal(t, "research", item.Name)
+
+	content, err := sessions.Load("research")
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(content))
+
+	items, _ = trash.List()
+	assert.Empty(t, items)
+}
+
+func TestTrash_LegacyExtension(t *testing.T) {
+	dir := t.TempDir()
+	db := NewDb(dir)
+	assert.NoError(t, db.Sessions.Configure())
+
+	// a session deleted before the sessions were saved as logs
+	deletion := filepath.Join(dir, "trash", "sessions", time.Now().Format(trashTimeLayout))
+	assert.NoError(t, os.MkdirAll(deletion, os.ModePerm))
+	assert.NoError(t, os.WriteFile(filepath.Join(deletion, "old.json"), []byte(`[{"role":"user","content":"hi"}]`), 0644))
+
+	items, err := db.Trash.List()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "old", items[0].Name)
+
+	_, err = db.Trash.Restore("old")
+	assert.NoError(t, err)
+	session, err := db.Sessions.Get("old")
+	assert.NoError(t, err)
+	assert.Len(t, session.Messages, 1)
+}
+
+func TestTrash_RestoreErrors(t *testing.T) {
+	trash, sessions, contexts := newTestTrash(t)
+
+	_, err := trash.Restore("missing")
+	assert.ErrorContains(t, err, "not in the trash")
+
+	assert.NoError(t, sessions.Save("notes", []byte("session")))
+	assert.NoError(t, contexts.Save("notes", []byte("context")))
+	assert.NoError(t, sessions.Delete("notes"))
+	assert.NoError(t, contexts.Delete("notes"))
+
+	_, err = trash.Restore("notes")
+	assert.ErrorContains(t, err, "please specify")
+
+	assert.NoError(t, contexts.Save("notes", []byte("new context")))
+	_, err = trash.Restore("contexts/notes")
+	assert.ErrorContains(t, err, "same name exists")
+
+	_, err = trash.Restore("sessions/notes")
+	assert.NoError(t, err)
+}
+
+func TestTrash_Purge(t *testing.T) {
+	trash, sessions, contexts := newTestTrash(t)
+
+	assert.NoError(t, sessions.Save("old", []byte("[]")))
+	assert.NoError(t, sessions.Delete("old"))
+	assert.NoError(t, contexts.Save("new", []byte("context")))
+	assert.NoError(t, contexts.Delete("new"))
+
+	// age the deletion of the old session
+	items, _ := trash.List()
+	for _, item := range items {
+		if item.Name == "old" {
+			aged := filepath.Join(trash.Dir, item.Kind, time.Now().AddDate(0, 0, -31).Format(trashTimeLayout))
+			assert.NoError(t, os.Rename(filepath.Dir(item.Path), aged))
+		}
+	}
+
+	purged, err := trash.Purge(30 * 24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "old", purged[0].Name)
+
+	purged, err = trash.Empty()
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+
+	items, _ = trash.List()
+	assert.Empty(t, items)
+}
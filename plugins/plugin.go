@@ -71,6 +71,33 @@ func (o *PluginBase) Configure() (err error) {
 	return
 }
 
+// ConfigureSettings reads the settings from the environment and validates them, but skips the custom configuration
+// like creating API clients. It allows to check cheaply whether the plugin is usable.
+func (o *PluginBase) ConfigureSettings() (err error) {
+	err = o.Settings.Configure()
+	return
+}
+
+// ConfigureWith configures the plugin like Configure, but the values override the environment variables of the same
+// name. It is used for settings given with a single request, they are never written to the environment.
+func (o *PluginBase) ConfigureWith(values map[string]string) (err error) {
+	if err = o.Settings.ConfigureWith(values); err != nil {
+		return
+	}
+
+	if o.ConfigureCustom != nil {
+		err = o.ConfigureCustom()
+	}
+	return
+}
+
+// ResetSettings removes the values of all settings, saving the .env file afterward removes the plugin configuration
+func (o *PluginBase) ResetSettings() {
+	for _, setting := range o.Settings {
+		setting.Value = ""
+	}
+}
+
 func (o *PluginBase) Setup() (err error) {
 	if err = o.Ask(o.Name); err != nil {
 		return
@@ -127,6 +154,14 @@ func (o *Setting) Configure() error {
 	return o.IsValidErr()
 }
 
+func (o *Setting) ConfigureWith(values map[string]string) error {
+	if value, ok := values[o.EnvVariable]; ok {
+		o.Value = value
+		return o.IsValidErr()
+	}
+	return o.Configure()
+}
+
 func (o *Setting) FillEnvFileContent(buffer *bytes.Buffer) {
 	if o.IsDefined() {
 		buffer.WriteString(o.EnvVariable)
@@ -208,6 +243,15 @@ func (o Settings) Configure() (err error) {
 	return
 }
 
+func (o Settings) ConfigureWith(values map[string]string) (err error) {
+	for _, setting := range o {
+		if err = setting.ConfigureWith(values); err != nil {
+			break
+		}
+	}
+	return
+}
+
 func (o Settings) FillEnvFileContent(buffer *bytes.Buffer) {
 	for _, setting := range o {
 		setting.FillEnvFileContent(buffer)
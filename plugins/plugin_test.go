@@ -37,6 +37,26 @@ func TestConfigurable_Configure(t *testing.T) {
 	assert.Equal(t, "test_value", setting.Value)
 }
 
+func TestConfigurable_ConfigureWith(t *testing.T) {
+	key := &Setting{EnvVariable: "TEST_KEY", Required: true}
+	url := &Setting{EnvVariable: "TEST_URL", Required: false}
+	conf := &PluginBase{
+		Settings: Settings{key, url},
+		Name:     "TestConfigurable",
+	}
+
+	_ = os.Setenv("TEST_KEY", "server_key")
+	_ = os.Setenv("TEST_URL", "http://server")
+	err := conf.ConfigureWith(map[string]string{"TEST_KEY": "request_key"})
+	assert.NoError(t, err)
+	assert.Equal(t, "request_key", key.Value)
+	assert.Equal(t, "http://server", url.Value)
+	assert.Equal(t, "server_key", os.Getenv("TEST_KEY"))
+
+	err = conf.ConfigureWith(map[string]string{"TEST_KEY": ""})
+	assert.Error(t, err)
+}
+
 func TestConfigurable_Setup(t *testing.T) {
 	setting := &Setting{
 		EnvVariable: "TEST_SETTING",
@@ -0,0 +1,219 @@
+package chatlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	FormatAuto     = "auto"
+	FormatSlack    = "slack"
+	FormatDiscord  = "discord"
+	FormatWhatsApp = "whatsapp"
+	FormatMatrix   = "matrix"
+
+	TimeLayout = "2006-01-02 15:04"
+)
+
+// Message is a chat message normalized from one of the supported export formats
+type Message struct {
+	Id          string
+	ThreadId    string
+	Channel     string
+	Speaker     string
+	Time        time.Time
+	Text        string
+	Attachments []string
+}
+
+// IsReply reports whether the message belongs to a thread started by another message
+func (o *Message) IsReply() bool {
+	return o.ThreadId != "" && o.ThreadId != o.Id
+}
+
+func (o *Message) String() (ret string) {
+	text := o.Text
+	for _, attachment := range o.Attachments {
+		text = strings.TrimSpace(fmt.Sprintf("%s [attachment: %s]", text, attachment))
+	}
+	ret = fmt.Sprintf("%s [%s]: %s", o.Speaker, o.Time.Format(TimeLayout), text)
+	return
+}
+
+// Options control the loading and the filtering of chat exports
+type Options struct {
+	Format   string
+	Since    time.Time
+	Until    time.Time
+	Channels []string
+	Location *time.Location
+}
+
+func (o *Options) location() (ret *time.Location) {
+	if ret = o.Location; ret == nil {
+		ret = time.Local
+	}
+	return
+}
+
+// Accept checks the message against the date range and channel filters
+func (o *Options) Accept(message *Message) (ret bool) {
+	if !o.Since.IsZero() && message.Time.Before(o.Since) {
+		return
+	}
+	if !o.Until.IsZero() && !message.Time.Before(o.Until) {
+		return
+	}
+	if len(o.Channels) > 0 && !lo.ContainsBy(o.Channels, func(channel string) bool {
+		return strings.EqualFold(strings.TrimPrefix(channel, "#"), message.Channel)
+	}) {
+		return
+	}
+	ret = true
+	return
+}
+
+// Load reads a chat export from a file or a directory and returns it as normalized text
+func Load(path string, opts *Options) (ret string, err error) {
+	var messages []*Message
+	if messages, err = LoadMessages(path, opts); err != nil {
+		return
+	}
+	ret = Format(messages)
+	return
+}
+
+// LoadMessages reads and filters the messages of a chat export
+func LoadMessages(path string, opts *Options) (ret []*Message, err error) {
+	format := opts.Format
+	if format == "" || format == FormatAuto {
+		if format, err = DetectFormat(path); err != nil {
+			return
+		}
+	}
+
+	var messages []*Message
+	switch format {
+	case FormatSlack:
+		messages, err = ParseSlackExport(path, opts.location())
+	case FormatDiscord:
+		messages, err = parseFile(path, ParseDiscord, opts.location())
+	case FormatWhatsApp:
+		messages, err = parseFile(path, ParseWhatsApp, opts.location())
+	case FormatMatrix:
+		messages, err = parseFile(path, ParseMatrix, opts.location())
+	default:
+		err = fmt.Errorf("unsupported chat export format: %s", format)
+	}
+	if err != nil {
+		return
+	}
+
+	ret = lo.Filter(messages, func(message *Message, _ int) bool {
+		return opts.Accept(message)
+	})
+	return
+}
+
+// DetectFormat guesses the export format from the path and the content
+func DetectFormat(path string) (ret string, err error) {
+	var info os.FileInfo
+	if info, err = os.Stat(path); err != nil {
+		return
+	}
+
+	// Slack exports are directories with a JSON file per channel and day
+	if info.IsDir() {
+		ret = FormatSlack
+		return
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".txt") {
+		ret = FormatWhatsApp
+		return
+	}
+
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	text := strings.TrimSpace(string(content))
+	switch {
+	case strings.HasPrefix(text, "["):
+		ret = FormatSlack
+	case strings.Contains(text, `"origin_server_ts"`):
+		ret = FormatMatrix
+	case strings.Contains(text, `"guild"`) || strings.Contains(text, `"author"`):
+		ret = FormatDiscord
+	default:
+		err = fmt.Errorf("could not detect the chat export format of %s, please set it explicitly", path)
+	}
+	return
+}
+
+// Format renders messages as "speaker [time]: text" lines, grouped by channel and thread
+func Format(messages []*Message) (ret string) {
+	var builder strings.Builder
+
+	channels := lo.Uniq(lo.Map(messages, func(message *Message, _ int) string { return message.Channel }))
+	for _, channel := range channels {
+		channelMessages := lo.Filter(messages, func(message *Message, _ int) bool { return message.Channel == channel })
+		if len(channels) > 1 && channel != "" {
+			if builder.Len() > 0 {
+				builder.WriteString("\n")
+			}
+			builder.WriteString(fmt.Sprintf("# %s\n", channel))
+		}
+		writeThreads(&builder, channelMessages)
+	}
+	ret = builder.String()
+	return
+}
+
+func writeThreads(builder *strings.Builder, messages []*Message) {
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Time.Before(messages[j].Time) })
+
+	replies := map[string][]*Message{}
+	ids := map[string]bool{}
+	for _, message := range messages {
+		ids[message.Id] = true
+	}
+	for _, message := range messages {
+		// replies to a thread root outside the filtered range are shown as top level messages
+		if message.IsReply() && ids[message.ThreadId] {
+			replies[message.ThreadId] = append(replies[message.ThreadId], message)
+		}
+	}
+
+	for _, message := range messages {
+		if message.IsReply() && ids[message.ThreadId] {
+			continue
+		}
+		builder.WriteString(message.String())
+		builder.WriteString("\n")
+		for _, reply := range replies[message.Id] {
+			builder.WriteString("  ")
+			builder.WriteString(reply.String())
+			builder.WriteString("\n")
+		}
+	}
+}
+
+func parseFile(path string, parse func([]byte, string, *time.Location) ([]*Message, error),
+	location *time.Location) (ret []*Message, err error) {
+
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+	channel := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	ret, err = parse(content, channel, location)
+	return
+}
@@ -0,0 +1,128 @@
+package chatlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSlackExport(t *testing.T) {
+	dir := t.TempDir()
+	users := `[{"id":"U1","name":"alice","profile":{"display_name":"Alice"}},{"id":"U2","name":"bob","real_name":"Bob"}]`
+	day := `[
+		{"type":"message","user":"U1","text":"Release today?","ts":"1700000000.000100","thread_ts":"1700000000.000100"},
+		{"type":"message","subtype":"channel_join","user":"U2","text":"joined","ts":"1700000010.000100"},
+		{"type":"message","user":"U3","text":"unrelated <@U1>","ts":"1700000060.000100","files":[{"name":"plan.pdf"}]},
+		{"type":"message","user":"U2","text":"Yes, at 5","ts":"1700000030.000100","thread_ts":"1700000000.000100"}
+	]`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "users.json"), []byte(users), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "general"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "general", "2023-11-14.json"), []byte(day), 0644))
+
+	ret, err := Load(dir, &Options{Location: time.UTC})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice [2023-11-14 22:13]: Release today?\n"+
+		"  Bob [2023-11-14 22:13]: Yes, at 5\n"+
+		"U3 [2023-11-14 22:14]: unrelated @Alice [attachment: plan.pdf]\n", ret)
+}
+
+func TestParseDiscord(t *testing.T) {
+	content := `{"guild":{"name":"g"},"channel":{"name":"dev"},"messages":[
+		{"id":"1","type":"Default","timestamp":"2024-01-02T10:00:00+00:00","content":"Anyone?","author":{"name":"carol"}},
+		{"id":"2","type":"Default","timestamp":"2024-01-02T10:05:00+00:00","content":"","author":{"name":"dave","nickname":"Dave"},"attachments":[{"fileName":"log.txt"}]},
+		{"id":"3","type":"Reply","timestamp":"2024-01-02T10:10:00+00:00","content":"Me","author":{"name":"erin"},"reference":{"messageId":"1"}},
+		{"id":"4","type":"Reply","timestamp":"2024-01-02T10:11:00+00:00","content":"Me too","author":{"name":"carol"},"reference":{"messageId":"3"}}
+	]}`
+
+	messages, err := ParseDiscord([]byte(content), "file", time.UTC)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 4)
+	assert.Equal(t, "1", messages[3].ThreadId)
+	assert.Equal(t, "Dave [2024-01-02 10:05]: [attachment: log.txt]", messages[1].String())
+	assert.Equal(t, "carol [2024-01-02 10:00]: Anyone?\n"+
+		"  erin [2024-01-02 10:10]: Me\n"+
+		"  carol [2024-01-02 10:11]: Me too\n"+
+		"Dave [2024-01-02 10:05]: [attachment: log.txt]\n", Format(messages))
+}
+
+func TestParseWhatsApp(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "Android day first",
+			content: "31/12/2023, 22:15 - Messages and calls are end-to-end encrypted.\n" +
+				"31/12/2023, 22:15 - Alice: Happy new year\nsee you tomorrow\n" +
+				"01/01/2024, 09:01 - Bob: <Media omitted>\n",
+			expected: "Alice [2023-12-31 22:15]: Happy new year\nsee you tomorrow\n" +
+				"Bob [2024-01-01 09:01]: [attachment: media]\n",
+		},
+		{
+			name: "iOS month first with meridiem",
+			content: "[1/2/24, 9:05:10 PM] Alice: Hi\n" +
+				"[1/13/24, 12:30:00 AM] Bob: ‎<attached: 00000012-PHOTO.jpg>\n",
+			expected: "Alice [2024-01-02 21:05]: Hi\n" +
+				"Bob [2024-01-13 00:30]: [attachment: 00000012-PHOTO.jpg]\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			messages, err := ParseWhatsApp([]byte(tc.content), "chat", time.UTC)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, Format(messages))
+		})
+	}
+}
+
+func TestParseMatrix(t *testing.T) {
+	content := `{"room_name":"ops","messages":[
+		{"type":"m.room.member","sender":"@alice:matrix.org","origin_server_ts":1700000000000},
+		{"type":"m.room.message","event_id":"$1","sender":"@alice:matrix.org","origin_server_ts":1700000000000,"content":{"msgtype":"m.text","body":"Deploy failed"}},
+		{"type":"m.room.message","event_id":"$2","sender":"@bob:example.org","origin_server_ts":1700000120000,"content":{"msgtype":"m.image","body":"trace.png","m.relates_to":{"rel_type":"m.thread","event_id":"$1"}}}
+	]}`
+
+	messages, err := ParseMatrix([]byte(content), "file", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice [2023-11-14 22:13]: Deploy failed\n"+
+		"  bob [2023-11-14 22:15]: [attachment: trace.png]\n", Format(messages))
+}
+
+func TestOptions_Accept(t *testing.T) {
+	opts := &Options{
+		Since:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Channels: []string{"#general"},
+	}
+
+	assert.True(t, opts.Accept(&Message{Channel: "general", Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}))
+	assert.False(t, opts.Accept(&Message{Channel: "random", Time: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}))
+	assert.False(t, opts.Accept(&Message{Channel: "general", Time: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)}))
+	assert.False(t, opts.Accept(&Message{Channel: "general", Time: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}))
+}
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"chat.txt":     "1/2/24, 9:05 PM - Alice: Hi",
+		"discord.json": `{"guild":{},"messages":[]}`,
+		"matrix.json":  `{"room_name":"r","messages":[{"origin_server_ts":1}]}`,
+		"slack.json":   `[]`,
+	}
+	expected := map[string]string{
+		"chat.txt": FormatWhatsApp, "discord.json": FormatDiscord, "matrix.json": FormatMatrix, "slack.json": FormatSlack,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		format, err := DetectFormat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[name], format, name)
+	}
+}
@@ -0,0 +1,78 @@
+package chatlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// discordExport is the JSON format of DiscordChatExporter
+type discordExport struct {
+	Channel struct {
+		Name string `json:"name"`
+	} `json:"channel"`
+	Messages []struct {
+		Id        string    `json:"id"`
+		Type      string    `json:"type"`
+		Timestamp time.Time `json:"timestamp"`
+		Content   string    `json:"content"`
+		Author    struct {
+			Name     string `json:"name"`
+			Nickname string `json:"nickname"`
+		} `json:"author"`
+		Attachments []struct {
+			FileName string `json:"fileName"`
+		} `json:"attachments"`
+		Reference *struct {
+			MessageId string `json:"messageId"`
+		} `json:"reference"`
+	} `json:"messages"`
+}
+
+// ParseDiscord parses a DiscordChatExporter JSON export. Replies are grouped in the thread of the message they reference.
+func ParseDiscord(content []byte, channel string, location *time.Location) (ret []*Message, err error) {
+	var export discordExport
+	if err = json.Unmarshal(content, &export); err != nil {
+		err = fmt.Errorf("could not parse Discord export: %v", err)
+		return
+	}
+
+	if export.Channel.Name != "" {
+		channel = export.Channel.Name
+	}
+
+	threadRoots := map[string]string{}
+	for _, discordMsg := range export.Messages {
+		if discordMsg.Type != "" && discordMsg.Type != "Default" && discordMsg.Type != "Reply" {
+			continue
+		}
+
+		message := &Message{
+			Id:      discordMsg.Id,
+			Channel: channel,
+			Speaker: discordMsg.Author.Nickname,
+			Time:    discordMsg.Timestamp.In(location),
+			Text:    discordMsg.Content,
+		}
+		if message.Speaker == "" {
+			message.Speaker = discordMsg.Author.Name
+		}
+
+		// a reply to a reply belongs to the thread of the first message
+		threadRoots[message.Id] = message.Id
+		if discordMsg.Reference != nil && discordMsg.Reference.MessageId != "" {
+			if root, ok := threadRoots[discordMsg.Reference.MessageId]; ok {
+				message.ThreadId = root
+			} else {
+				message.ThreadId = discordMsg.Reference.MessageId
+			}
+			threadRoots[message.Id] = message.ThreadId
+		}
+
+		for _, attachment := range discordMsg.Attachments {
+			message.Attachments = append(message.Attachments, attachment.FileName)
+		}
+		ret = append(ret, message)
+	}
+	return
+}
@@ -0,0 +1,78 @@
+package chatlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// matrixExport is the JSON format of the Element chat export
+type matrixExport struct {
+	RoomName string `json:"room_name"`
+	Messages []struct {
+		Type           string `json:"type"`
+		EventId        string `json:"event_id"`
+		Sender         string `json:"sender"`
+		OriginServerTs int64  `json:"origin_server_ts"`
+		Content        struct {
+			MsgType   string `json:"msgtype"`
+			Body      string `json:"body"`
+			RelatesTo *struct {
+				RelType string `json:"rel_type"`
+				EventId string `json:"event_id"`
+			} `json:"m.relates_to"`
+		} `json:"content"`
+	} `json:"messages"`
+}
+
+var matrixAttachmentTypes = map[string]bool{
+	"m.image": true, "m.file": true, "m.audio": true, "m.video": true,
+}
+
+// ParseMatrix parses a Matrix room export as produced by Element
+func ParseMatrix(content []byte, channel string, location *time.Location) (ret []*Message, err error) {
+	var export matrixExport
+	if err = json.Unmarshal(content, &export); err != nil {
+		err = fmt.Errorf("could not parse Matrix export: %v", err)
+		return
+	}
+
+	if export.RoomName != "" {
+		channel = export.RoomName
+	}
+
+	for _, event := range export.Messages {
+		if event.Type != "m.room.message" {
+			continue
+		}
+
+		message := &Message{
+			Id:      event.EventId,
+			Channel: channel,
+			Speaker: matrixDisplayName(event.Sender),
+			Time:    time.UnixMilli(event.OriginServerTs).In(location),
+		}
+
+		if relatesTo := event.Content.RelatesTo; relatesTo != nil && relatesTo.RelType == "m.thread" {
+			message.ThreadId = relatesTo.EventId
+		}
+
+		if matrixAttachmentTypes[event.Content.MsgType] {
+			message.Attachments = append(message.Attachments, event.Content.Body)
+		} else {
+			message.Text = event.Content.Body
+		}
+		ret = append(ret, message)
+	}
+	return
+}
+
+// matrixDisplayName turns "@alice:matrix.org" into "alice"
+func matrixDisplayName(sender string) (ret string) {
+	ret = strings.TrimPrefix(sender, "@")
+	if name, _, found := strings.Cut(ret, ":"); found {
+		ret = name
+	}
+	return
+}
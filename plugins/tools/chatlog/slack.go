@@ -0,0 +1,176 @@
+package chatlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var slackMentionRegex = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|[^>]*)?>`)
+
+type slackUser struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Profile  struct {
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+func (o *slackUser) displayName() (ret string) {
+	for _, name := range []string{o.Profile.DisplayName, o.Profile.RealName, o.RealName, o.Name} {
+		if ret = name; ret != "" {
+			break
+		}
+	}
+	return
+}
+
+type slackMessage struct {
+	Type        string `json:"type"`
+	SubType     string `json:"subtype"`
+	User        string `json:"user"`
+	Username    string `json:"username"`
+	Text        string `json:"text"`
+	Ts          string `json:"ts"`
+	ThreadTs    string `json:"thread_ts"`
+	UserProfile struct {
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"user_profile"`
+	Files []struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	} `json:"files"`
+}
+
+// ParseSlackExport reads a Slack workspace export directory (users.json and a folder per channel)
+// or a single Slack JSON file with a list of messages
+func ParseSlackExport(path string, location *time.Location) (ret []*Message, err error) {
+	var info os.FileInfo
+	if info, err = os.Stat(path); err != nil {
+		return
+	}
+
+	if !info.IsDir() {
+		var content []byte
+		if content, err = os.ReadFile(path); err != nil {
+			return
+		}
+		// day files of an export are stored in a folder per channel
+		channel := filepath.Base(filepath.Dir(path))
+		ret, err = ParseSlack(content, channel, location, nil)
+		return
+	}
+
+	users := map[string]string{}
+	if content, readErr := os.ReadFile(filepath.Join(path, "users.json")); readErr == nil {
+		var slackUsers []*slackUser
+		if err = json.Unmarshal(content, &slackUsers); err != nil {
+			err = fmt.Errorf("could not parse Slack users.json: %v", err)
+			return
+		}
+		for _, user := range slackUsers {
+			users[user.Id] = user.displayName()
+		}
+	}
+
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(path); err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		channel := entry.Name()
+
+		var dayFiles []string
+		if dayFiles, err = filepath.Glob(filepath.Join(path, channel, "*.json")); err != nil {
+			return
+		}
+
+		for _, dayFile := range dayFiles {
+			var content []byte
+			if content, err = os.ReadFile(dayFile); err != nil {
+				return
+			}
+
+			var messages []*Message
+			if messages, err = ParseSlack(content, channel, location, users); err != nil {
+				err = fmt.Errorf("%s: %v", dayFile, err)
+				return
+			}
+			ret = append(ret, messages...)
+		}
+	}
+	return
+}
+
+// ParseSlack parses a JSON list of Slack messages. Users maps user ids to display names and may be nil.
+func ParseSlack(content []byte, channel string, location *time.Location, users map[string]string) (ret []*Message, err error) {
+	var slackMessages []*slackMessage
+	if err = json.Unmarshal(content, &slackMessages); err != nil {
+		err = fmt.Errorf("could not parse Slack messages: %v", err)
+		return
+	}
+
+	for _, slackMsg := range slackMessages {
+		if slackMsg.Type != "" && slackMsg.Type != "message" {
+			continue
+		}
+		if slackMsg.SubType == "channel_join" || slackMsg.SubType == "channel_leave" {
+			continue
+		}
+
+		message := &Message{
+			Id:       slackMsg.Ts,
+			ThreadId: slackMsg.ThreadTs,
+			Channel:  channel,
+			Speaker:  slackMsg.speaker(users),
+			Time:     parseSlackTs(slackMsg.Ts).In(location),
+			Text:     replaceSlackMentions(slackMsg.Text, users),
+		}
+		for _, file := range slackMsg.Files {
+			if file.Name != "" {
+				message.Attachments = append(message.Attachments, file.Name)
+			} else {
+				message.Attachments = append(message.Attachments, file.Title)
+			}
+		}
+		ret = append(ret, message)
+	}
+	return
+}
+
+func (o *slackMessage) speaker(users map[string]string) (ret string) {
+	for _, name := range []string{o.UserProfile.DisplayName, o.UserProfile.RealName, users[o.User], o.Username, o.User} {
+		if ret = name; ret != "" {
+			break
+		}
+	}
+	return
+}
+
+func parseSlackTs(ts string) (ret time.Time) {
+	seconds, _ := strconv.ParseFloat(ts, 64)
+	ret = time.Unix(int64(seconds), 0)
+	return
+}
+
+func replaceSlackMentions(text string, users map[string]string) string {
+	return slackMentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+		userId := slackMentionRegex.FindStringSubmatch(mention)[1]
+		if name, ok := users[userId]; ok {
+			return "@" + name
+		}
+		return "@" + strings.ToLower(userId)
+	})
+}
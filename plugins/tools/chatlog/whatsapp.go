@@ -0,0 +1,155 @@
+package chatlog
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// whatsAppLineRegex matches the start of a message of the Android ("31/12/20, 22:15 - ") and the iOS ("[31/12/20, 22:15:03] ") exports
+var whatsAppLineRegex = regexp.MustCompile(
+	`^\x{200e}?\[?(\d{1,2})[./-](\d{1,2})[./-](\d{2,4}),?\s+(\d{1,2}):(\d{2})(?::(\d{2}))?[\s\x{202f}]*([AaPp]\.?[Mm]\.?)?\]?(?:\s+-)?\s+(.*)$`)
+
+var whatsAppAttachedRegex = regexp.MustCompile(`^\x{200e}?<attached: (.+)>$`)
+var whatsAppFileAttachedRegex = regexp.MustCompile(`^\x{200e}?(.+) \(file attached\)$`)
+
+var whatsAppOmitted = map[string]string{
+	"<Media omitted>":  "media",
+	"image omitted":    "image",
+	"video omitted":    "video",
+	"audio omitted":    "audio",
+	"sticker omitted":  "sticker",
+	"document omitted": "document",
+	"GIF omitted":      "GIF",
+}
+
+type whatsAppLine struct {
+	first, second, year, hour, minute, second2 int
+	meridiem                                   string
+	rest                                       string
+}
+
+// ParseWhatsApp parses the text export of a WhatsApp chat. Dates are day or month first depending on the locale of
+// the phone, the order is detected from the content.
+func ParseWhatsApp(content []byte, channel string, location *time.Location) (ret []*Message, err error) {
+	var lines []*whatsAppLine
+	var continuation = map[int][]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line := parseWhatsAppLine(text); line != nil {
+			lines = append(lines, line)
+		} else if len(lines) > 0 {
+			continuation[len(lines)-1] = append(continuation[len(lines)-1], text)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	dayFirst := detectWhatsAppDayFirst(lines)
+
+	for i, line := range lines {
+		speaker, text, found := strings.Cut(line.rest, ": ")
+		if !found {
+			// system messages like "Messages and calls are end-to-end encrypted"
+			continue
+		}
+		if more := continuation[i]; len(more) > 0 {
+			text = strings.Join(append([]string{text}, more...), "\n")
+		}
+
+		message := &Message{
+			Id:      strconv.Itoa(i),
+			Channel: channel,
+			Speaker: strings.TrimPrefix(speaker, "\u200e"),
+			Time:    line.time(dayFirst, location),
+		}
+		message.Text, message.Attachments = whatsAppAttachments(text)
+		ret = append(ret, message)
+	}
+	return
+}
+
+func parseWhatsAppLine(text string) (ret *whatsAppLine) {
+	match := whatsAppLineRegex.FindStringSubmatch(text)
+	if match == nil {
+		return
+	}
+
+	atoi := func(value string) (ret int) {
+		ret, _ = strconv.Atoi(value)
+		return
+	}
+	ret = &whatsAppLine{
+		first:    atoi(match[1]),
+		second:   atoi(match[2]),
+		year:     atoi(match[3]),
+		hour:     atoi(match[4]),
+		minute:   atoi(match[5]),
+		second2:  atoi(match[6]),
+		meridiem: strings.ToLower(strings.ReplaceAll(match[7], ".", "")),
+		rest:     match[8],
+	}
+	if ret.year < 100 {
+		ret.year += 2000
+	}
+	return
+}
+
+func detectWhatsAppDayFirst(lines []*whatsAppLine) (ret bool) {
+	meridiem := false
+	for _, line := range lines {
+		if line.first > 12 {
+			return true
+		}
+		if line.second > 12 {
+			return false
+		}
+		meridiem = meridiem || line.meridiem != ""
+	}
+	// 12 hour clocks are mostly used with month first dates
+	ret = !meridiem
+	return
+}
+
+func (o *whatsAppLine) time(dayFirst bool, location *time.Location) time.Time {
+	day, month := o.second, o.first
+	if dayFirst {
+		day, month = o.first, o.second
+	}
+
+	hour := o.hour
+	if o.meridiem == "pm" && hour < 12 {
+		hour += 12
+	} else if o.meridiem == "am" && hour == 12 {
+		hour = 0
+	}
+	return time.Date(o.year, time.Month(month), day, hour, o.minute, o.second2, 0, location)
+}
+
+func whatsAppAttachments(text string) (ret string, attachments []string) {
+	trimmed := strings.TrimSpace(text)
+	if kind, ok := whatsAppOmitted[strings.TrimPrefix(trimmed, "\u200e")]; ok {
+		attachments = append(attachments, kind)
+		return
+	}
+	if match := whatsAppAttachedRegex.FindStringSubmatch(trimmed); match != nil {
+		attachments = append(attachments, match[1])
+		return
+	}
+	// a document can be followed by a caption
+	firstLine, caption, _ := strings.Cut(trimmed, "\n")
+	if match := whatsAppFileAttachedRegex.FindStringSubmatch(firstLine); match != nil {
+		attachments = append(attachments, match[1])
+		ret = strings.TrimSpace(caption)
+		return
+	}
+	ret = text
+	return
+}
@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxCells limits the size of the LCS table of a block, larger changed blocks are shown as removed and added
+const maxCells = 4_000_000
+
+var (
+	itemMarkerRegex = regexp.MustCompile(`^(?:[-*+•]|\d+[.)])\s+`)
+	normalizeRegex  = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op refers to the element of the old values for deletions and to the one of the new values otherwise
+type op struct {
+	kind  opKind
+	index int
+}
+
+// Words returns the new text with the changes to the old one marked like git diff --word-diff=plain, [-removed-] and
+// {+added+}. Lines are compared first, the words of the changed lines then, so large texts stay fast.
+func Words(oldText string, newText string) string {
+	var ret strings.Builder
+	oldLines, newLines := splitLines(oldText), splitLines(newText)
+	lineOps := compare(oldLines, newLines)
+	for i := 0; i < len(lineOps); {
+		if lineOps[i].kind == opEqual {
+			ret.WriteString(newLines[lineOps[i].index])
+			ret.WriteString("\n")
+			i++
+			continue
+		}
+
+		// a changed block is a run of deleted and inserted lines, its words are compared
+		var deleted, inserted []string
+		for ; i < len(lineOps) && lineOps[i].kind != opEqual; i++ {
+			if lineOps[i].kind == opDelete {
+				deleted = append(deleted, oldLines[lineOps[i].index])
+			} else {
+				inserted = append(inserted, newLines[lineOps[i].index])
+			}
+		}
+		ret.WriteString(diffWords(strings.Join(deleted, "\n"), strings.Join(inserted, "\n")))
+		ret.WriteString("\n")
+	}
+	return strings.TrimSuffix(ret.String(), "\n")
+}
+
+// NewItems returns the items of the new list that are not in the old one, e.g. new predictions or ideas. Items are
+// the lines that are not headings, they are compared without their list markers, case and punctuation.
+func NewItems(oldText string, newText string) (ret []string) {
+	known := map[string]bool{}
+	for _, line := range splitLines(oldText) {
+		if key, ok := itemKey(line); ok {
+			known[key] = true
+		}
+	}
+	for _, line := range splitLines(newText) {
+		if key, ok := itemKey(line); ok && !known[key] {
+			known[key] = true
+			ret = append(ret, strings.TrimSpace(line))
+		}
+	}
+	return
+}
+
+// Changed returns whether the texts differ by more than whitespace
+func Changed(oldText string, newText string) bool {
+	return strings.Join(strings.Fields(oldText), " ") != strings.Join(strings.Fields(newText), " ")
+}
+
+func itemKey(line string) (ret string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "```") {
+		return
+	}
+	line = itemMarkerRegex.ReplaceAllString(line, "")
+	ret = strings.TrimSpace(normalizeRegex.ReplaceAllString(strings.ToLower(line), " "))
+	ok = ret != ""
+	return
+}
+
+func diffWords(oldText string, newText string) string {
+	var ret strings.Builder
+	oldWords, newWords := splitWords(oldText), splitWords(newText)
+	// the words are compared without their whitespace, e.g. a word at the end of a line is still the same word
+	ops := compare(trimAll(oldWords), trimAll(newWords))
+	for i := 0; i < len(ops); {
+		kind := ops[i].kind
+		var values []string
+		for ; i < len(ops) && ops[i].kind == kind; i++ {
+			if kind == opDelete {
+				values = append(values, oldWords[ops[i].index])
+			} else {
+				values = append(values, newWords[ops[i].index])
+			}
+		}
+		switch kind {
+		case opEqual:
+			ret.WriteString(strings.Join(values, ""))
+		case opDelete:
+			ret.WriteString(wrap("[-", strings.Join(values, ""), "-]"))
+		case opInsert:
+			ret.WriteString(wrap("{+", strings.Join(values, ""), "+}"))
+		}
+	}
+	return ret.String()
+}
+
+// wrap puts the markers around the text without the whitespace at its ends, so that the markers stay next to the
+// words
+func wrap(start string, text string, end string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	index := strings.Index(text, trimmed)
+	return text[:index] + start + trimmed + end + text[index+len(trimmed):]
+}
+
+// compare returns the operations that turn a into b, based on the longest common subsequence
+func compare(a []string, b []string) (ret []op) {
+	// the common prefix and suffix are kept out of the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for index := 0; index < prefix; index++ {
+		ret = append(ret, op{opEqual, index})
+	}
+	for _, middleOp := range compareMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		middleOp.index += prefix
+		ret = append(ret, middleOp)
+	}
+	for index := len(b) - suffix; index < len(b); index++ {
+		ret = append(ret, op{opEqual, index})
+	}
+	return
+}
+
+func compareMiddle(a []string, b []string) (ret []op) {
+	if len(a)*len(b) > maxCells {
+		for index := range a {
+			ret = append(ret, op{opDelete, index})
+		}
+		for index := range b {
+			ret = append(ret, op{opInsert, index})
+		}
+		return
+	}
+
+	// lengths[i][j] is the length of the LCS of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ret = append(ret, op{opEqual, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ret = append(ret, op{opDelete, i})
+			i++
+		default:
+			ret = append(ret, op{opInsert, j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ret = append(ret, op{opDelete, i})
+	}
+	for ; j < len(b); j++ {
+		ret = append(ret, op{opInsert, j})
+	}
+	return
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+func trimAll(values []string) (ret []string) {
+	ret = make([]string, len(values))
+	for i, value := range values {
+		ret[i] = strings.TrimSpace(value)
+	}
+	return
+}
+
+// splitWords splits the text into words with their following whitespace, joining the words returns the text
+func splitWords(text string) (ret []string) {
+	start := 0
+	inSpace := false
+	for index, char := range text {
+		isSpace := char == ' ' || char == '\t' || char == '\n'
+		if !isSpace && inSpace {
+			ret = append(ret, text[start:index])
+			start = index
+		}
+		inSpace = isSpace
+	}
+	if start < len(text) {
+		ret = append(ret, text[start:])
+	}
+	return
+}
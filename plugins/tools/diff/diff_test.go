@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want string
+	}{
+		{"same", "a b\nc\n", "a b\nc", "a b\nc"},
+		{"changed word", "The sky is blue.\nEnd", "The sky is grey.\nEnd", "The sky is [-blue.-]{+grey.+}\nEnd"},
+		{"added line", "one\nthree", "one\ntwo\nthree", "one\n{+two+}\nthree"},
+		{"removed words", "keep this and that", "keep that", "keep [-this and-] that"},
+		{"rewrapped", "a b\nc", "a\nb c", "a\nb c"},
+		{"from nothing", "", "new", "{+new+}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Words(tt.old, tt.new); got != tt.want {
+				t.Errorf("Words() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewItems(t *testing.T) {
+	old := "# PREDICTIONS\n\n- AI will write most code by 2030.\n- Mars landing in 2035\n"
+	new := "# PREDICTIONS\n\n1. AI will write most code by 2030\n- Mars landing in 2035!\n- Fusion power by 2040.\n"
+
+	want := []string{"- Fusion power by 2040."}
+	if got := NewItems(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewItems() = %v, want %v", got, want)
+	}
+	if got := NewItems(new, new); len(got) != 0 {
+		t.Errorf("NewItems() of the same list = %v, want none", got)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	if Changed("a  b\n", "a b") {
+		t.Error("expected whitespace changes to be ignored")
+	}
+	if !Changed("a b", "a c") {
+		t.Error("expected a changed word to be detected")
+	}
+}
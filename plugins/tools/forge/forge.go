@@ -0,0 +1,294 @@
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins"
+)
+
+const (
+	KindGitHub = "GitHub"
+	KindGitLab = "GitLab"
+	KindGitea  = "Gitea"
+
+	DefaultGitHubApiBaseURL = "https://api.github.com"
+	DefaultGitLabApiBaseURL = "https://gitlab.com/api/v4"
+)
+
+var (
+	gitHubPullRegex   = regexp.MustCompile(`^/(.+)/([^/]+)/pull/(\d+)`)
+	gitLabMergeRegex  = regexp.MustCompile(`^/(.+)/-/merge_requests/(\d+)`)
+	giteaPullRegex    = regexp.MustCompile(`^/(.+)/([^/]+)/pulls/(\d+)`)
+	linkHeaderNextUrl = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+func NewClient() (ret *Client) {
+	label := "Git Forges"
+	ret = &Client{
+		PluginBase: &plugins.PluginBase{
+			Name:             label,
+			SetupDescription: "Git Forges - to grab pull requests from GitHub, GitLab and Gitea",
+			EnvNamePrefix:    plugins.BuildEnvVariablePrefix("Forge"),
+		},
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+
+	ret.GitHubToken = ret.AddSetupQuestion("GitHub Token", false)
+	ret.GitHubApiBaseURL = ret.AddSetupQuestionCustom("GitHub API Base URL", false,
+		"Enter your GitHub API base URL, the token is only sent to it (leave empty for github.com, e.g. https://github.example.com/api/v3 for GitHub Enterprise)")
+	ret.GitLabToken = ret.AddSetupQuestion("GitLab Token", false)
+	ret.GitLabApiBaseURL = ret.AddSetupQuestionCustom("GitLab API Base URL", false,
+		"Enter your GitLab API base URL, the token is only sent to it (leave empty for gitlab.com, other hosts are used without token, e.g. https://gitlab.example.com/api/v4)")
+	ret.GiteaToken = ret.AddSetupQuestion("Gitea Token", false)
+	ret.GiteaApiBaseURL = ret.AddSetupQuestionCustom("Gitea API Base URL", false,
+		"Enter your Gitea API base URL, the token is only sent to it (leave empty to use pull request hosts without token, e.g. https://gitea.example.com/api/v1)")
+
+	return
+}
+
+type Client struct {
+	*plugins.PluginBase
+	GitHubToken      *plugins.SetupQuestion
+	GitHubApiBaseURL *plugins.SetupQuestion
+	GitLabToken      *plugins.SetupQuestion
+	GitLabApiBaseURL *plugins.SetupQuestion
+	GiteaToken       *plugins.SetupQuestion
+	GiteaApiBaseURL  *plugins.SetupQuestion
+
+	httpClient *http.Client
+}
+
+// PullRequestRef identifies a pull request (or a GitLab merge request) on a forge
+type PullRequestRef struct {
+	Kind       string
+	ApiBaseURL string
+	Owner      string
+	Repo       string
+	Number     int
+}
+
+// ParsePullRequestUrl detects the forge of a web URL of a pull request and builds its API coordinates
+func (o *Client) ParsePullRequestUrl(prUrl string) (ret *PullRequestRef, err error) {
+	var parsed *url.URL
+	if parsed, err = url.Parse(prUrl); err != nil || parsed.Host == "" {
+		err = fmt.Errorf("invalid pull request URL: %s", prUrl)
+		return
+	}
+	host := fmt.Sprintf("%s://%s", parsed.Scheme, parsed.Host)
+
+	if match := gitLabMergeRegex.FindStringSubmatch(parsed.Path); match != nil {
+		ret = &PullRequestRef{Kind: KindGitLab, Repo: match[1],
+			ApiBaseURL: o.apiBaseUrl(o.GitLabApiBaseURL, host+"/api/v4")}
+		ret.Number, _ = strconv.Atoi(match[2])
+	} else if match = gitHubPullRegex.FindStringSubmatch(parsed.Path); match != nil {
+		defaultApiBaseUrl := DefaultGitHubApiBaseURL
+		if parsed.Host != "github.com" && parsed.Host != "www.github.com" {
+			defaultApiBaseUrl = host + "/api/v3"
+		}
+		ret = &PullRequestRef{Kind: KindGitHub, Owner: match[1], Repo: match[2],
+			ApiBaseURL: o.apiBaseUrl(o.GitHubApiBaseURL, defaultApiBaseUrl)}
+		ret.Number, _ = strconv.Atoi(match[3])
+	} else if match = giteaPullRegex.FindStringSubmatch(parsed.Path); match != nil {
+		ret = &PullRequestRef{Kind: KindGitea, Owner: match[1], Repo: match[2],
+			ApiBaseURL: o.apiBaseUrl(o.GiteaApiBaseURL, host+"/api/v1")}
+		ret.Number, _ = strconv.Atoi(match[3])
+	} else {
+		err = fmt.Errorf("unsupported pull request URL, expected a GitHub, GitLab or Gitea pull request: %s", prUrl)
+	}
+	return
+}
+
+func (o *Client) apiBaseUrl(setting *plugins.SetupQuestion, defaultValue string) string {
+	if setting.Value != "" {
+		return strings.TrimSuffix(setting.Value, "/")
+	}
+	return defaultValue
+}
+
+// GetPullRequest fetches title, description, diff, changed files and comments of a pull request
+func (o *Client) GetPullRequest(prUrl string) (ret *PullRequest, err error) {
+	var ref *PullRequestRef
+	if ref, err = o.ParsePullRequestUrl(prUrl); err != nil {
+		return
+	}
+
+	switch ref.Kind {
+	case KindGitHub:
+		ret, err = o.getGitHubPullRequest(ref)
+	case KindGitLab:
+		ret, err = o.getGitLabMergeRequest(ref)
+	case KindGitea:
+		ret, err = o.getGiteaPullRequest(ref)
+	}
+	if ret != nil && ret.Url == "" {
+		ret.Url = prUrl
+	}
+	return
+}
+
+// PostComment adds a comment to the conversation of a pull request
+func (o *Client) PostComment(prUrl string, body string) (err error) {
+	var ref *PullRequestRef
+	if ref, err = o.ParsePullRequestUrl(prUrl); err != nil {
+		return
+	}
+
+	var commentsUrl string
+	switch ref.Kind {
+	case KindGitHub, KindGitea:
+		commentsUrl = fmt.Sprintf("%s/repos/%s/%s/issues/%d/comments", ref.ApiBaseURL, ref.Owner, ref.Repo, ref.Number)
+	case KindGitLab:
+		commentsUrl = fmt.Sprintf("%s/notes", ref.gitLabMergeRequestUrl())
+	}
+
+	var payload []byte
+	if payload, err = json.Marshal(map[string]string{"body": body}); err != nil {
+		return
+	}
+
+	var resp *http.Response
+	if resp, err = o.request(ref, http.MethodPost, commentsUrl, "application/json", bytes.NewReader(payload)); err != nil {
+		return
+	}
+	err = resp.Body.Close()
+	return
+}
+
+// token returns the token of the forge if the request goes to the host it is configured for, so that the URL of a pull
+// request on another host never gets the token
+func (o *Client) token(ref *PullRequestRef, requestUrl string) (ret string) {
+	var tokenApiBaseUrl string
+	switch ref.Kind {
+	case KindGitHub:
+		ret, tokenApiBaseUrl = o.GitHubToken.Value, o.apiBaseUrl(o.GitHubApiBaseURL, DefaultGitHubApiBaseURL)
+	case KindGitLab:
+		ret, tokenApiBaseUrl = o.GitLabToken.Value, o.apiBaseUrl(o.GitLabApiBaseURL, DefaultGitLabApiBaseURL)
+	case KindGitea:
+		ret, tokenApiBaseUrl = o.GiteaToken.Value, o.apiBaseUrl(o.GiteaApiBaseURL, "")
+	}
+	if !sameHost(requestUrl, tokenApiBaseUrl) {
+		ret = ""
+	}
+	return
+}
+
+// sameHost reports whether both URLs have the same scheme and host
+func sameHost(first string, second string) bool {
+	firstUrl, firstErr := url.Parse(first)
+	secondUrl, secondErr := url.Parse(second)
+	return firstErr == nil && secondErr == nil && firstUrl.Host != "" &&
+		strings.EqualFold(firstUrl.Scheme, secondUrl.Scheme) && strings.EqualFold(firstUrl.Host, secondUrl.Host)
+}
+
+func (o *Client) request(ref *PullRequestRef, method string, requestUrl string, accept string, body io.Reader) (
+	resp *http.Response, err error) {
+
+	var req *http.Request
+	if req, err = http.NewRequest(method, requestUrl, body); err != nil {
+		err = fmt.Errorf("error creating request: %w", err)
+		return
+	}
+
+	req.Header.Set("Accept", accept)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token := o.token(ref, requestUrl); token != "" {
+		switch ref.Kind {
+		case KindGitHub:
+			req.Header.Set("Authorization", "Bearer "+token)
+		case KindGitLab:
+			req.Header.Set("PRIVATE-TOKEN", token)
+		case KindGitea:
+			req.Header.Set("Authorization", "token "+token)
+		}
+	}
+
+	if resp, err = o.httpClient.Do(req); err != nil {
+		err = fmt.Errorf("error sending request: %w", err)
+		return
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		err = fmt.Errorf("%s %s: %s %s", method, requestUrl, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return
+}
+
+func (o *Client) getText(ref *PullRequestRef, requestUrl string, accept string) (ret string, err error) {
+	var resp *http.Response
+	if resp, err = o.request(ref, http.MethodGet, requestUrl, accept, nil); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if body, err = io.ReadAll(resp.Body); err != nil {
+		err = fmt.Errorf("error reading response body: %w", err)
+		return
+	}
+	ret = string(body)
+	return
+}
+
+func (o *Client) getJson(ref *PullRequestRef, requestUrl string, item interface{}) (err error) {
+	var resp *http.Response
+	if resp, err = o.request(ref, http.MethodGet, requestUrl, "application/json", nil); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if err = json.NewDecoder(resp.Body).Decode(item); err != nil {
+		err = fmt.Errorf("could not decode %s: %v", requestUrl, err)
+	}
+	return
+}
+
+// getJsonPages follows the "next" links of paginated list endpoints and collects all items
+func getJsonPages[T any](o *Client, ref *PullRequestRef, requestUrl string) (ret []T, err error) {
+	nextUrl := requestUrl
+	for nextUrl != "" {
+		var resp *http.Response
+		if resp, err = o.request(ref, http.MethodGet, nextUrl, "application/json", nil); err != nil {
+			return
+		}
+
+		var items []T
+		err = json.NewDecoder(resp.Body).Decode(&items)
+		resp.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("could not decode %s: %v", nextUrl, err)
+			return
+		}
+		ret = append(ret, items...)
+
+		nextUrl = ""
+		if match := linkHeaderNextUrl.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			if !sameHost(match[1], requestUrl) {
+				err = fmt.Errorf("the next page of %s is on another host: %s", requestUrl, match[1])
+				return
+			}
+			nextUrl = match[1]
+		}
+	}
+	return
+}
+
+func withQuery(requestUrl string, query string) string {
+	if strings.Contains(requestUrl, "?") {
+		return requestUrl + "&" + query
+	}
+	return requestUrl + "?" + query
+}
@@ -0,0 +1,185 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePullRequestUrl(t *testing.T) {
+	client := NewClient()
+
+	ref, err := client.ParsePullRequestUrl("https://github.com/danielmiessler/fabric/pull/42")
+	assert.NoError(t, err)
+	assert.Equal(t, &PullRequestRef{Kind: KindGitHub, ApiBaseURL: DefaultGitHubApiBaseURL,
+		Owner: "danielmiessler", Repo: "fabric", Number: 42}, ref)
+
+	ref, err = client.ParsePullRequestUrl("https://gitlab.example.com/group/sub/project/-/merge_requests/7")
+	assert.NoError(t, err)
+	assert.Equal(t, &PullRequestRef{Kind: KindGitLab, ApiBaseURL: "https://gitlab.example.com/api/v4",
+		Repo: "group/sub/project", Number: 7}, ref)
+
+	client.GiteaApiBaseURL.Value = "https://git.example.com/custom/api/v1/"
+	ref, err = client.ParsePullRequestUrl("https://git.example.com/owner/repo/pulls/3")
+	assert.NoError(t, err)
+	assert.Equal(t, &PullRequestRef{Kind: KindGitea, ApiBaseURL: "https://git.example.com/custom/api/v1",
+		Owner: "owner", Repo: "repo", Number: 3}, ref)
+
+	_, err = client.ParsePullRequestUrl("https://example.com/owner/repo/issues/3")
+	assert.Error(t, err)
+}
+
+func writeJson(w http.ResponseWriter, item interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(item)
+}
+
+func TestGetPullRequest_GitHub(t *testing.T) {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.Header.Get("Accept") == "application/vnd.github.v3.diff" {
+			_, _ = io.WriteString(w, "diff --git a/main.go b/main.go\n+fmt.Println()\n")
+			return
+		}
+		writeJson(w, map[string]interface{}{
+			"title": "Add feature", "body": "Adds the feature", "state": "open",
+			"user": map[string]string{"login": "alice"},
+			"head": map[string]string{"ref": "feature"}, "base": map[string]string{"ref": "main"},
+		})
+	})
+	mux.HandleFunc("/api/v3/repos/o/r/pulls/1/files", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/repos/o/r/pulls/1/files?page=2>; rel="next"`, server.URL))
+			writeJson(w, []map[string]interface{}{{"filename": "main.go", "status": "modified", "additions": 1}})
+			return
+		}
+		writeJson(w, []map[string]interface{}{{"filename": "README.md", "status": "added", "additions": 3}})
+	})
+	mux.HandleFunc("/api/v3/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, []map[string]interface{}{{"user": map[string]string{"login": "bob"}, "body": "Looks good"}})
+	})
+	mux.HandleFunc("/api/v3/repos/o/r/pulls/1/reviews", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, []map[string]interface{}{{"user": map[string]string{"login": "carol"}, "body": "Nit", "state": "COMMENTED"}})
+	})
+	mux.HandleFunc("/api/v3/repos/o/r/pulls/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, []map[string]interface{}{
+			{"user": map[string]string{"login": "carol"}, "body": "Rename this", "path": "main.go", "line": 10}})
+	})
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient()
+	client.GitHubToken.Value = "secret"
+	client.GitHubApiBaseURL.Value = server.URL + "/api/v3"
+	pullRequest, err := client.GetPullRequest(server.URL + "/o/r/pull/1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Add feature", pullRequest.Title)
+	assert.Equal(t, server.URL+"/o/r/pull/1", pullRequest.Url)
+	assert.Len(t, pullRequest.Files, 2)
+	assert.Len(t, pullRequest.Comments, 3)
+
+	text := pullRequest.String()
+	assert.Contains(t, text, "# PULL REQUEST: Add feature")
+	assert.Contains(t, text, "Branches: feature -> main")
+	assert.Contains(t, text, "- README.md (added, +3 -0)")
+	assert.Contains(t, text, "- carol on main.go:10: Rename this")
+	assert.Contains(t, text, "- carol (COMMENTED): Nit")
+	assert.Contains(t, text, "+fmt.Println()")
+}
+
+func TestGetPullRequest_GitLab(t *testing.T) {
+	// the project path is URL encoded, so the routes are matched on the escaped path
+	mux := map[string]http.HandlerFunc{}
+	mux["/api/v4/projects/group%2Fproject/merge_requests/5"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		writeJson(w, map[string]interface{}{
+			"title": "Fix bug", "description": "Fixes it", "state": "merged", "web_url": "https://gitlab/mr/5",
+			"source_branch": "fix", "target_branch": "main", "author": map[string]string{"username": "dave"},
+		})
+	}
+	mux["/api/v4/projects/group%2Fproject/merge_requests/5/changes"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJson(w, map[string]interface{}{"changes": []map[string]interface{}{
+			{"old_path": "a.go", "new_path": "a.go", "diff": "@@ -1 +1 @@\n-old\n+new\n"},
+		}})
+	}
+	mux["/api/v4/projects/group%2Fproject/merge_requests/5/notes"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var payload map[string]string
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+			assert.Equal(t, "summary", payload["body"])
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		writeJson(w, []map[string]interface{}{
+			{"body": "added 1 commit", "system": true, "author": map[string]string{"username": "dave"}},
+			{"body": "Why?", "author": map[string]string{"username": "erin"},
+				"position": map[string]interface{}{"new_path": "a.go", "new_line": 1}},
+		})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := mux[r.URL.EscapedPath()]; ok {
+			handler(w, r)
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.GitLabToken.Value = "secret"
+	client.GitLabApiBaseURL.Value = server.URL + "/api/v4"
+	prUrl := server.URL + "/group/project/-/merge_requests/5"
+
+	pullRequest, err := client.GetPullRequest(prUrl)
+	assert.NoError(t, err)
+	assert.Equal(t, "merged", pullRequest.State)
+	assert.Equal(t, []*ChangedFile{{Path: "a.go", Status: "modified", Additions: 1, Deletions: 1}}, pullRequest.Files)
+	assert.Equal(t, []*Comment{{Author: "erin", Body: "Why?", Path: "a.go", Line: 1}}, pullRequest.Comments)
+	assert.Contains(t, pullRequest.Diff, "+++ b/a.go\n@@ -1 +1 @@\n-old\n+new\n")
+
+	assert.NoError(t, client.PostComment(prUrl, "summary"))
+}
+
+func TestGetPullRequest_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	_, err := client.GetPullRequest(server.URL + "/o/r/pulls/9")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestGetPullRequest_ForeignHost(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"), "the token must not be sent to another host")
+		if r.URL.Path == "/api/v3/repos/o/r/pulls/1/files" {
+			// the next page is on yet another host
+			w.Header().Set("Link", `<https://elsewhere.example/files?page=2>; rel="next"`)
+		}
+		writeJson(w, []map[string]interface{}{})
+	}))
+	defer foreign.Close()
+
+	client := NewClient()
+	client.GitHubToken.Value = "secret"
+	ref, err := client.ParsePullRequestUrl(foreign.URL + "/o/r/pull/1")
+	assert.NoError(t, err)
+	assert.Empty(t, client.token(ref, ref.ApiBaseURL+"/repos/o/r/pulls/1"))
+
+	_, err = getJsonPages[ChangedFile](client, ref, ref.ApiBaseURL+"/repos/o/r/pulls/1/files")
+	assert.ErrorContains(t, err, "another host")
+
+	client.GiteaToken.Value = "secret"
+	ref, _ = client.ParsePullRequestUrl("https://evil.example/o/r/pulls/1")
+	assert.Empty(t, client.token(ref, ref.ApiBaseURL+"/repos/o/r/pulls/1"))
+}
@@ -0,0 +1,84 @@
+package forge
+
+import (
+	"fmt"
+)
+
+type giteaReview struct {
+	Id    int64      `json:"id"`
+	User  gitHubUser `json:"user"`
+	Body  string     `json:"body"`
+	State string     `json:"state"`
+}
+
+type giteaReviewComment struct {
+	User     gitHubUser `json:"user"`
+	Body     string     `json:"body"`
+	Path     string     `json:"path"`
+	Position int        `json:"position"`
+}
+
+// getGiteaPullRequest reads the pull request through the REST API of Gitea (and Forgejo)
+func (o *Client) getGiteaPullRequest(ref *PullRequestRef) (ret *PullRequest, err error) {
+	pullUrl := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", ref.ApiBaseURL, ref.Owner, ref.Repo, ref.Number)
+	issueUrl := fmt.Sprintf("%s/repos/%s/%s/issues/%d", ref.ApiBaseURL, ref.Owner, ref.Repo, ref.Number)
+
+	var pull gitHubPull
+	if err = o.getJson(ref, pullUrl, &pull); err != nil {
+		return
+	}
+
+	ret = &PullRequest{
+		Url:          pull.HtmlUrl,
+		Title:        pull.Title,
+		Description:  pull.Body,
+		Author:       pull.User.Login,
+		State:        pull.State,
+		SourceBranch: pull.Head.Ref,
+		TargetBranch: pull.Base.Ref,
+	}
+	if pull.Merged {
+		ret.State = "merged"
+	}
+
+	if ret.Diff, err = o.getText(ref, pullUrl+".diff", "text/plain"); err != nil {
+		return
+	}
+
+	var files []gitHubFile
+	if files, err = getJsonPages[gitHubFile](o, ref, withQuery(pullUrl+"/files", "limit=50")); err != nil {
+		return
+	}
+	for _, file := range files {
+		ret.Files = append(ret.Files, &ChangedFile{
+			Path: file.Filename, Status: file.Status, Additions: file.Additions, Deletions: file.Deletions})
+	}
+
+	var comments []gitHubComment
+	if comments, err = getJsonPages[gitHubComment](o, ref, withQuery(issueUrl+"/comments", "limit=50")); err != nil {
+		return
+	}
+	ret.appendGitHubComments(comments)
+
+	var reviews []giteaReview
+	if reviews, err = getJsonPages[giteaReview](o, ref, withQuery(pullUrl+"/reviews", "limit=50")); err != nil {
+		return
+	}
+	for _, review := range reviews {
+		if review.Body != "" {
+			ret.Comments = append(ret.Comments, &Comment{
+				Author: fmt.Sprintf("%s (%s)", review.User.Login, review.State), Body: review.Body})
+		}
+
+		var reviewComments []giteaReviewComment
+		if reviewComments, err = getJsonPages[giteaReviewComment](o, ref,
+			fmt.Sprintf("%s/reviews/%d/comments", pullUrl, review.Id)); err != nil {
+			return
+		}
+		for _, comment := range reviewComments {
+			ret.Comments = append(ret.Comments, &Comment{
+				Author: comment.User.Login, Body: comment.Body, Path: comment.Path, Line: comment.Position})
+		}
+	}
+	return
+}
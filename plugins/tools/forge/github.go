@@ -0,0 +1,113 @@
+package forge
+
+import (
+	"fmt"
+)
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+type gitHubPull struct {
+	Title   string     `json:"title"`
+	Body    string     `json:"body"`
+	State   string     `json:"state"`
+	Merged  bool       `json:"merged"`
+	HtmlUrl string     `json:"html_url"`
+	User    gitHubUser `json:"user"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+type gitHubFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+type gitHubComment struct {
+	User gitHubUser `json:"user"`
+	Body string     `json:"body"`
+	Path string     `json:"path"`
+	Line int        `json:"line"`
+}
+
+type gitHubReview struct {
+	User  gitHubUser `json:"user"`
+	Body  string     `json:"body"`
+	State string     `json:"state"`
+}
+
+// getGitHubPullRequest reads the pull request through the REST API of GitHub (and GitHub Enterprise);
+// Gitea implements the same API for the fields we use.
+func (o *Client) getGitHubPullRequest(ref *PullRequestRef) (ret *PullRequest, err error) {
+	pullUrl := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", ref.ApiBaseURL, ref.Owner, ref.Repo, ref.Number)
+	issueUrl := fmt.Sprintf("%s/repos/%s/%s/issues/%d", ref.ApiBaseURL, ref.Owner, ref.Repo, ref.Number)
+
+	var pull gitHubPull
+	if err = o.getJson(ref, pullUrl, &pull); err != nil {
+		return
+	}
+
+	ret = &PullRequest{
+		Url:          pull.HtmlUrl,
+		Title:        pull.Title,
+		Description:  pull.Body,
+		Author:       pull.User.Login,
+		State:        pull.State,
+		SourceBranch: pull.Head.Ref,
+		TargetBranch: pull.Base.Ref,
+	}
+	if pull.Merged {
+		ret.State = "merged"
+	}
+
+	if ret.Diff, err = o.getText(ref, pullUrl, "application/vnd.github.v3.diff"); err != nil {
+		return
+	}
+
+	var files []gitHubFile
+	if files, err = getJsonPages[gitHubFile](o, ref, withQuery(pullUrl+"/files", "per_page=100")); err != nil {
+		return
+	}
+	for _, file := range files {
+		ret.Files = append(ret.Files, &ChangedFile{
+			Path: file.Filename, Status: file.Status, Additions: file.Additions, Deletions: file.Deletions})
+	}
+
+	var comments []gitHubComment
+	if comments, err = getJsonPages[gitHubComment](o, ref, withQuery(issueUrl+"/comments", "per_page=100")); err != nil {
+		return
+	}
+	ret.appendGitHubComments(comments)
+
+	var reviews []gitHubReview
+	if reviews, err = getJsonPages[gitHubReview](o, ref, withQuery(pullUrl+"/reviews", "per_page=100")); err != nil {
+		return
+	}
+	for _, review := range reviews {
+		if review.Body != "" {
+			ret.Comments = append(ret.Comments, &Comment{
+				Author: fmt.Sprintf("%s (%s)", review.User.Login, review.State), Body: review.Body})
+		}
+	}
+
+	var reviewComments []gitHubComment
+	if reviewComments, err = getJsonPages[gitHubComment](o, ref, withQuery(pullUrl+"/comments", "per_page=100")); err != nil {
+		return
+	}
+	ret.appendGitHubComments(reviewComments)
+	return
+}
+
+func (o *PullRequest) appendGitHubComments(comments []gitHubComment) {
+	for _, comment := range comments {
+		o.Comments = append(o.Comments, &Comment{
+			Author: comment.User.Login, Body: comment.Body, Path: comment.Path, Line: comment.Line})
+	}
+}
@@ -0,0 +1,126 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+type gitLabMergeRequest struct {
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"`
+	WebUrl       string     `json:"web_url"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	Author       gitLabUser `json:"author"`
+}
+
+type gitLabChanges struct {
+	Changes []struct {
+		OldPath     string `json:"old_path"`
+		NewPath     string `json:"new_path"`
+		Diff        string `json:"diff"`
+		NewFile     bool   `json:"new_file"`
+		RenamedFile bool   `json:"renamed_file"`
+		DeletedFile bool   `json:"deleted_file"`
+	} `json:"changes"`
+}
+
+type gitLabNote struct {
+	Body     string     `json:"body"`
+	System   bool       `json:"system"`
+	Author   gitLabUser `json:"author"`
+	Position *struct {
+		NewPath string `json:"new_path"`
+		NewLine int    `json:"new_line"`
+	} `json:"position"`
+}
+
+func (o *PullRequestRef) gitLabMergeRequestUrl() string {
+	return fmt.Sprintf("%s/projects/%s/merge_requests/%d", o.ApiBaseURL, url.PathEscape(o.Repo), o.Number)
+}
+
+// getGitLabMergeRequest reads the merge request through the REST API v4 of GitLab
+func (o *Client) getGitLabMergeRequest(ref *PullRequestRef) (ret *PullRequest, err error) {
+	mergeRequestUrl := ref.gitLabMergeRequestUrl()
+
+	var mergeRequest gitLabMergeRequest
+	if err = o.getJson(ref, mergeRequestUrl, &mergeRequest); err != nil {
+		return
+	}
+
+	ret = &PullRequest{
+		Url:          mergeRequest.WebUrl,
+		Title:        mergeRequest.Title,
+		Description:  mergeRequest.Description,
+		Author:       mergeRequest.Author.Username,
+		State:        mergeRequest.State,
+		SourceBranch: mergeRequest.SourceBranch,
+		TargetBranch: mergeRequest.TargetBranch,
+	}
+
+	var changes gitLabChanges
+	if err = o.getJson(ref, mergeRequestUrl+"/changes", &changes); err != nil {
+		return
+	}
+
+	var diff strings.Builder
+	for _, change := range changes.Changes {
+		status := "modified"
+		switch {
+		case change.NewFile:
+			status = "added"
+		case change.DeletedFile:
+			status = "removed"
+		case change.RenamedFile:
+			status = "renamed"
+		}
+
+		additions, deletions := countDiffLines(change.Diff)
+		ret.Files = append(ret.Files, &ChangedFile{
+			Path: change.NewPath, Status: status, Additions: additions, Deletions: deletions})
+
+		diff.WriteString(fmt.Sprintf("diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n",
+			change.OldPath, change.NewPath, change.OldPath, change.NewPath))
+		diff.WriteString(change.Diff)
+		if !strings.HasSuffix(change.Diff, "\n") {
+			diff.WriteString("\n")
+		}
+	}
+	ret.Diff = diff.String()
+
+	var notes []gitLabNote
+	if notes, err = getJsonPages[gitLabNote](o, ref,
+		withQuery(mergeRequestUrl+"/notes", "sort=asc&order_by=created_at&per_page=100")); err != nil {
+		return
+	}
+	for _, note := range notes {
+		// system notes are events like "added 1 commit"
+		if note.System {
+			continue
+		}
+		comment := &Comment{Author: note.Author.Username, Body: note.Body}
+		if note.Position != nil {
+			comment.Path = note.Position.NewPath
+			comment.Line = note.Position.NewLine
+		}
+		ret.Comments = append(ret.Comments, comment)
+	}
+	return
+}
+
+func countDiffLines(diff string) (additions int, deletions int) {
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			additions++
+		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+			deletions++
+		}
+	}
+	return
+}
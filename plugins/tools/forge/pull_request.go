@@ -0,0 +1,88 @@
+package forge
+
+import (
+	"fmt"
+	"strings"
+)
+
+type PullRequest struct {
+	Url          string
+	Title        string
+	Description  string
+	Author       string
+	State        string
+	SourceBranch string
+	TargetBranch string
+	Files        []*ChangedFile
+	Comments     []*Comment
+	Diff         string
+}
+
+type ChangedFile struct {
+	Path      string
+	Status    string
+	Additions int
+	Deletions int
+}
+
+// Comment is a conversation comment, a review or a review comment on a line of the diff
+type Comment struct {
+	Author string
+	Body   string
+	Path   string
+	Line   int
+}
+
+func (o *Comment) String() (ret string) {
+	location := ""
+	if o.Path != "" {
+		if o.Line > 0 {
+			location = fmt.Sprintf(" on %s:%d", o.Path, o.Line)
+		} else {
+			location = fmt.Sprintf(" on %s", o.Path)
+		}
+	}
+	ret = fmt.Sprintf("- %s%s: %s", o.Author, location, strings.TrimSpace(o.Body))
+	return
+}
+
+// String formats the pull request as input for patterns like summarize_pull-requests or write_pull-request
+func (o *PullRequest) String() (ret string) {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# PULL REQUEST: %s\n\n", o.Title))
+	builder.WriteString(fmt.Sprintf("URL: %s\n", o.Url))
+	builder.WriteString(fmt.Sprintf("Author: %s\n", o.Author))
+	builder.WriteString(fmt.Sprintf("State: %s\n", o.State))
+	builder.WriteString(fmt.Sprintf("Branches: %s -> %s\n", o.SourceBranch, o.TargetBranch))
+
+	if description := strings.TrimSpace(o.Description); description != "" {
+		builder.WriteString("\n# DESCRIPTION\n\n")
+		builder.WriteString(description)
+		builder.WriteString("\n")
+	}
+
+	if len(o.Files) > 0 {
+		builder.WriteString("\n# CHANGED FILES\n\n")
+		for _, file := range o.Files {
+			builder.WriteString(fmt.Sprintf("- %s (%s, +%d -%d)\n", file.Path, file.Status, file.Additions, file.Deletions))
+		}
+	}
+
+	if len(o.Comments) > 0 {
+		builder.WriteString("\n# REVIEW COMMENTS\n\n")
+		for _, comment := range o.Comments {
+			builder.WriteString(comment.String())
+			builder.WriteString("\n")
+		}
+	}
+
+	if diff := strings.TrimSpace(o.Diff); diff != "" {
+		builder.WriteString("\n# DIFF\n\n")
+		builder.WriteString(diff)
+		builder.WriteString("\n")
+	}
+
+	ret = builder.String()
+	return
+}
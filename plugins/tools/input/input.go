@@ -0,0 +1,150 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// The input types a pattern declares in the input contract of its pattern.json
+const (
+	TypeYouTubeTranscript = "youtube-transcript"
+	TypeURLArticle        = "url-article"
+	TypeGitDiff           = "git-diff"
+	TypeCode              = "code"
+	TypeEmail             = "email"
+	TypeText              = "text"
+)
+
+var Types = []string{TypeYouTubeTranscript, TypeURLArticle, TypeGitDiff, TypeCode, TypeEmail, TypeText}
+
+// Source is where the input of a pattern is acquired from
+type Source int
+
+const (
+	// SourceMessage uses the message as it is
+	SourceMessage Source = iota
+	// SourceYouTube grabs the transcript of the YouTube video the message refers to
+	SourceYouTube
+	// SourceURL scrapes the web page the message refers to
+	SourceURL
+	// SourceGitDiff uses the changes of the git repository in the working directory
+	SourceGitDiff
+)
+
+var (
+	urlRegex     = regexp.MustCompile(`^https?://\S+$`)
+	youTubeRegex = regexp.MustCompile(`^https?://(?:www\.|m\.)?(?:youtube\.com/|youtu\.be/)`)
+	diffRegex    = regexp.MustCompile(`(?m)^(?:diff --git |@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@|\+\+\+ |--- a/)`)
+	emailRegex   = regexp.MustCompile(`(?mi)^(?:from|to|subject|date|received|message-id):\s`)
+)
+
+// Plan returns where the input of a pattern with the contract is acquired from. The message is only replaced if it is
+// a reference to the input, a bare URL or no message at all.
+func Plan(contract *fsdb.PatternInput, message string) Source {
+	if contract == nil {
+		return SourceMessage
+	}
+
+	message = strings.TrimSpace(message)
+	switch contract.Type {
+	case TypeYouTubeTranscript, TypeURLArticle:
+		if youTubeRegex.MatchString(message) {
+			return SourceYouTube
+		}
+		if urlRegex.MatchString(message) {
+			return SourceURL
+		}
+	case TypeGitDiff:
+		if message == "" {
+			return SourceGitDiff
+		}
+	}
+	return SourceMessage
+}
+
+// Check returns warnings if the input clearly doesn't match the contract. Only obvious mismatches are reported, the
+// patterns work with more than their declared input.
+func Check(contract *fsdb.PatternInput, message string) (ret []string) {
+	if contract == nil {
+		return
+	}
+
+	if !slices.Contains(Types, contract.Type) {
+		ret = append(ret, fmt.Sprintf("unknown input type %s in %s, use one of %s",
+			contract.Type, fsdb.PatternMetadataFile, strings.Join(Types, ", ")))
+		return
+	}
+
+	if contract.MaxSize > 0 && len(message) > contract.MaxSize {
+		ret = append(ret, fmt.Sprintf("the input has %d bytes, more than the %d bytes the pattern handles well",
+			len(message), contract.MaxSize))
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return
+	}
+
+	isDiff := diffRegex.MatchString(message)
+	switch {
+	case urlRegex.MatchString(message):
+		ret = append(ret, fmt.Sprintf("the input is only a URL, the pattern expects %s", describe(contract.Type)))
+	case contract.Type == TypeGitDiff && !isDiff:
+		ret = append(ret, "the input doesn't look like a git diff")
+	case contract.Type == TypeEmail && !emailRegex.MatchString(message):
+		ret = append(ret, "the input has no email headers like From or Subject")
+	case isDiff && !slices.Contains([]string{TypeGitDiff, TypeCode, TypeText}, contract.Type):
+		ret = append(ret, fmt.Sprintf("the input is a diff, the pattern expects %s", describe(contract.Type)))
+	}
+	return
+}
+
+// GitDiff returns the staged changes of the git repository in the working directory, or the unstaged ones if nothing
+// is staged
+func GitDiff() (ret string, err error) {
+	if _, err = runGit("rev-parse", "--is-inside-work-tree"); err != nil {
+		err = fmt.Errorf("the working directory is not in a git repository, give the diff as input")
+		return
+	}
+	for _, args := range [][]string{{"diff", "--cached"}, {"diff"}} {
+		if ret, err = runGit(args...); err != nil || strings.TrimSpace(ret) != "" {
+			return
+		}
+	}
+	err = fmt.Errorf("no changes in the git repository of the working directory")
+	return
+}
+
+func runGit(args ...string) (ret string, err error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("git %s failed: %v %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+		return
+	}
+	ret = stdout.String()
+	return
+}
+
+func describe(inputType string) string {
+	switch inputType {
+	case TypeYouTubeTranscript:
+		return "a YouTube transcript"
+	case TypeURLArticle:
+		return "an article"
+	case TypeGitDiff:
+		return "a git diff"
+	case TypeEmail:
+		return "an email"
+	default:
+		return inputType
+	}
+}
@@ -0,0 +1,62 @@
+package input
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		contract *fsdb.PatternInput
+		message  string
+		want     Source
+	}{
+		{"no contract", nil, "https://example.com", SourceMessage},
+		{"youtube", &fsdb.PatternInput{Type: TypeYouTubeTranscript}, " https://youtu.be/abcdefghijk\n", SourceYouTube},
+		{"article of youtube pattern", &fsdb.PatternInput{Type: TypeYouTubeTranscript}, "https://example.com/a", SourceURL},
+		{"article", &fsdb.PatternInput{Type: TypeURLArticle}, "https://example.com/a", SourceURL},
+		{"article text", &fsdb.PatternInput{Type: TypeURLArticle}, "see https://example.com/a", SourceMessage},
+		{"git diff", &fsdb.PatternInput{Type: TypeGitDiff}, "", SourceGitDiff},
+		{"given diff", &fsdb.PatternInput{Type: TypeGitDiff}, "diff --git a/x b/x", SourceMessage},
+		{"code url", &fsdb.PatternInput{Type: TypeCode}, "https://example.com/a.go", SourceMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Plan(tt.contract, tt.message); got != tt.want {
+				t.Errorf("Plan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	diff := "diff --git a/x.go b/x.go\n--- a/x.go\n+++ b/x.go\n@@ -1 +1 @@\n-old\n+new\n"
+	tests := []struct {
+		name     string
+		contract *fsdb.PatternInput
+		message  string
+		want     string
+	}{
+		{"no contract", nil, "anything", ""},
+		{"diff", &fsdb.PatternInput{Type: TypeGitDiff}, diff, ""},
+		{"no diff", &fsdb.PatternInput{Type: TypeGitDiff}, "some text", "doesn't look like a git diff"},
+		{"too large", &fsdb.PatternInput{Type: TypeText, MaxSize: 4}, "some text", "more than the 4 bytes"},
+		{"url", &fsdb.PatternInput{Type: TypeCode}, "https://example.com", "only a URL"},
+		{"email", &fsdb.PatternInput{Type: TypeEmail}, "From: a@example.com\nSubject: hi\n\nHello", ""},
+		{"no email", &fsdb.PatternInput{Type: TypeEmail}, "Hello", "no email headers"},
+		{"diff for transcript", &fsdb.PatternInput{Type: TypeYouTubeTranscript}, diff, "the input is a diff"},
+		{"diff for code", &fsdb.PatternInput{Type: TypeCode}, diff, ""},
+		{"unknown type", &fsdb.PatternInput{Type: "video"}, "text", "unknown input type video"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(Check(tt.contract, tt.message), "\n")
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,16 +3,28 @@ package jina
 // see https://jina.ai for more information
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/danielmiessler/fabric/plugins"
 )
 
+const (
+	readerBaseURL = "https://r.jina.ai/"
+	searchBaseURL = "https://s.jina.ai/"
+)
+
 type Client struct {
 	*plugins.PluginBase
 	ApiKey *plugins.SetupQuestion
+
+	readerBaseURL string
+	searchBaseURL string
 }
 
 func NewClient() (ret *Client) {
@@ -25,6 +37,8 @@ func NewClient() (ret *Client) {
 			SetupDescription: "Jina AI Service - to grab a webpage as clean, LLM-friendly text",
 			EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
 		},
+		readerBaseURL: readerBaseURL,
+		searchBaseURL: searchBaseURL,
 	}
 
 	ret.ApiKey = ret.AddSetupQuestion("API Key", false)
@@ -32,16 +46,120 @@ func NewClient() (ret *Client) {
 	return
 }
 
+// Options of the reader and the search, they are sent as headers. See https://jina.ai/reader for details.
+type Options struct {
+	// TargetSelector is the CSS selector of the content to return instead of the whole page
+	TargetSelector string `json:"target_selector,omitempty"`
+	// WaitForSelector is the CSS selector of an element the reader waits for before returning the page
+	WaitForSelector string `json:"wait_for_selector,omitempty"`
+	// RemoveSelector is the CSS selector of the elements to remove, e.g. "header, footer, .ads"
+	RemoveSelector string `json:"remove_selector,omitempty"`
+	// ImageCaptions adds generated captions to the images without alt text
+	ImageCaptions *bool `json:"image_captions,omitempty"`
+	// LinksSummary adds a summary of all links at the end
+	LinksSummary *bool `json:"links_summary,omitempty"`
+	// ImagesSummary adds a summary of all images at the end
+	ImagesSummary *bool `json:"images_summary,omitempty"`
+	// Json returns the JSON response with the title, description, URL and the other metadata of the page
+	Json *bool `json:"json,omitempty"`
+	// NoCache bypasses the cache of the reader
+	NoCache *bool `json:"no_cache,omitempty"`
+	// Timeout is the time in seconds the reader waits for the page to load
+	Timeout int `json:"timeout,omitempty"`
+	// Locale is the browser locale the page is loaded with, e.g. de-DE
+	Locale string `json:"locale,omitempty"`
+}
+
+// Merge returns the options with the values that are not set taken from the defaults
+func (o *Options) Merge(defaults *Options) (ret *Options) {
+	ret = &Options{}
+	if o != nil {
+		*ret = *o
+	}
+	if defaults == nil {
+		return
+	}
+
+	mergeString := func(value *string, defaultValue string) {
+		if *value == "" {
+			*value = defaultValue
+		}
+	}
+	mergeString(&ret.TargetSelector, defaults.TargetSelector)
+	mergeString(&ret.WaitForSelector, defaults.WaitForSelector)
+	mergeString(&ret.RemoveSelector, defaults.RemoveSelector)
+	mergeString(&ret.Locale, defaults.Locale)
+	mergeBool := func(value **bool, defaultValue *bool) {
+		if *value == nil {
+			*value = defaultValue
+		}
+	}
+	mergeBool(&ret.ImageCaptions, defaults.ImageCaptions)
+	mergeBool(&ret.LinksSummary, defaults.LinksSummary)
+	mergeBool(&ret.ImagesSummary, defaults.ImagesSummary)
+	mergeBool(&ret.Json, defaults.Json)
+	mergeBool(&ret.NoCache, defaults.NoCache)
+	if ret.Timeout == 0 {
+		ret.Timeout = defaults.Timeout
+	}
+	return
+}
+
+func (o *Options) headers() (ret map[string]string) {
+	ret = map[string]string{}
+	if o == nil {
+		return
+	}
+
+	for header, value := range map[string]string{
+		"X-Target-Selector":   o.TargetSelector,
+		"X-Wait-For-Selector": o.WaitForSelector,
+		"X-Remove-Selector":   o.RemoveSelector,
+		"X-Locale":            o.Locale,
+	} {
+		if value != "" {
+			ret[header] = value
+		}
+	}
+	for header, value := range map[string]*bool{
+		"X-With-Generated-Alt":  o.ImageCaptions,
+		"X-With-Links-Summary":  o.LinksSummary,
+		"X-With-Images-Summary": o.ImagesSummary,
+		"X-No-Cache":            o.NoCache,
+	} {
+		if value != nil && *value {
+			ret[header] = "true"
+		}
+	}
+	if o.Timeout > 0 {
+		ret["X-Timeout"] = strconv.Itoa(o.Timeout)
+	}
+	if o.Json != nil && *o.Json {
+		ret["Accept"] = "application/json"
+	}
+	return
+}
+
 // ScrapeURL return the main content of a webpage in clean, LLM-friendly text.
 func (jc *Client) ScrapeURL(url string) (ret string, err error) {
-	return jc.request(fmt.Sprintf("https://r.jina.ai/%s", url))
+	return jc.ScrapeURLWithOptions(url, nil)
+}
+
+// ScrapeURLWithOptions returns the content of the webpage read with the options, nil for the defaults of the reader
+func (jc *Client) ScrapeURLWithOptions(url string, options *Options) (ret string, err error) {
+	return jc.request(jc.readerBaseURL+url, options)
 }
 
 func (jc *Client) ScrapeQuestion(question string) (ret string, err error) {
-	return jc.request(fmt.Sprintf("https://s.jina.ai/%s", question))
+	return jc.ScrapeQuestionWithOptions(question, nil)
+}
+
+// ScrapeQuestionWithOptions returns the search results of the question read with the options
+func (jc *Client) ScrapeQuestionWithOptions(question string, options *Options) (ret string, err error) {
+	return jc.request(jc.searchBaseURL+question, options)
 }
 
-func (jc *Client) request(requestURL string) (ret string, err error) {
+func (jc *Client) request(requestURL string, options *Options) (ret string, err error) {
 	var req *http.Request
 	if req, err = http.NewRequest("GET", requestURL, nil); err != nil {
 		err = fmt.Errorf("error creating request: %w", err)
@@ -52,8 +170,15 @@ func (jc *Client) request(requestURL string) (ret string, err error) {
 	if jc.ApiKey.Value != "" {
 		req.Header.Set("Authorization", "Bearer "+jc.ApiKey.Value)
 	}
+	for header, value := range options.headers() {
+		req.Header.Set(header, value)
+	}
 
 	client := &http.Client{}
+	if options != nil && options.Timeout > 0 {
+		// the reader gets the time to load the page, the response some more
+		client.Timeout = time.Duration(options.Timeout)*time.Second + 30*time.Second
+	}
 	var resp *http.Response
 	if resp, err = client.Do(req); err != nil {
 		err = fmt.Errorf("error sending request: %w", err)
@@ -66,6 +191,35 @@ func (jc *Client) request(requestURL string) (ret string, err error) {
 		err = fmt.Errorf("error reading response body: %w", err)
 		return
 	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("jina request failed with status %s: %s", resp.Status, bytes.TrimSpace(body))
+		return
+	}
+
+	if options != nil && options.Json != nil && *options.Json {
+		ret, err = formatJson(body)
+		return
+	}
 	ret = string(body)
 	return
 }
+
+// formatJson returns the data of the JSON response indented, the status fields of the envelope are left out
+func formatJson(body []byte) (ret string, err error) {
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil || len(response.Data) == 0 {
+		// not the usual envelope, return the response as it is
+		ret, err = string(body), nil
+		return
+	}
+
+	var indented bytes.Buffer
+	if err = json.Indent(&indented, response.Data, "", "  "); err != nil {
+		err = fmt.Errorf("error reading JSON response: %w", err)
+		return
+	}
+	ret = indented.String()
+	return
+}
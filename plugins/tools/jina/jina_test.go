@@ -0,0 +1,90 @@
+package jina
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(handler http.HandlerFunc) (ret *Client, server *httptest.Server) {
+	server = httptest.NewServer(handler)
+	ret = NewClient()
+	ret.readerBaseURL = server.URL + "/reader/"
+	ret.searchBaseURL = server.URL + "/search/"
+	return
+}
+
+func TestScrapeURLWithOptions(t *testing.T) {
+	var headers http.Header
+	var path string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		path = r.URL.Path
+		_, _ = w.Write([]byte("Title: Example\n\ncontent"))
+	})
+	defer server.Close()
+	client.ApiKey.Value = "key"
+
+	on := true
+	content, err := client.ScrapeURLWithOptions("https://example.com/page", &Options{
+		TargetSelector:  "article",
+		WaitForSelector: "#main",
+		RemoveSelector:  "header, footer",
+		ImageCaptions:   &on,
+		LinksSummary:    &on,
+		NoCache:         &on,
+		Timeout:         10,
+		Locale:          "de-DE",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Title: Example\n\ncontent", content)
+	assert.Equal(t, "/reader/https://example.com/page", path)
+	assert.Equal(t, "Bearer key", headers.Get("Authorization"))
+	assert.Equal(t, "article", headers.Get("X-Target-Selector"))
+	assert.Equal(t, "#main", headers.Get("X-Wait-For-Selector"))
+	assert.Equal(t, "header, footer", headers.Get("X-Remove-Selector"))
+	assert.Equal(t, "true", headers.Get("X-With-Generated-Alt"))
+	assert.Equal(t, "true", headers.Get("X-With-Links-Summary"))
+	assert.Empty(t, headers.Get("X-With-Images-Summary"))
+	assert.Equal(t, "true", headers.Get("X-No-Cache"))
+	assert.Equal(t, "10", headers.Get("X-Timeout"))
+	assert.Equal(t, "de-DE", headers.Get("X-Locale"))
+}
+
+func TestScrapeQuestionJson(t *testing.T) {
+	var accept string
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		_, _ = w.Write([]byte(`{"code":200,"status":20000,"data":[{"title":"Result","url":"https://example.com"}]}`))
+	})
+	defer server.Close()
+
+	on := true
+	content, err := client.ScrapeQuestionWithOptions("what is fabric", &Options{Json: &on})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/json", accept)
+	assert.Equal(t, "[\n  {\n    \"title\": \"Result\",\n    \"url\": \"https://example.com\"\n  }\n]", content)
+}
+
+func TestRequestError(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	})
+	defer server.Close()
+
+	_, err := client.ScrapeURL("https://example.com")
+	assert.ErrorContains(t, err, "429")
+}
+
+func TestOptionsMerge(t *testing.T) {
+	on, off := true, false
+	defaults := &Options{TargetSelector: "article", Timeout: 20, Json: &on, ImageCaptions: &on}
+	merged := (&Options{TargetSelector: "main", NoCache: &on, ImageCaptions: &off}).Merge(defaults)
+	assert.Equal(t, &Options{TargetSelector: "main", Timeout: 20, Json: &on, NoCache: &on, ImageCaptions: &off}, merged)
+	assert.Empty(t, merged.headers()["X-With-Generated-Alt"])
+
+	var none *Options
+	assert.Equal(t, defaults, none.Merge(defaults))
+}
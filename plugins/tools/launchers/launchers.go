@@ -0,0 +1,196 @@
+package launchers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GeneratedMarker is written to every generated file, only files with it are replaced or removed on regeneration
+const GeneratedMarker = "Generated by fabric launchers generate"
+
+const (
+	ShowNotify = "notify"
+	ShowEditor = "editor"
+)
+
+// Entry is a pattern offered by a launcher
+type Entry struct {
+	Pattern     string
+	Description string
+}
+
+// Title returns a readable name of the pattern, e.g. "Extract Wisdom" for extract_wisdom
+func (o *Entry) Title() string {
+	words := strings.FieldsFunc(o.Pattern, func(r rune) bool { return r == '_' || r == '-' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Target generates the entries of a launcher to a directory
+type Target struct {
+	Name string
+	// DefaultOutput is the directory the launcher reads its entries from, relative to the home directory
+	DefaultOutput string
+	generate      func(generator *Generator, output string, entries []*Entry) (files []string, err error)
+}
+
+var Targets = []*Target{
+	{Name: "rofi", DefaultOutput: ".config/rofi/scripts", generate: generateRofi},
+	{Name: "ulauncher", DefaultOutput: ".local/share/ulauncher/extensions/" + ulauncherExtensionId, generate: generateUlauncher},
+	{Name: "albert", DefaultOutput: ".local/share/applications", generate: generateDesktopEntries},
+	{Name: "raycast", DefaultOutput: ".config/fabric/launchers/raycast", generate: generateRaycast},
+}
+
+func FindTarget(name string) (ret *Target, err error) {
+	for _, target := range Targets {
+		if target.Name == name {
+			ret = target
+			return
+		}
+	}
+	err = fmt.Errorf("unknown launcher %s", name)
+	return
+}
+
+// Generator writes the launcher entries. They all call a runner script, that takes the input from the argument, the
+// selection or the clipboard, runs the pattern with fabric and shows the result in a notification or an editor.
+type Generator struct {
+	// FabricPath is the fabric binary called by the runner, launchers usually don't have the PATH of a shell
+	FabricPath string
+	// RunnerPath is where the runner script is written to
+	RunnerPath string
+	// Show is where the result is shown, ShowNotify or ShowEditor
+	Show string
+}
+
+// Generate writes the runner script and the entries of the target, it returns the written files
+func (o *Generator) Generate(target *Target, output string, entries []*Entry) (ret []string, err error) {
+	if o.Show != ShowNotify && o.Show != ShowEditor {
+		err = fmt.Errorf("unknown result view %s, use %s or %s", o.Show, ShowNotify, ShowEditor)
+		return
+	}
+
+	if err = writeFile(o.RunnerPath, o.buildRunner(), 0755); err != nil {
+		return
+	}
+	ret = append(ret, o.RunnerPath)
+
+	if err = os.MkdirAll(output, os.ModePerm); err != nil {
+		return
+	}
+
+	var files []string
+	if files, err = target.generate(o, output, entries); err != nil {
+		return
+	}
+	ret = append(ret, files...)
+	return
+}
+
+func (o *Generator) buildRunner() string {
+	return strings.ReplaceAll(runnerScript, "{{fabric}}", shellQuote(o.FabricPath))
+}
+
+// removeGenerated removes the files of the directory with the given prefix and suffix that were generated before, so
+// that the entries of removed patterns don't stay behind
+func removeGenerated(dir string, prefix string, suffix string) (err error) {
+	var entries []os.DirEntry
+	if entries, err = os.ReadDir(dir); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if content, readErr := os.ReadFile(path); readErr == nil && strings.Contains(string(content), GeneratedMarker) {
+			if err = os.Remove(path); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func writeFile(path string, content string, perm os.FileMode) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+	if err = os.WriteFile(path, []byte(content), perm); err != nil {
+		return
+	}
+	// WriteFile keeps the permissions of an existing file
+	err = os.Chmod(path, perm)
+	return
+}
+
+// shellQuote quotes the value as one word for sh
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// singleLine joins the lines of the value, launchers show one line descriptions
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+const runnerScript = `#!/bin/sh
+# ` + GeneratedMarker + `
+# Usage: fabric-launcher <pattern> <notify|editor> [input]
+# Without input, the selection or the clipboard is used.
+
+FABRIC={{fabric}}
+pattern="$1"
+show="$2"
+shift 2
+input="$*"
+
+notify() {
+    if command -v notify-send >/dev/null 2>&1; then
+        notify-send "$1" "$2"
+    elif command -v osascript >/dev/null 2>&1; then
+        osascript -e 'on run argv' -e 'display notification (item 2 of argv) with title (item 1 of argv)' -e 'end run' "$1" "$2"
+    else
+        printf '%s\n%s\n' "$1" "$2"
+    fi
+}
+
+if [ -z "$input" ]; then
+    if [ -n "$WAYLAND_DISPLAY" ] && command -v wl-paste >/dev/null 2>&1; then
+        input=$(wl-paste --primary --no-newline 2>/dev/null)
+        [ -z "$input" ] && input=$(wl-paste --no-newline 2>/dev/null)
+    elif command -v xclip >/dev/null 2>&1; then
+        input=$(xclip -o -selection primary 2>/dev/null)
+        [ -z "$input" ] && input=$(xclip -o -selection clipboard 2>/dev/null)
+    elif command -v xsel >/dev/null 2>&1; then
+        input=$(xsel --primary --output 2>/dev/null)
+        [ -z "$input" ] && input=$(xsel --clipboard --output 2>/dev/null)
+    elif command -v pbpaste >/dev/null 2>&1; then
+        input=$(pbpaste)
+    fi
+fi
+
+if [ -z "$input" ]; then
+    notify "fabric $pattern" "There is no selected or copied text"
+    exit 1
+fi
+
+output=$(printf '%s' "$input" | "$FABRIC" --pattern "$pattern" 2>&1)
+
+if [ "$show" = "editor" ]; then
+    file="${TMPDIR:-/tmp}/fabric-$pattern-$(date +%Y%m%d-%H%M%S).md"
+    printf '%s\n' "$output" > "$file"
+    if [ "$(uname)" = "Darwin" ]; then
+        open -t "$file"
+    else
+        xdg-open "$file"
+    fi
+else
+    notify "fabric $pattern" "$output"
+fi
+`
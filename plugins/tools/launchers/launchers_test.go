@@ -0,0 +1,114 @@
+package launchers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGenerator(t *testing.T) (ret *Generator, output string) {
+	dir := t.TempDir()
+	ret = &Generator{
+		FabricPath: "/opt/fabric's bin/fabric",
+		RunnerPath: filepath.Join(dir, "runner", "fabric-launcher"),
+		Show:       ShowNotify,
+	}
+	output = filepath.Join(dir, "output")
+	return
+}
+
+var testEntries = []*Entry{
+	{Pattern: "extract_wisdom", Description: "You extract surprising, insightful,\nand interesting information."},
+	{Pattern: "summarize"},
+}
+
+func TestEntry_Title(t *testing.T) {
+	assert.Equal(t, "Extract Wisdom", testEntries[0].Title())
+	assert.Equal(t, "Write Pull Request", (&Entry{Pattern: "write_pull-request"}).Title())
+}
+
+func TestGenerate_Raycast(t *testing.T) {
+	generator, output := newTestGenerator(t)
+	target, err := FindTarget("raycast")
+	require.NoError(t, err)
+
+	// entries of removed patterns are deleted, other scripts are kept
+	require.NoError(t, os.MkdirAll(output, os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "fabric-removed.sh"), []byte("# "+GeneratedMarker), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(output, "fabric-own.sh"), []byte("echo own"), 0755))
+
+	files, err := generator.Generate(target, output, testEntries)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	runner, err := os.ReadFile(generator.RunnerPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(runner), `FABRIC='/opt/fabric'\''s bin/fabric'`)
+
+	script, err := os.ReadFile(filepath.Join(output, "fabric-extract_wisdom.sh"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script), "# @raycast.title Extract Wisdom\n")
+	assert.Contains(t, string(script),
+		"# @raycast.description You extract surprising, insightful, and interesting information.\n")
+	assert.Contains(t, string(script), "'extract_wisdom' 'notify' \"$1\"")
+
+	assert.NoFileExists(t, filepath.Join(output, "fabric-removed.sh"))
+	assert.FileExists(t, filepath.Join(output, "fabric-own.sh"))
+}
+
+func TestGenerate_Rofi(t *testing.T) {
+	generator, output := newTestGenerator(t)
+	generator.Show = ShowEditor
+	target, _ := FindTarget("rofi")
+
+	_, err := generator.Generate(target, output, testEntries)
+	require.NoError(t, err)
+
+	script, err := os.ReadFile(filepath.Join(output, "fabric"))
+	require.NoError(t, err)
+	assert.Contains(t, string(script),
+		`'extract_wisdom - You extract surprising, insightful, and interesting information.' 'extract_wisdom'`)
+	assert.Contains(t, string(script), `"$ROFI_INFO" 'editor'`)
+}
+
+func TestGenerate_Albert(t *testing.T) {
+	generator, output := newTestGenerator(t)
+	generator.RunnerPath = filepath.Join(t.TempDir(), "100% fabric", "fabric-launcher")
+	target, _ := FindTarget("albert")
+
+	files, err := generator.Generate(target, output, testEntries[:1])
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	entry, err := os.ReadFile(filepath.Join(output, "fabric-extract_wisdom.desktop"))
+	require.NoError(t, err)
+	assert.Contains(t, string(entry), "Name=Fabric: Extract Wisdom\n")
+	assert.Contains(t, string(entry), "Comment=You extract surprising, insightful, and interesting information.\n")
+	assert.Contains(t, string(entry), `100%% fabric/fabric-launcher" "extract_wisdom" "notify"`)
+}
+
+func TestGenerate_Ulauncher(t *testing.T) {
+	generator, output := newTestGenerator(t)
+	target, _ := FindTarget("ulauncher")
+
+	_, err := generator.Generate(target, output, testEntries)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(output, "manifest.json"))
+	assert.FileExists(t, filepath.Join(output, "main.py"))
+	patterns, err := os.ReadFile(filepath.Join(output, "patterns.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(patterns), `"title": "Extract Wisdom"`)
+}
+
+func TestGenerate_UnknownShow(t *testing.T) {
+	generator, output := newTestGenerator(t)
+	generator.Show = "popup"
+	target, _ := FindTarget("rofi")
+
+	_, err := generator.Generate(target, output, testEntries)
+	assert.Error(t, err)
+}
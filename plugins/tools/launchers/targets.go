@@ -0,0 +1,233 @@
+package launchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// generateRofi writes a script for the script mode of rofi, it lists the patterns and runs the chosen one
+func generateRofi(generator *Generator, output string, entries []*Entry) (ret []string, err error) {
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("# " + GeneratedMarker + "\n")
+	script.WriteString(fmt.Sprintf("# Usage: rofi -show fabric -modi fabric:%s\n\n", filepath.Join(output, "fabric")))
+	script.WriteString("if [ -n \"$ROFI_INFO\" ]; then\n")
+	script.WriteString(fmt.Sprintf("    %s \"$ROFI_INFO\" %s >/dev/null 2>&1 &\n",
+		shellQuote(generator.RunnerPath), shellQuote(generator.Show)))
+	script.WriteString("    exit 0\nfi\n\n")
+	for _, entry := range entries {
+		label := entry.Pattern
+		if entry.Description != "" {
+			label = fmt.Sprintf("%s - %s", entry.Pattern, singleLine(entry.Description))
+		}
+		script.WriteString(fmt.Sprintf("printf '%%s\\000info\\037%%s\\n' %s %s\n",
+			shellQuote(label), shellQuote(entry.Pattern)))
+	}
+
+	path := filepath.Join(output, "fabric")
+	if err = writeFile(path, script.String(), 0755); err != nil {
+		return
+	}
+	ret = append(ret, path)
+	return
+}
+
+const ulauncherExtensionId = "com.github.danielmiessler.fabric"
+
+type ulauncherPattern struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type ulauncherConfig struct {
+	Runner   string              `json:"runner"`
+	Show     string              `json:"show"`
+	Patterns []*ulauncherPattern `json:"patterns"`
+}
+
+// generateUlauncher writes an Ulauncher extension, its keyword lists the patterns and runs the chosen one
+func generateUlauncher(generator *Generator, output string, entries []*Entry) (ret []string, err error) {
+	config := &ulauncherConfig{Runner: generator.RunnerPath, Show: generator.Show, Patterns: []*ulauncherPattern{}}
+	for _, entry := range entries {
+		config.Patterns = append(config.Patterns, &ulauncherPattern{
+			Name: entry.Pattern, Title: entry.Title(), Description: singleLine(entry.Description)})
+	}
+
+	var patterns []byte
+	if patterns, err = json.MarshalIndent(config, "", "  "); err != nil {
+		return
+	}
+
+	files := map[string]string{
+		"manifest.json":   ulauncherManifest,
+		"main.py":         ulauncherMain,
+		"patterns.json":   string(patterns),
+		"images/icon.svg": launcherIcon,
+	}
+	for name, content := range files {
+		path := filepath.Join(output, name)
+		if err = writeFile(path, content, 0644); err != nil {
+			return
+		}
+		ret = append(ret, path)
+	}
+	return
+}
+
+// generateDesktopEntries writes a desktop entry per pattern, they are indexed by the applications plugin of Albert and
+// by the application menus of the desktops
+func generateDesktopEntries(generator *Generator, output string, entries []*Entry) (ret []string, err error) {
+	if err = removeGenerated(output, "fabric-", ".desktop"); err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		var desktopEntry strings.Builder
+		desktopEntry.WriteString("[Desktop Entry]\n")
+		desktopEntry.WriteString("# " + GeneratedMarker + "\n")
+		desktopEntry.WriteString("Type=Application\n")
+		desktopEntry.WriteString(fmt.Sprintf("Name=Fabric: %s\n", entry.Title()))
+		if entry.Description != "" {
+			desktopEntry.WriteString(fmt.Sprintf("Comment=%s\n", escapeDesktopValue(singleLine(entry.Description))))
+		}
+		desktopEntry.WriteString(fmt.Sprintf("Exec=%s %s %s\n",
+			quoteDesktopArg(generator.RunnerPath), quoteDesktopArg(entry.Pattern), quoteDesktopArg(generator.Show)))
+		desktopEntry.WriteString("Terminal=false\n")
+		desktopEntry.WriteString("Categories=Utility;\n")
+		desktopEntry.WriteString(fmt.Sprintf("Keywords=fabric;%s;\n", entry.Pattern))
+
+		path := filepath.Join(output, fmt.Sprintf("fabric-%s.desktop", entry.Pattern))
+		if err = writeFile(path, desktopEntry.String(), 0644); err != nil {
+			return
+		}
+		ret = append(ret, path)
+	}
+	return
+}
+
+// generateRaycast writes a script command per pattern, the input is the argument or the selection or clipboard
+func generateRaycast(generator *Generator, output string, entries []*Entry) (ret []string, err error) {
+	if err = removeGenerated(output, "fabric-", ".sh"); err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		var script strings.Builder
+		script.WriteString("#!/bin/bash\n")
+		script.WriteString("# " + GeneratedMarker + "\n\n")
+		script.WriteString("# Required parameters:\n")
+		script.WriteString("# @raycast.schemaVersion 1\n")
+		script.WriteString(fmt.Sprintf("# @raycast.title %s\n", entry.Title()))
+		script.WriteString("# @raycast.mode silent\n\n")
+		script.WriteString("# Optional parameters:\n")
+		script.WriteString("# @raycast.icon 🧠\n")
+		script.WriteString("# @raycast.packageName Fabric\n")
+		script.WriteString("# @raycast.argument1 { \"type\": \"text\", \"placeholder\": \"Input, empty for the clipboard\", \"optional\": true }\n")
+		if entry.Description != "" {
+			script.WriteString("\n# Documentation:\n")
+			script.WriteString(fmt.Sprintf("# @raycast.description %s\n", singleLine(entry.Description)))
+		}
+		script.WriteString(fmt.Sprintf("\nexec %s %s %s \"$1\"\n",
+			shellQuote(generator.RunnerPath), shellQuote(entry.Pattern), shellQuote(generator.Show)))
+
+		path := filepath.Join(output, fmt.Sprintf("fabric-%s.sh", entry.Pattern))
+		if err = writeFile(path, script.String(), 0755); err != nil {
+			return
+		}
+		ret = append(ret, path)
+	}
+	return
+}
+
+func escapeDesktopValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`).Replace(value)
+}
+
+// quoteDesktopArg quotes an argument of the Exec key of a desktop entry
+func quoteDesktopArg(value string) string {
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(value)
+	// the Exec value is unescaped like other string values first
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(quoted, `\`, `\\`), "%", "%%") + `"`
+}
+
+const ulauncherManifest = `{
+  "required_api_version": "^2.0.0",
+  "name": "Fabric",
+  "description": "Run fabric patterns on the selection or the clipboard",
+  "developer_name": "fabric",
+  "icon": "images/icon.svg",
+  "options": {
+    "query_debounce": 0.1
+  },
+  "preferences": [
+    {
+      "id": "fabric_keyword",
+      "type": "keyword",
+      "name": "Fabric",
+      "default_value": "fabric"
+    }
+  ]
+}
+`
+
+const ulauncherMain = `# ` + GeneratedMarker + `
+import json
+import os
+import subprocess
+
+from ulauncher.api.client.EventListener import EventListener
+from ulauncher.api.client.Extension import Extension
+from ulauncher.api.shared.action.ExtensionCustomAction import ExtensionCustomAction
+from ulauncher.api.shared.action.HideWindowAction import HideWindowAction
+from ulauncher.api.shared.action.RenderResultListAction import RenderResultListAction
+from ulauncher.api.shared.event import ItemEnterEvent, KeywordQueryEvent
+from ulauncher.api.shared.item.ExtensionResultItem import ExtensionResultItem
+
+DIR = os.path.dirname(os.path.abspath(__file__))
+ICON = os.path.join(DIR, "images", "icon.svg")
+MAX_ITEMS = 10
+
+with open(os.path.join(DIR, "patterns.json")) as config_file:
+    CONFIG = json.load(config_file)
+
+
+class FabricExtension(Extension):
+    def __init__(self):
+        super().__init__()
+        self.subscribe(KeywordQueryEvent, QueryListener())
+        self.subscribe(ItemEnterEvent, EnterListener())
+
+
+class QueryListener(EventListener):
+    def on_event(self, event, extension):
+        query = (event.get_argument() or "").lower()
+        items = []
+        for pattern in CONFIG["patterns"]:
+            if query in pattern["name"].lower() or query in pattern["description"].lower():
+                items.append(ExtensionResultItem(icon=ICON, name=pattern["title"],
+                                                 description=pattern["description"],
+                                                 on_enter=ExtensionCustomAction(pattern["name"])))
+            if len(items) >= MAX_ITEMS:
+                break
+        return RenderResultListAction(items)
+
+
+class EnterListener(EventListener):
+    def on_event(self, event, extension):
+        subprocess.Popen([CONFIG["runner"], event.get_data(), CONFIG["show"]], start_new_session=True,
+                         stdout=subprocess.DEVNULL, stderr=subprocess.DEVNULL)
+        return HideWindowAction()
+
+
+if __name__ == "__main__":
+    FabricExtension().run()
+`
+
+const launcherIcon = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 64 64">
+  <rect width="64" height="64" rx="12" fill="#2b2d42"/>
+  <path d="M20 16h26v8H29v6h14v8H29v14h-9z" fill="#edf2f4"/>
+</svg>
+`
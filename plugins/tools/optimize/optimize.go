@@ -0,0 +1,361 @@
+package optimize
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JudgePattern scores the output of a prompt for a case of the dataset
+const JudgePattern = "rate_pattern_output"
+
+// RevisePattern revises a prompt based on the cases it failed
+const RevisePattern = "improve_prompt_with_cases"
+
+// ReportFile is written to the directory of the optimized pattern
+const ReportFile = "optimize_report.md"
+
+// maxFailingCases limits the cases the revision is based on, so that the input of the revise pattern stays small
+const maxFailingCases = 5
+
+var (
+	scoreRegex  = regexp.MustCompile(`(?im)^\W*score\W*\s*(\d+(?:\.\d+)?)`)
+	reasonRegex = regexp.MustCompile(`(?im)^\W*reason\W*\s*(.*)$`)
+	fenceRegex  = regexp.MustCompile("(?s)^```[a-zA-Z]*\n(.*)\n```$")
+)
+
+// Case is a line of the dataset, the expected output and the criteria are optional
+type Case struct {
+	Input     string            `json:"input"`
+	Expected  string            `json:"expected,omitempty"`
+	Criteria  string            `json:"criteria,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// LoadCases reads the cases of a JSONL file, one JSON object per line
+func LoadCases(path string) (ret []*Case, err error) {
+	var file *os.File
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		testCase := &Case{}
+		if err = json.Unmarshal([]byte(line), testCase); err != nil {
+			err = fmt.Errorf("invalid case on line %d of %s: %v", number, path, err)
+			return
+		}
+		if strings.TrimSpace(testCase.Input) == "" {
+			err = fmt.Errorf("the case on line %d of %s has no input", number, path)
+			return
+		}
+		ret = append(ret, testCase)
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if len(ret) == 0 {
+		err = fmt.Errorf("no cases in %s", path)
+	}
+	return
+}
+
+// Result is the score of a prompt for one case
+type Result struct {
+	Case   *Case
+	Output string
+	Score  float64
+	Reason string
+	Error  string
+}
+
+// Evaluation is the score of a prompt for all cases, the mean of the scores of the cases from 0 to 10
+type Evaluation struct {
+	Prompt  string
+	Score   float64
+	Results []*Result
+}
+
+// Failing returns the results below the passing score, the worst first
+func (o *Evaluation) Failing(passingScore float64) (ret []*Result) {
+	for _, result := range o.Results {
+		if result.Score < passingScore {
+			ret = append(ret, result)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].Score < ret[j].Score })
+	return
+}
+
+// Optimizer improves a prompt in iterations: the model proposes revisions based on the failing cases of the best
+// prompt so far, and a revision is kept if it scores better on all cases
+type Optimizer struct {
+	Jobs         int
+	Iterations   int
+	Candidates   int
+	PassingScore float64
+
+	// Run returns the output of the prompt for the case
+	Run func(prompt string, testCase *Case) (string, error)
+	// Judge returns the output of the judge pattern for the input of BuildJudgeInput
+	Judge func(input string) (string, error)
+	// Revise returns the output of the revise pattern for the input of BuildReviseInput
+	Revise func(input string) (string, error)
+	// Log reports the progress
+	Log func(format string, args ...any)
+}
+
+// Report is the course of an optimization
+type Report struct {
+	Initial *Evaluation
+	Best    *Evaluation
+	// Steps are all evaluated revisions, in order
+	Steps []*Step
+}
+
+// Step is a revision of an iteration
+type Step struct {
+	Iteration  int
+	Candidate  int
+	Evaluation *Evaluation
+	Error      string
+	Kept       bool
+}
+
+// Improved returns whether a revision scored better than the initial prompt
+func (o *Report) Improved() bool {
+	return o.Best != o.Initial
+}
+
+// Optimize evaluates the prompt and its revisions with the cases and returns the report with the best prompt
+func (o *Optimizer) Optimize(prompt string, cases []*Case) (ret *Report, err error) {
+	ret = &Report{}
+	o.log("evaluating the current prompt with %d cases", len(cases))
+	if ret.Initial, err = o.Evaluate(prompt, cases); err != nil {
+		return
+	}
+	ret.Best = ret.Initial
+	o.log("score %.2f", ret.Initial.Score)
+
+	for iteration := 1; iteration <= o.Iterations; iteration++ {
+		failing := ret.Best.Failing(o.PassingScore)
+		if len(failing) == 0 {
+			o.log("all cases pass, stopping")
+			break
+		}
+		if len(failing) > maxFailingCases {
+			failing = failing[:maxFailingCases]
+		}
+
+		best := ret.Best
+		for candidate := 1; candidate <= max(o.Candidates, 1); candidate++ {
+			step := &Step{Iteration: iteration, Candidate: candidate}
+			ret.Steps = append(ret.Steps, step)
+
+			var revised string
+			if revised, err = o.revise(best.Prompt, failing); err == nil {
+				step.Evaluation, err = o.Evaluate(revised, cases)
+			}
+			if err != nil {
+				step.Error = err.Error()
+				o.log("iteration %d, candidate %d: %v", iteration, candidate, err)
+				err = nil
+				continue
+			}
+
+			o.log("iteration %d, candidate %d: score %.2f", iteration, candidate, step.Evaluation.Score)
+			if step.Evaluation.Score > ret.Best.Score {
+				ret.Best = step.Evaluation
+			}
+		}
+	}
+
+	for _, step := range ret.Steps {
+		step.Kept = step.Evaluation != nil && step.Evaluation == ret.Best
+	}
+	return
+}
+
+// Evaluate runs the prompt for all cases and lets the judge score the outputs. A case that fails to run scores 0,
+// the evaluation fails only if no case could be scored.
+func (o *Optimizer) Evaluate(prompt string, cases []*Case) (ret *Evaluation, err error) {
+	ret = &Evaluation{Prompt: prompt, Results: make([]*Result, len(cases))}
+
+	jobs := max(o.Jobs, 1)
+	semaphore := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, testCase := range cases {
+		ret.Results[i] = &Result{Case: testCase}
+		wg.Add(1)
+		go func(result *Result) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if caseErr := o.score(prompt, result); caseErr != nil {
+				result.Score, result.Error = 0, caseErr.Error()
+			}
+		}(ret.Results[i])
+	}
+	wg.Wait()
+
+	var errs []error
+	var sum float64
+	for _, result := range ret.Results {
+		sum += result.Score
+		if result.Error != "" {
+			errs = append(errs, errors.New(result.Error))
+		}
+	}
+	if len(errs) == len(cases) {
+		err = fmt.Errorf("no case could be scored: %w", errors.Join(errs...))
+		return
+	}
+	ret.Score = sum / float64(len(cases))
+	return
+}
+
+func (o *Optimizer) score(prompt string, result *Result) (err error) {
+	if result.Output, err = o.Run(prompt, result.Case); err != nil {
+		return
+	}
+	var judgement string
+	if judgement, err = o.Judge(BuildJudgeInput(prompt, result.Case, result.Output)); err != nil {
+		return
+	}
+	result.Score, result.Reason, err = ParseJudgement(judgement)
+	return
+}
+
+func (o *Optimizer) revise(prompt string, failing []*Result) (ret string, err error) {
+	var output string
+	if output, err = o.Revise(BuildReviseInput(prompt, failing)); err != nil {
+		return
+	}
+	ret = strings.TrimSpace(output)
+	if match := fenceRegex.FindStringSubmatch(ret); match != nil {
+		ret = strings.TrimSpace(match[1])
+	}
+	if ret == "" {
+		err = fmt.Errorf("the revision is empty")
+	}
+	return
+}
+
+func (o *Optimizer) log(format string, args ...any) {
+	if o.Log != nil {
+		o.Log(format, args...)
+	}
+}
+
+// BuildJudgeInput returns the input of the judge pattern
+func BuildJudgeInput(prompt string, testCase *Case, output string) string {
+	var ret strings.Builder
+	ret.WriteString(fmt.Sprintf("PROMPT:\n%s\n\nINPUT:\n%s\n\n", strings.TrimSpace(prompt), strings.TrimSpace(testCase.Input)))
+	if testCase.Expected != "" {
+		ret.WriteString(fmt.Sprintf("EXPECTED OUTPUT:\n%s\n\n", strings.TrimSpace(testCase.Expected)))
+	}
+	if testCase.Criteria != "" {
+		ret.WriteString(fmt.Sprintf("CRITERIA:\n%s\n\n", strings.TrimSpace(testCase.Criteria)))
+	}
+	ret.WriteString(fmt.Sprintf("OUTPUT:\n%s\n", strings.TrimSpace(output)))
+	return ret.String()
+}
+
+// ParseJudgement reads the SCORE and REASON lines of the output of the judge pattern, the score is from 0 to 10
+func ParseJudgement(output string) (score float64, reason string, err error) {
+	match := scoreRegex.FindStringSubmatch(output)
+	if match == nil {
+		err = fmt.Errorf("could not read the score of the judge: %s", output)
+		return
+	}
+	if score, err = strconv.ParseFloat(match[1], 64); err != nil {
+		return
+	}
+	if score > 10 {
+		err = fmt.Errorf("the score %s of the judge is not between 0 and 10", match[1])
+		return
+	}
+	if match = reasonRegex.FindStringSubmatch(output); match != nil {
+		reason = strings.TrimSpace(match[1])
+	}
+	return
+}
+
+// BuildReviseInput returns the input of the revise pattern, the prompt with the cases it failed
+func BuildReviseInput(prompt string, failing []*Result) string {
+	var ret strings.Builder
+	ret.WriteString(fmt.Sprintf("PROMPT:\n%s\n", strings.TrimSpace(prompt)))
+	for i, result := range failing {
+		ret.WriteString(fmt.Sprintf("\nFAILED CASE %d (score %.1f of 10)\n", i+1, result.Score))
+		ret.WriteString(fmt.Sprintf("INPUT:\n%s\n", truncate(result.Case.Input)))
+		if result.Case.Expected != "" {
+			ret.WriteString(fmt.Sprintf("EXPECTED OUTPUT:\n%s\n", truncate(result.Case.Expected)))
+		}
+		if result.Case.Criteria != "" {
+			ret.WriteString(fmt.Sprintf("CRITERIA:\n%s\n", result.Case.Criteria))
+		}
+		if result.Error != "" {
+			ret.WriteString(fmt.Sprintf("ERROR:\n%s\n", result.Error))
+		} else {
+			ret.WriteString(fmt.Sprintf("OUTPUT:\n%s\nJUDGE:\n%s\n", truncate(result.Output), result.Reason))
+		}
+	}
+	return ret.String()
+}
+
+// truncate keeps long inputs and outputs of failing cases from dominating the input of the revise pattern
+func truncate(text string) string {
+	const maxLength = 4000
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > maxLength {
+		return string(runes[:maxLength]) + "\n[...]"
+	}
+	return text
+}
+
+// Markdown returns the report with the scores of all steps and the cases of the best prompt
+func (o *Report) Markdown(patternName string, datasetPath string) string {
+	var ret strings.Builder
+	ret.WriteString(fmt.Sprintf("# Optimization of %s\n\n", patternName))
+	ret.WriteString(fmt.Sprintf("Dataset: %s (%d cases)\n\n", datasetPath, len(o.Initial.Results)))
+	ret.WriteString(fmt.Sprintf("Initial score: %.2f\n\nBest score: %.2f\n\n", o.Initial.Score, o.Best.Score))
+
+	ret.WriteString("## Steps\n\n| Iteration | Candidate | Score | Kept |\n|---|---|---|---|\n")
+	for _, step := range o.Steps {
+		score := "error: " + strings.ReplaceAll(step.Error, "|", "/")
+		if step.Evaluation != nil {
+			score = fmt.Sprintf("%.2f", step.Evaluation.Score)
+		}
+		kept := ""
+		if step.Kept {
+			kept = "yes"
+		}
+		ret.WriteString(fmt.Sprintf("| %d | %d | %s | %s |\n", step.Iteration, step.Candidate, score, kept))
+	}
+
+	ret.WriteString("\n## Cases\n\n| Case | Initial | Best | Reason |\n|---|---|---|---|\n")
+	for i, result := range o.Best.Results {
+		reason := result.Reason
+		if result.Error != "" {
+			reason = "error: " + result.Error
+		}
+		ret.WriteString(fmt.Sprintf("| %d | %.1f | %.1f | %s |\n", i+1, o.Initial.Results[i].Score, result.Score,
+			strings.ReplaceAll(strings.ReplaceAll(reason, "|", "/"), "\n", " ")))
+	}
+	return ret.String()
+}
@@ -0,0 +1,85 @@
+package optimize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.jsonl")
+	assert.NoError(t, os.WriteFile(path, []byte(
+		`{"input": "a long text", "expected": "short"}`+"\n\n"+`{"input": "other", "criteria": "three bullets"}`+"\n"), 0644))
+	cases, err := LoadCases(path)
+	assert.NoError(t, err)
+	assert.Len(t, cases, 2)
+	assert.Equal(t, "three bullets", cases[1].Criteria)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"expected": "no input"}`), 0644))
+	_, err = LoadCases(path)
+	assert.Error(t, err)
+}
+
+func TestParseJudgement(t *testing.T) {
+	score, reason, err := ParseJudgement("SCORE: 7.5\nREASON: misses the format")
+	assert.NoError(t, err)
+	assert.Equal(t, 7.5, score)
+	assert.Equal(t, "misses the format", reason)
+
+	_, _, err = ParseJudgement("looks fine")
+	assert.Error(t, err)
+	_, _, err = ParseJudgement("SCORE: 70")
+	assert.Error(t, err)
+}
+
+func TestOptimize(t *testing.T) {
+	cases := []*Case{{Input: "one"}, {Input: "two"}, {Input: "three"}}
+
+	// a prompt scores by the number of rules it has, the revision adds one
+	var mutex sync.Mutex
+	var revisions int
+	optimizer := &Optimizer{
+		Jobs:         2,
+		Iterations:   3,
+		Candidates:   2,
+		PassingScore: 8,
+		Run: func(prompt string, testCase *Case) (string, error) {
+			if testCase.Input == "three" && !strings.Contains(prompt, "rule") {
+				return "", fmt.Errorf("failed")
+			}
+			return prompt, nil
+		},
+		Judge: func(input string) (string, error) {
+			output := input[strings.Index(input, "OUTPUT:\n")+len("OUTPUT:\n"):]
+			return fmt.Sprintf("SCORE: %d\nREASON: has %d rules", min(4+2*strings.Count(output, "rule"), 10),
+				strings.Count(output, "rule")), nil
+		},
+		Revise: func(input string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			revisions++
+			assert.Contains(t, input, "FAILED CASE 1")
+			prompt := strings.TrimSpace(strings.TrimPrefix(input[:strings.Index(input, "\nFAILED CASE")], "PROMPT:\n"))
+			if revisions%2 == 0 {
+				// a worse candidate
+				return "```\nno rules\n```", nil
+			}
+			return prompt + " rule", nil
+		},
+	}
+
+	report, err := optimizer.Optimize("Summarize.", cases)
+	assert.NoError(t, err)
+	assert.True(t, report.Improved())
+	assert.InDelta(t, 8.0/3, report.Initial.Score, 0.001)
+	assert.Equal(t, "Summarize. rule rule", report.Best.Prompt)
+	assert.Equal(t, 8.0, report.Best.Score)
+	// the third round is skipped as all cases pass
+	assert.Len(t, report.Steps, 4)
+	assert.Contains(t, report.Markdown("summarize", "cases.jsonl"), "| 2 | 1 | 8.00 | yes |")
+}
@@ -21,10 +21,11 @@ import (
 const DefaultPatternsGitRepoUrl = "https://github.com/danielmiessler/fabric.git"
 const DefaultPatternsGitRepoFolder = "patterns"
 
-func NewPatternsLoader(patterns *fsdb.PatternsEntity) (ret *PatternsLoader) {
+func NewPatternsLoader(patterns *fsdb.PatternsEntity, remoteCache *fsdb.StorageEntity) (ret *PatternsLoader) {
 	label := "Patterns Loader"
 	ret = &PatternsLoader{
 		Patterns:       patterns,
+		RemoteCache:    remoteCache,
 		loadedFilePath: patterns.BuildFilePath("loaded"),
 	}
 
@@ -43,6 +44,10 @@ func NewPatternsLoader(patterns *fsdb.PatternsEntity) (ret *PatternsLoader) {
 		"Enter the default folder in the Git repository where patterns are stored")
 	ret.DefaultFolder.Value = DefaultPatternsGitRepoFolder
 
+	// patterns given by URL, e.g. fabric -p https://raw.githubusercontent.com/.../system.md
+	ret.RemoteAllowedHosts = ret.AddSetting("Remote Allowed Hosts", false)
+	ret.RemoteAllowedHosts.Value = DefaultRemoteAllowedHosts
+
 	return
 }
 
@@ -53,6 +58,10 @@ type PatternsLoader struct {
 	DefaultGitRepoUrl *plugins.SetupQuestion
 	DefaultFolder     *plugins.SetupQuestion
 
+	// RemoteAllowedHosts are the comma separated hosts patterns can be fetched from by URL
+	RemoteAllowedHosts *plugins.Setting
+	RemoteCache        *fsdb.StorageEntity
+
 	loadedFilePath string
 
 	pathPatternsPrefix string
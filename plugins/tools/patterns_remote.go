@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// DefaultRemoteAllowedHosts are the hosts patterns can be fetched from by URL
+const DefaultRemoteAllowedHosts = "raw.githubusercontent.com,gist.githubusercontent.com"
+
+// RemoteCacheTTL is how long a fetched pattern is used before it is fetched again
+const RemoteCacheTTL = time.Hour
+
+// GetRemote returns the pattern of the URL of a system prompt, e.g. https://raw.githubusercontent.com/.../system.md.
+// Only the allowed hosts are fetched from. Patterns are cached, a cached pattern is also used if fetching it fails.
+// For a system.md the user.md next to it is fetched too.
+func (o *PatternsLoader) GetRemote(patternURL string) (ret *fsdb.Pattern, err error) {
+	var parsed *url.URL
+	if parsed, err = url.Parse(patternURL); err != nil {
+		err = fmt.Errorf("invalid pattern URL %s: %v", patternURL, err)
+		return
+	}
+	if !slices.Contains(o.GetRemoteAllowedHosts(), strings.ToLower(parsed.Hostname())) {
+		err = fmt.Errorf("patterns are not fetched from %s, add the host to %s to allow it",
+			parsed.Hostname(), o.RemoteAllowedHosts.EnvVariable)
+		return
+	}
+
+	ret = &fsdb.Pattern{Name: patternURL}
+	if ret.Pattern, err = o.fetchCached(patternURL, true); err != nil {
+		return
+	}
+	if strings.HasSuffix(parsed.Path, "/"+o.Patterns.SystemPatternFile) {
+		userURL := *parsed
+		userURL.Path = strings.TrimSuffix(parsed.Path, o.Patterns.SystemPatternFile) + fsdb.UserPatternFile
+		ret.User, _ = o.fetchCached(userURL.String(), false)
+	}
+	ret.Description = fsdb.DescribePattern(ret.Pattern)
+	return
+}
+
+// GetRemoteAllowedHosts returns the lower-case hosts patterns can be fetched from
+func (o *PatternsLoader) GetRemoteAllowedHosts() (ret []string) {
+	for _, host := range strings.Split(o.RemoteAllowedHosts.Value, ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			ret = append(ret, host)
+		}
+	}
+	return
+}
+
+// fetchCached returns the content of the URL, a missing optional file is cached as empty content
+func (o *PatternsLoader) fetchCached(fileURL string, required bool) (ret string, err error) {
+	name := fmt.Sprintf("%x", sha256.Sum256([]byte(fileURL)))
+	cached, cacheErr := o.RemoteCache.Load(name)
+	if cacheErr == nil {
+		if info, statErr := os.Stat(o.RemoteCache.BuildFilePathByName(name)); statErr == nil &&
+			time.Since(info.ModTime()) < RemoteCacheTTL {
+			ret = string(cached)
+			return
+		}
+	}
+
+	var content []byte
+	var found bool
+	if content, found, err = fetch(fileURL); err != nil {
+		if cacheErr == nil {
+			fmt.Fprintf(os.Stderr, "warning: using the cached pattern, %v\n", err)
+			ret, err = string(cached), nil
+		}
+		return
+	}
+	if !found && required {
+		err = fmt.Errorf("pattern %s not found", fileURL)
+		return
+	}
+
+	ret = string(content)
+	// nothing is cached in an ephemeral run
+	_ = o.RemoteCache.Save(name, content)
+	return
+}
+
+// fetch returns the content of the URL, found is false if it doesn't exist
+func fetch(fileURL string) (ret []byte, found bool, err error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	var resp *http.Response
+	if resp, err = client.Get(fileURL); err != nil {
+		err = fmt.Errorf("could not fetch %s: %v", fileURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("could not fetch %s: %s", fileURL, resp.Status)
+		return
+	}
+	if ret, err = io.ReadAll(resp.Body); err != nil {
+		err = fmt.Errorf("could not read %s: %v", fileURL, err)
+		return
+	}
+	found = true
+	return
+}
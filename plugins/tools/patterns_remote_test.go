@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+func TestPatternsLoader_GetRemote(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/draft/system.md":
+			_, _ = w.Write([]byte("You summarize."))
+		case "/draft/user.md":
+			_, _ = w.Write([]byte("CONTENT:"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cache := &fsdb.StorageEntity{Dir: t.TempDir(), FileExtension: ".md"}
+	patterns := &fsdb.PatternsEntity{
+		StorageEntity:     &fsdb.StorageEntity{Dir: t.TempDir(), ItemIsDir: true},
+		SystemPatternFile: "system.md",
+	}
+	loader := NewPatternsLoader(patterns, cache)
+
+	if _, err := loader.GetRemote(server.URL + "/draft/system.md"); err == nil {
+		t.Fatal("expected the host not to be allowed by default")
+	}
+
+	loader.RemoteAllowedHosts.Value = DefaultRemoteAllowedHosts + ",127.0.0.1"
+	pattern, err := loader.GetRemote(server.URL + "/draft/system.md")
+	if err != nil {
+		t.Fatalf("could not fetch the pattern: %v", err)
+	}
+	if pattern.Pattern != "You summarize." || pattern.User != "CONTENT:" {
+		t.Errorf("unexpected pattern %v", pattern)
+	}
+
+	fetched := requests
+	if _, err = loader.GetRemote(server.URL + "/draft/system.md"); err != nil || requests != fetched {
+		t.Errorf("expected the cached pattern, %d requests %v", requests-fetched, err)
+	}
+
+	if _, err = loader.GetRemote(server.URL + "/missing/system.md"); err == nil {
+		t.Error("expected an error for a missing pattern")
+	}
+}
@@ -0,0 +1,209 @@
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultRedactFlags are the flags whose values are redacted if no flags are configured, pattern variables often
+// contain secrets
+var DefaultRedactFlags = []string{"v", "variable"}
+
+const redacted = "[redacted]"
+
+func NewPrivacy() (ret *Privacy) {
+	label := "Privacy"
+	ret = &Privacy{}
+
+	ret.PluginBase = &plugins.PluginBase{
+		Name:             label,
+		SetupDescription: "Privacy - Control what is stored in sessions",
+		EnvNamePrefix:    plugins.BuildEnvVariablePrefix(label),
+		ConfigureCustom:  ret.configure,
+	}
+
+	ret.MaxInputSize = ret.AddSetupQuestionCustom("Max Input Size", false,
+		"Enter the size in bytes of the largest input stored in sessions, larger inputs are stored as hash and source (0 for no limit)")
+	ret.RedactFlags = ret.AddSetupQuestionCustom("Redact Flags", false,
+		fmt.Sprintf("Enter the comma separated flags whose values are redacted in the stored command line (default: %s)",
+			strings.Join(DefaultRedactFlags, ",")))
+	ret.NoMeta = ret.AddSetupQuestionCustom("No Meta", false,
+		"Enter true to not store the command line in sessions at all")
+
+	return
+}
+
+// Privacy controls what is persisted in sessions: large inputs, the values of flags and the command line itself
+type Privacy struct {
+	*plugins.PluginBase
+
+	MaxInputSize *plugins.SetupQuestion
+	RedactFlags  *plugins.SetupQuestion
+	NoMeta       *plugins.SetupQuestion
+
+	maxInputSize int
+	redactFlags  []string
+	noMeta       bool
+	// configured is set once the settings are valid, the command line is not stored before
+	configured bool
+}
+
+func (o *Privacy) configure() (err error) {
+	// the defaults apply even if a setting is invalid, so that an invalid size does not disable the redaction
+	o.configured = false
+	o.maxInputSize = 0
+	o.noMeta = false
+	o.redactFlags = DefaultRedactFlags
+	if o.RedactFlags.Value != "" {
+		o.redactFlags = nil
+		for _, flag := range strings.Split(o.RedactFlags.Value, ",") {
+			if flag = strings.TrimLeft(strings.TrimSpace(flag), "-"); flag != "" {
+				o.redactFlags = append(o.redactFlags, flag)
+			}
+		}
+	}
+
+	if o.MaxInputSize.Value != "" {
+		if o.maxInputSize, err = strconv.Atoi(o.MaxInputSize.Value); err != nil || o.maxInputSize < 0 {
+			o.maxInputSize = 0
+			err = fmt.Errorf("%s=%s is not a number of bytes", o.MaxInputSize.EnvVariable, o.MaxInputSize.Value)
+			return
+		}
+	}
+
+	if o.NoMeta.Value != "" {
+		if o.noMeta, err = strconv.ParseBool(o.NoMeta.Value); err != nil {
+			err = fmt.Errorf("%s=%s is not true or false", o.NoMeta.EnvVariable, o.NoMeta.Value)
+			return
+		}
+	}
+	o.configured = true
+	return
+}
+
+// CommandLine describes the flags of the command line. Aliases maps the short and long names of the flags to each
+// other, so that configuring one of them redacts both. Booleans are the flags without value, they can be combined with
+// other short flags, e.g. -sv.
+type CommandLine struct {
+	Aliases  map[string]string
+	Booleans map[string]bool
+}
+
+// BuildMeta returns the command line stored in the session, empty if it is not stored. The values of the redacted
+// flags are replaced and arguments larger than the max input size are stored as reference. Without a valid
+// configuration the command line is not stored.
+func (o *Privacy) BuildMeta(args []string, commandLine *CommandLine) (ret string) {
+	if o.noMeta || !o.configured {
+		return
+	}
+	if commandLine == nil {
+		commandLine = &CommandLine{}
+	}
+
+	var meta []string
+	redactNext := false
+	for _, arg := range args {
+		switch {
+		case redactNext:
+			arg = redacted
+			redactNext = false
+		case strings.HasPrefix(arg, "-") && arg != "-" && arg != "--":
+			var redactValue bool
+			arg, redactValue = o.redactFlag(arg, commandLine)
+			redactNext = redactValue
+		default:
+			arg = o.referenceIfLarge(arg, "argument")
+		}
+		meta = append(meta, arg)
+	}
+	ret = strings.Join(meta, " ")
+	return
+}
+
+// redactFlag redacts the value of the flag if it is given in the same argument, e.g. --variable=x, -vx or -svx, and
+// returns whether the next argument is its value
+func (o *Privacy) redactFlag(arg string, commandLine *CommandLine) (ret string, valueIsNext bool) {
+	ret = arg
+	if strings.HasPrefix(arg, "--") {
+		name, _, hasValue := strings.Cut(arg[2:], "=")
+		if !o.isRedacted(name, commandLine) {
+			return
+		}
+		if hasValue {
+			ret = fmt.Sprintf("--%s=%s", name, redacted)
+		} else {
+			valueIsNext = true
+		}
+		return
+	}
+
+	// short flags have a single character and can be combined, the first one with a value ends the combination. Its
+	// value follows directly, after = or as next argument.
+	for i := 1; i < len(arg); i++ {
+		name := arg[i : i+1]
+		if commandLine.Booleans[name] {
+			continue
+		}
+		if !o.isRedacted(name, commandLine) {
+			return
+		}
+		if value := arg[i+1:]; value != "" {
+			separator := ""
+			if value[0] == '=' {
+				separator = "="
+			}
+			ret = fmt.Sprintf("%s%s%s", arg[:i+1], separator, redacted)
+		} else {
+			valueIsNext = true
+		}
+		return
+	}
+	return
+}
+
+func (o *Privacy) isRedacted(name string, commandLine *CommandLine) bool {
+	alias := commandLine.Aliases[name]
+	for _, flag := range o.redactFlags {
+		if flag == name || (alias != "" && flag == alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProtectSession stores a reference instead of the new user messages of the session that are larger than the max
+// input size. The messages are still sent to the vendor.
+func (o *Privacy) ProtectSession(session *fsdb.Session, source string) {
+	if o.maxInputSize <= 0 {
+		return
+	}
+	for _, message := range session.Unsaved() {
+		if message.Role == goopenai.ChatMessageRoleUser && len(message.Content) > o.maxInputSize {
+			session.StoreAs(message, Reference(message.Content, source))
+		}
+	}
+}
+
+func (o *Privacy) referenceIfLarge(value string, source string) string {
+	if o.maxInputSize > 0 && len(value) > o.maxInputSize {
+		return Reference(value, source)
+	}
+	return value
+}
+
+// Reference describes an input that is not stored, the hash allows to check whether a known input was used
+func Reference(content string, source string) string {
+	hash := sha256.Sum256([]byte(content))
+	if source == "" {
+		source = "unknown"
+	}
+	return fmt.Sprintf("[input not stored: %d bytes, sha256:%s, source: %s]",
+		len(content), hex.EncodeToString(hash[:]), source)
+}
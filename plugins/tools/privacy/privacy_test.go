@@ -0,0 +1,92 @@
+package privacy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPrivacy(t *testing.T, maxInputSize string, redactFlags string, noMeta string) (ret *Privacy) {
+	ret = NewPrivacy()
+	ret.MaxInputSize.Value = maxInputSize
+	ret.RedactFlags.Value = redactFlags
+	ret.NoMeta.Value = noMeta
+	require.NoError(t, ret.configure())
+	return
+}
+
+func TestBuildMeta(t *testing.T) {
+	privacy := newTestPrivacy(t, "20", "", "")
+
+	meta := privacy.BuildMeta([]string{"-p", "summarize", "-v=#token:secret", "--variable", "#key:secret",
+		"--variable=#other:secret", "-vx", "--session=work", strings.Repeat("input ", 10)}, nil)
+
+	assert.NotContains(t, meta, "secret")
+	assert.Contains(t, meta, "-p summarize -v=[redacted] --variable [redacted] --variable=[redacted] -v[redacted]")
+	assert.Contains(t, meta, "--session=work")
+	assert.Contains(t, meta, "[input not stored: 60 bytes, sha256:")
+	assert.Contains(t, meta, "source: argument]")
+}
+
+func TestBuildMeta_CombinedShortFlags(t *testing.T) {
+	privacy := newTestPrivacy(t, "", "", "")
+	commandLine := &CommandLine{Booleans: map[string]bool{"s": true, "r": true}}
+
+	assert.Equal(t, "-sv [redacted] -p summarize",
+		privacy.BuildMeta([]string{"-sv", "key:secret", "-p", "summarize"}, commandLine))
+	assert.Equal(t, "-rsv=[redacted]", privacy.BuildMeta([]string{"-rsv=key:secret"}, commandLine))
+	assert.Equal(t, "-sv[redacted]", privacy.BuildMeta([]string{"-svkey:secret"}, commandLine))
+
+	// the value of another flag ends the combination
+	assert.Equal(t, "-sptweet", privacy.BuildMeta([]string{"-sptweet"}, commandLine))
+}
+
+func TestBuildMeta_Configured(t *testing.T) {
+	privacy := newTestPrivacy(t, "", "--session, model", "")
+	assert.Equal(t, "-v=#a:b --session [redacted] -m=[redacted]",
+		privacy.BuildMeta([]string{"-v=#a:b", "--session", "work", "-m=gpt"},
+			&CommandLine{Aliases: map[string]string{"m": "model"}}))
+
+	privacy = newTestPrivacy(t, "", "", "true")
+	assert.Empty(t, privacy.BuildMeta([]string{"-p", "summarize"}, nil))
+}
+
+func TestConfigure_Invalid(t *testing.T) {
+	privacy := NewPrivacy()
+	privacy.MaxInputSize.Value = "large"
+	assert.Error(t, privacy.configure())
+	assert.Equal(t, DefaultRedactFlags, privacy.redactFlags)
+	assert.Empty(t, privacy.BuildMeta([]string{"-v=#token:secret"}, nil))
+
+	assert.Empty(t, NewPrivacy().BuildMeta([]string{"-p", "summarize"}, nil))
+}
+
+func TestProtectSession(t *testing.T) {
+	privacy := newTestPrivacy(t, "10", "", "")
+	dir := t.TempDir()
+	sessions := &fsdb.SessionsEntity{StorageEntity: &fsdb.StorageEntity{Dir: dir, FileExtension: fsdb.SessionLogExtension}}
+
+	session, err := sessions.Get("private")
+	require.NoError(t, err)
+	large := &common.Message{Role: "user", Content: "a very large input"}
+	session.Append(&common.Message{Role: "system", Content: "a very large system prompt"}, large,
+		&common.Message{Role: "assistant", Content: "a very large answer"})
+
+	privacy.ProtectSession(session, "stdin")
+	require.NoError(t, sessions.SaveSession(session))
+	assert.Equal(t, "a very large input", large.Content)
+
+	stored, err := sessions.Get("private")
+	require.NoError(t, err)
+	assert.Equal(t, "a very large system prompt", stored.Messages[0].Content)
+	assert.Equal(t, Reference("a very large input", "stdin"), stored.Messages[1].Content)
+	assert.Equal(t, "a very large answer", stored.Messages[2].Content)
+
+	info, err := sessions.GetInfo("private")
+	require.NoError(t, err)
+	assert.NotContains(t, info.Title, "a very large input")
+}
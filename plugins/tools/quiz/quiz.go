@@ -0,0 +1,230 @@
+package quiz
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// GradePattern grades a free-text answer against the generated answer of the question
+const GradePattern = "grade_quiz_answer"
+
+// PassingScore is the lowest score of a correct answer, lower scores restart the repetition of the card
+const PassingScore = 3
+
+const initialEase = 2.5
+const minEase = 1.3
+
+var (
+	subjectRegex   = regexp.MustCompile(`^(?:[*-]\s*)?Subject:\s*(.*)$`)
+	objectiveRegex = regexp.MustCompile(`^[*-]\s*Learning objective:\s*(.*)$`)
+	questionRegex  = regexp.MustCompile(`^(?:[*-]\s*)?(?:\*\*)?Question\s*\d*(?:\*\*)?:\s*(?:\*\*)?\s*(.*)$`)
+	answerRegex    = regexp.MustCompile(`^(?:[*-]\s*)?(?:\*\*)?Answer\s*\d*(?:\*\*)?:\s*(?:\*\*)?\s*(.*)$`)
+	qaRegex        = regexp.MustCompile(`^Q:\s*(.*?)\s+A:\s*(.*)$`)
+	scoreRegex     = regexp.MustCompile(`(?i)^\W*score\W*\s*(\d)`)
+	feedbackRegex  = regexp.MustCompile(`(?i)^\W*feedback\W*\s*(.*)$`)
+	correctRegex   = regexp.MustCompile(`(?i)^\W*(?:correct answer|answer)\W*\s*(.*)$`)
+)
+
+// Parse returns the questions of the output of create_quiz, with the subject and learning objective, or of
+// to_flashcards, CSV or "Q: ... A: ..." lines. Output that is none of them, e.g. prose, is an error.
+func Parse(output string) (ret []*fsdb.QuizCard, err error) {
+	if ret = parseQuiz(output); len(ret) > 0 {
+		return
+	}
+	if ret = parseQA(output); len(ret) > 0 {
+		return
+	}
+	ret, err = parseCSV(output)
+	return
+}
+
+func parseQuiz(output string) (ret []*fsdb.QuizCard) {
+	var subject, objective string
+	var card *fsdb.QuizCard
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if match := subjectRegex.FindStringSubmatch(line); match != nil {
+			subject = strings.TrimSpace(match[1])
+		} else if match = objectiveRegex.FindStringSubmatch(line); match != nil {
+			objective = strings.TrimSpace(match[1])
+		} else if match = questionRegex.FindStringSubmatch(line); match != nil && strings.TrimSpace(match[1]) != "" {
+			card = &fsdb.QuizCard{Subject: joinSubject(subject, objective), Question: strings.TrimSpace(match[1])}
+			ret = append(ret, card)
+		} else if match = answerRegex.FindStringSubmatch(line); match != nil && card != nil && card.Answer == "" {
+			card.Answer = strings.TrimSpace(match[1])
+		}
+	}
+	return
+}
+
+func parseQA(output string) (ret []*fsdb.QuizCard) {
+	for _, line := range strings.Split(output, "\n") {
+		if match := qaRegex.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			ret = append(ret, &fsdb.QuizCard{Question: match[1], Answer: match[2]})
+		}
+	}
+	return
+}
+
+// parseCSV accepts CSV whose records, besides code fences and an optional header, all have the same number of at least
+// two columns, so that prose with commas is not taken for questions
+func parseCSV(output string) (ret []*fsdb.QuizCard, err error) {
+	reader := csv.NewReader(strings.NewReader(output))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var records [][]string
+	if records, err = reader.ReadAll(); err != nil {
+		err = fmt.Errorf("the output is neither a quiz nor CSV: %v", err)
+		return
+	}
+
+	columns := 0
+	for i, record := range records {
+		if len(record) == 1 && strings.HasPrefix(strings.TrimSpace(record[0]), "```") {
+			continue
+		}
+		if columns == 0 {
+			columns = len(record)
+		}
+		if len(record) < 2 || len(record) != columns {
+			err = fmt.Errorf("the output is neither a quiz nor CSV with questions and answers, line %d has %d columns",
+				i+1, len(record))
+			ret = nil
+			return
+		}
+		if len(ret) == 0 && isHeader(record) {
+			continue
+		}
+		ret = append(ret, &fsdb.QuizCard{
+			Question: strings.TrimSpace(record[0]),
+			Answer:   strings.TrimSpace(strings.Join(record[1:], ",")),
+		})
+	}
+	return
+}
+
+func isHeader(record []string) bool {
+	question := strings.ToLower(strings.TrimSpace(record[0]))
+	return question == "question" || question == "front" || question == "q"
+}
+
+func joinSubject(subject string, objective string) string {
+	if subject != "" && objective != "" {
+		return subject + " - " + objective
+	}
+	return subject + objective
+}
+
+// Review schedules the next repetition of the card with SM-2. Scores below PassingScore restart the repetitions, so
+// the missed question comes back the next day.
+func Review(card *fsdb.QuizCard, score int, now time.Time) {
+	if card.Ease == 0 {
+		card.Ease = initialEase
+	}
+
+	if score < PassingScore {
+		card.Repetitions = 0
+		card.Interval = 1
+	} else {
+		card.Repetitions++
+		switch card.Repetitions {
+		case 1:
+			card.Interval = 1
+		case 2:
+			card.Interval = 6
+		default:
+			card.Interval = int(math.Round(float64(card.Interval) * card.Ease))
+		}
+	}
+
+	missing := float64(5 - score)
+	if card.Ease += 0.1 - missing*(0.08+missing*0.02); card.Ease < minEase {
+		card.Ease = minEase
+	}
+
+	card.Due = now.AddDate(0, 0, card.Interval)
+	card.Reviews = append(card.Reviews, &fsdb.QuizReview{Time: now, Score: score})
+}
+
+// Select returns up to count cards to ask, the due cards first, the most overdue ones first, then the new cards in
+// their order
+func Select(deck *fsdb.QuizDeck, count int, now time.Time) (ret []*fsdb.QuizCard) {
+	var due, fresh []*fsdb.QuizCard
+	for _, card := range deck.Cards {
+		if card.IsNew() {
+			fresh = append(fresh, card)
+		} else if !card.Due.After(now) {
+			due = append(due, card)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Due.Before(due[j].Due) })
+
+	ret = append(due, fresh...)
+	if count > 0 && len(ret) > count {
+		ret = ret[:count]
+	}
+	return
+}
+
+// NextDue returns the time the next card is due, zero if there are no cards
+func NextDue(deck *fsdb.QuizDeck) (ret time.Time) {
+	for _, card := range deck.Cards {
+		if !card.IsNew() && (ret.IsZero() || card.Due.Before(ret)) {
+			ret = card.Due
+		}
+	}
+	return
+}
+
+// BuildGradeInput returns the input of the grade_quiz_answer pattern
+func BuildGradeInput(card *fsdb.QuizCard, answer string) string {
+	var ret strings.Builder
+	if card.Subject != "" {
+		ret.WriteString(fmt.Sprintf("SUBJECT: %s\n", card.Subject))
+	}
+	ret.WriteString(fmt.Sprintf("QUESTION: %s\n", card.Question))
+	if card.Answer != "" {
+		ret.WriteString(fmt.Sprintf("EXPECTED ANSWER: %s\n", card.Answer))
+	} else {
+		ret.WriteString("EXPECTED ANSWER: (none, use your own knowledge)\n")
+	}
+	ret.WriteString(fmt.Sprintf("STUDENT ANSWER: %s\n", answer))
+	return ret.String()
+}
+
+// Grade is the result of grading an answer
+type Grade struct {
+	Score    int
+	Feedback string
+	// Answer is the correct answer, the expected one or the one of the model if the question had none
+	Answer string
+}
+
+// ParseGrade reads the SCORE, FEEDBACK and ANSWER lines of the output of the grade_quiz_answer pattern
+func ParseGrade(output string) (ret *Grade, err error) {
+	ret = &Grade{Score: -1}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if match := scoreRegex.FindStringSubmatch(line); match != nil {
+			ret.Score, _ = strconv.Atoi(match[1])
+		} else if match = feedbackRegex.FindStringSubmatch(line); match != nil {
+			ret.Feedback = strings.TrimSpace(match[1])
+		} else if match = correctRegex.FindStringSubmatch(line); match != nil {
+			ret.Answer = strings.TrimSpace(match[1])
+		}
+	}
+	if ret.Score < 0 || ret.Score > 5 {
+		err = fmt.Errorf("could not read the score of the grade: %s", output)
+	}
+	return
+}
@@ -0,0 +1,98 @@
+package quiz
+
+import (
+	"testing"
+	"time"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+func TestParse_Quiz(t *testing.T) {
+	output := `Subject: Networking
+* Learning objective: Explain TCP
+    - Question 1: What does the three-way handshake establish?
+    - Answer 1:
+
+    - Question 2: Why does TCP use sequence numbers?
+    - Answer 2: To order segments and detect loss.
+`
+	cards, err := Parse(output)
+	if err != nil || len(cards) != 2 {
+		t.Fatalf("expected 2 cards, got %d", len(cards))
+	}
+	if cards[0].Subject != "Networking - Explain TCP" || cards[0].Answer != "" {
+		t.Errorf("unexpected first card %+v", cards[0])
+	}
+	if cards[1].Question != "Why does TCP use sequence numbers?" || cards[1].Answer != "To order segments and detect loss." {
+		t.Errorf("unexpected second card %+v", cards[1])
+	}
+}
+
+func TestParse_Flashcards(t *testing.T) {
+	cards, _ := Parse("```csv\nQuestion,Answer\nWhere is the Dead Sea located?,\"on the border between Israel and Jordan\"\nHow long is the Dead Sea?,70 km\n```\n")
+	if len(cards) != 2 || cards[0].Answer != "on the border between Israel and Jordan" || cards[1].Answer != "70 km" {
+		t.Errorf("unexpected cards %v", cards)
+	}
+
+	cards, _ = Parse("Q: How long is the Dead Sea? A: 70 km\n")
+	if len(cards) != 1 || cards[0].Question != "How long is the Dead Sea?" || cards[0].Answer != "70 km" {
+		t.Errorf("unexpected cards %v", cards)
+	}
+}
+
+func TestParse_Prose(t *testing.T) {
+	cards, err := Parse("Sure, here are some thoughts on the Dead Sea.\nIt is very salty, and very low.\nEnjoy your trip!\n")
+	if err == nil || len(cards) != 0 {
+		t.Errorf("expected an error for prose, got %v %v", cards, err)
+	}
+}
+
+func TestReview(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	card := &fsdb.QuizCard{}
+
+	for _, interval := range []int{1, 6, 16} {
+		Review(card, 5, now)
+		if card.Interval != interval {
+			t.Fatalf("expected interval %d, got %d", interval, card.Interval)
+		}
+	}
+
+	Review(card, 1, now)
+	if card.Repetitions != 0 || card.Interval != 1 || !card.Due.Equal(now.AddDate(0, 0, 1)) {
+		t.Errorf("expected a missed card to come back the next day, got %+v", card)
+	}
+	if card.Ease < minEase || len(card.Reviews) != 4 {
+		t.Errorf("unexpected ease %v or reviews %d", card.Ease, len(card.Reviews))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	reviewed := []*fsdb.QuizReview{{Time: now, Score: 2}}
+	deck := &fsdb.QuizDeck{Cards: []*fsdb.QuizCard{
+		{Question: "new"},
+		{Question: "later", Due: now.Add(time.Hour), Reviews: reviewed},
+		{Question: "due", Due: now.Add(-time.Hour), Reviews: reviewed},
+		{Question: "overdue", Due: now.Add(-48 * time.Hour), Reviews: reviewed},
+	}}
+
+	cards := Select(deck, 0, now)
+	if len(cards) != 3 || cards[0].Question != "overdue" || cards[1].Question != "due" || cards[2].Question != "new" {
+		t.Errorf("unexpected selection %v", cards)
+	}
+	if cards = Select(deck, 1, now); len(cards) != 1 {
+		t.Errorf("expected the count to limit the selection, got %d", len(cards))
+	}
+}
+
+func TestParseGrade(t *testing.T) {
+	grade, err := ParseGrade("SCORE: 4\nFEEDBACK: Mostly right.\nANSWER: It synchronizes sequence numbers.")
+	if err != nil || grade.Score != 4 || grade.Feedback != "Mostly right." || grade.Answer != "It synchronizes sequence numbers." {
+		t.Errorf("unexpected grade %+v %v", grade, err)
+	}
+
+	if _, err = ParseGrade("I think it is fine"); err == nil {
+		t.Error("expected an error without a score")
+	}
+}
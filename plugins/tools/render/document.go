@@ -0,0 +1,296 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	outputStartPrefix = "<!-- fabric:output"
+	outputEnd         = "<!-- /fabric:output -->"
+
+	InputBlockPrefix = "block:"
+	InputFilePrefix  = "file:"
+)
+
+var (
+	fenceOpenRegex   = regexp.MustCompile("^(`{3,}|~{3,})\\s*fabric(?:\\s+(.*))?$")
+	outputStartRegex = regexp.MustCompile(`^<!-- fabric:output(?:\s+hash=([0-9a-f]+))?\s*-->$`)
+	attributeRegex   = regexp.MustCompile(`([\w.#-]+)=(?:"([^"]*)"|(\S+))`)
+)
+
+// Document is a Markdown document split into plain text and fabric blocks
+type Document struct {
+	Segments []*Segment
+	Blocks   []*Block
+}
+
+// Segment is either plain text or a fabric block
+type Segment struct {
+	Text  string
+	Block *Block
+}
+
+// Block is a fenced ```fabric block, its attributes define how it is executed
+type Block struct {
+	Index      int
+	Id         string
+	Attributes map[string]string
+	Header     string
+	Fence      string
+	Body       string
+
+	Output     string
+	OutputHash string
+}
+
+func (o *Block) Pattern() string {
+	return o.Attributes["pattern"]
+}
+
+func (o *Block) Model() string {
+	return o.Attributes["model"]
+}
+
+func (o *Block) Context() string {
+	return o.Attributes["context"]
+}
+
+// Inputs returns the references of the input attribute, a comma separated list of
+// "block:<id>", "file:<path>" or URLs
+func (o *Block) Inputs() (ret []string) {
+	for _, input := range strings.Split(o.Attributes["input"], ",") {
+		if input = strings.TrimSpace(input); input != "" {
+			ret = append(ret, input)
+		}
+	}
+	return
+}
+
+// Variables returns the pattern variables given as var.<name>=<value> attributes
+func (o *Block) Variables() (ret map[string]string) {
+	for key, value := range o.Attributes {
+		if name, found := strings.CutPrefix(key, "var."); found {
+			if ret == nil {
+				ret = map[string]string{}
+			}
+			ret[name] = value
+		}
+	}
+	return
+}
+
+// DependsOn returns the ids of the blocks whose outputs are inputs of this block
+func (o *Block) DependsOn() (ret []string) {
+	for _, input := range o.Inputs() {
+		if id, found := strings.CutPrefix(input, InputBlockPrefix); found {
+			ret = append(ret, id)
+		}
+	}
+	return
+}
+
+func (o *Block) Name() (ret string) {
+	if ret = o.Id; ret == "" {
+		ret = fmt.Sprintf("#%d", o.Index+1)
+	}
+	return
+}
+
+// Parse splits a Markdown document into text segments and fabric blocks with their previous outputs
+func Parse(content string) (ret *Document, err error) {
+	ret = &Document{}
+	lines := strings.SplitAfter(content, "\n")
+
+	var text strings.Builder
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		match := fenceOpenRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			text.WriteString(lines[i])
+			continue
+		}
+
+		block := &Block{
+			Index:      len(ret.Blocks),
+			Header:     line,
+			Fence:      match[1],
+			Attributes: parseAttributes(match[2]),
+		}
+		block.Id = block.Attributes["id"]
+
+		var body strings.Builder
+		closed := false
+		for i++; i < len(lines); i++ {
+			closingLine := strings.TrimSpace(lines[i])
+			if strings.HasPrefix(closingLine, block.Fence) && strings.Trim(closingLine, block.Fence[:1]) == "" {
+				closed = true
+				break
+			}
+			body.WriteString(lines[i])
+		}
+		if !closed {
+			err = fmt.Errorf("fabric block %s is not closed", block.Name())
+			return
+		}
+		block.Body = body.String()
+
+		i = parseOutput(lines, i, block)
+
+		if text.Len() > 0 {
+			ret.Segments = append(ret.Segments, &Segment{Text: text.String()})
+			text.Reset()
+		}
+		ret.Segments = append(ret.Segments, &Segment{Block: block})
+		ret.Blocks = append(ret.Blocks, block)
+	}
+
+	if text.Len() > 0 {
+		ret.Segments = append(ret.Segments, &Segment{Text: text.String()})
+	}
+
+	err = ret.validate()
+	return
+}
+
+// parseOutput reads the output region following the closing fence at index end and returns the index of the last
+// consumed line
+func parseOutput(lines []string, end int, block *Block) (ret int) {
+	ret = end
+
+	start := end + 1
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	if start >= len(lines) {
+		return
+	}
+
+	match := outputStartRegex.FindStringSubmatch(strings.TrimSpace(lines[start]))
+	if match == nil {
+		return
+	}
+
+	var output strings.Builder
+	for i := start + 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == outputEnd {
+			block.OutputHash = match[1]
+			block.Output = strings.TrimSuffix(output.String(), "\n")
+			ret = i
+			return
+		}
+		output.WriteString(lines[i])
+	}
+	// an output region without end marker is treated as text
+	return
+}
+
+func parseAttributes(header string) (ret map[string]string) {
+	ret = map[string]string{}
+	for _, match := range attributeRegex.FindAllStringSubmatch(header, -1) {
+		if match[2] != "" {
+			ret[match[1]] = match[2]
+		} else {
+			ret[match[1]] = match[3]
+		}
+	}
+	return
+}
+
+func (o *Document) validate() (err error) {
+	ids := map[string]*Block{}
+	for _, block := range o.Blocks {
+		if block.Id == "" {
+			continue
+		}
+		if _, exists := ids[block.Id]; exists {
+			err = fmt.Errorf("duplicate fabric block id: %s", block.Id)
+			return
+		}
+		ids[block.Id] = block
+	}
+
+	for _, block := range o.Blocks {
+		if block.Pattern() == "" {
+			err = fmt.Errorf("fabric block %s has no pattern", block.Name())
+			return
+		}
+		for _, dependency := range block.DependsOn() {
+			if _, exists := ids[dependency]; !exists {
+				err = fmt.Errorf("fabric block %s references unknown block: %s", block.Name(), dependency)
+				return
+			}
+		}
+	}
+
+	// detect dependency cycles, they would block the execution forever
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(block *Block) error
+	visit = func(block *Block) error {
+		if visited[block.Id] {
+			return nil
+		}
+		if visiting[block.Id] {
+			return fmt.Errorf("fabric block %s has a cyclic input reference", block.Name())
+		}
+		visiting[block.Id] = true
+		for _, dependency := range block.DependsOn() {
+			if cycleErr := visit(ids[dependency]); cycleErr != nil {
+				return cycleErr
+			}
+		}
+		visiting[block.Id] = false
+		visited[block.Id] = true
+		return nil
+	}
+	for _, block := range o.Blocks {
+		if block.Id != "" {
+			if err = visit(block); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// BlockById returns the block with the given id or nil
+func (o *Document) BlockById(id string) (ret *Block) {
+	for _, block := range o.Blocks {
+		if block.Id == id {
+			ret = block
+			break
+		}
+	}
+	return
+}
+
+// String writes the document back with the output region below each executed block
+func (o *Document) String() (ret string) {
+	var builder strings.Builder
+	for _, segment := range o.Segments {
+		if segment.Block == nil {
+			builder.WriteString(segment.Text)
+			continue
+		}
+
+		block := segment.Block
+		builder.WriteString(block.Header)
+		builder.WriteString("\n")
+		builder.WriteString(block.Body)
+		builder.WriteString(block.Fence)
+		builder.WriteString("\n")
+		if block.OutputHash != "" {
+			builder.WriteString(fmt.Sprintf("%s hash=%s -->\n", outputStartPrefix, block.OutputHash))
+			if block.Output != "" {
+				builder.WriteString(block.Output)
+				builder.WriteString("\n")
+			}
+			builder.WriteString(outputEnd)
+			builder.WriteString("\n")
+		}
+	}
+	ret = builder.String()
+	return
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDocument = "# Weekly report\n\n" +
+	"```fabric pattern=summarize id=notes input=file:notes.md\n" +
+	"```\n\n" +
+	"Some text in between.\n\n" +
+	"```fabric pattern=extract_questions input=block:notes model=\"gpt 4\" var.#role=expert\n" +
+	"Only the open ones.\n" +
+	"```\n"
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(testDocument)
+	assert.NoError(t, err)
+	assert.Len(t, doc.Blocks, 2)
+	assert.Len(t, doc.Segments, 4)
+
+	assert.Equal(t, "notes", doc.Blocks[0].Id)
+	assert.Equal(t, []string{"file:notes.md"}, doc.Blocks[0].Inputs())
+	assert.Equal(t, "gpt 4", doc.Blocks[1].Model())
+	assert.Equal(t, []string{"notes"}, doc.Blocks[1].DependsOn())
+	assert.Equal(t, map[string]string{"#role": "expert"}, doc.Blocks[1].Variables())
+	assert.Equal(t, "Only the open ones.\n", doc.Blocks[1].Body)
+
+	assert.Equal(t, testDocument, doc.String())
+}
+
+func TestParse_Errors(t *testing.T) {
+	_, err := Parse("```fabric pattern=a\nnot closed\n")
+	assert.ErrorContains(t, err, "not closed")
+
+	_, err = Parse("```fabric\n```\n")
+	assert.ErrorContains(t, err, "no pattern")
+
+	_, err = Parse("```fabric pattern=a input=block:missing\n```\n")
+	assert.ErrorContains(t, err, "unknown block")
+
+	_, err = Parse("```fabric pattern=a id=a input=block:b\n```\n```fabric pattern=b id=b input=block:a\n```\n")
+	assert.ErrorContains(t, err, "cyclic")
+}
+
+func TestRenderFile(t *testing.T) {
+	dir := t.TempDir()
+	docPath := filepath.Join(dir, "report.md")
+	assert.NoError(t, os.WriteFile(docPath, []byte(testDocument), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("meeting notes"), 0644))
+
+	var executions atomic.Int32
+	renderer := &Renderer{
+		Jobs: 2,
+		Execute: func(block *Block, input string) (string, error) {
+			executions.Add(1)
+			return fmt.Sprintf("%s(%s)", block.Pattern(), strings.ReplaceAll(input, "\n", " ")), nil
+		},
+	}
+
+	assert.NoError(t, renderer.RenderFile(docPath))
+	assert.Equal(t, int32(2), executions.Load())
+
+	rendered, _ := os.ReadFile(docPath)
+	assert.Contains(t, string(rendered), "```\n<!-- fabric:output hash=")
+	assert.Contains(t, string(rendered), "\nsummarize(meeting notes)\n<!-- /fabric:output -->\n")
+	assert.Contains(t, string(rendered), "\nextract_questions(summarize(meeting notes)  Only the open ones.)\n")
+
+	// rendering again is idempotent and does not execute the unchanged blocks
+	assert.NoError(t, renderer.RenderFile(docPath))
+	assert.Equal(t, int32(2), executions.Load())
+	renderedAgain, _ := os.ReadFile(docPath)
+	assert.Equal(t, string(rendered), string(renderedAgain))
+
+	// a changed input executes the block and the blocks depending on it
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("new notes"), 0644))
+	assert.NoError(t, renderer.RenderFile(docPath))
+	assert.Equal(t, int32(4), executions.Load())
+}
+
+func TestRender_Cache(t *testing.T) {
+	cache := &fsdb.StorageEntity{Dir: t.TempDir(), FileExtension: ".md"}
+	var executions atomic.Int32
+	renderer := &Renderer{
+		Cache: cache,
+		Execute: func(block *Block, input string) (string, error) {
+			executions.Add(1)
+			return "output", nil
+		},
+	}
+
+	content := "```fabric pattern=summarize\ntext\n```\n"
+	_, err := renderer.Render(content)
+	assert.NoError(t, err)
+
+	// the output was removed from the document, but it is still cached
+	rendered, err := renderer.Render(content)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), executions.Load())
+	assert.Contains(t, rendered, "\noutput\n")
+}
+
+func TestRender_FailedDependency(t *testing.T) {
+	renderer := &Renderer{
+		Execute: func(block *Block, input string) (string, error) {
+			if block.Id == "a" {
+				return "", fmt.Errorf("vendor error")
+			}
+			return "ok", nil
+		},
+	}
+
+	content := "```fabric pattern=x id=a\nin\n```\n```fabric pattern=y id=b input=block:a\n```\n```fabric pattern=z\nin\n```\n"
+	rendered, err := renderer.Render(content)
+	assert.ErrorContains(t, err, "fabric block a: vendor error")
+	assert.ErrorContains(t, err, "fabric block b: input block failed")
+	assert.Equal(t, 1, strings.Count(rendered, "<!-- /fabric:output -->"))
+}
+
+func TestRender_DryRunAndFingerprint(t *testing.T) {
+	cache := &fsdb.StorageEntity{Dir: t.TempDir(), FileExtension: ".md"}
+	fingerprint := "model-a"
+	var executions atomic.Int32
+	renderer := &Renderer{
+		Cache:       cache,
+		DryRun:      true,
+		Fingerprint: func(block *Block) (string, error) { return fingerprint, nil },
+		Execute: func(block *Block, input string) (string, error) {
+			executions.Add(1)
+			return "output " + fingerprint, nil
+		},
+	}
+
+	content := "```fabric pattern=summarize\ntext\n```\n"
+	rendered, err := renderer.Render(content)
+	assert.NoError(t, err)
+	assert.NotContains(t, rendered, "hash=")
+	names, _ := cache.GetNames()
+	assert.Empty(t, names)
+
+	// the dry run neither cached the output nor stored its hash
+	renderer.DryRun = false
+	rendered, err = renderer.Render(content)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), executions.Load())
+
+	// a changed fingerprint executes the block again
+	fingerprint = "model-b"
+	rendered, err = renderer.Render(rendered)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), executions.Load())
+	assert.Contains(t, rendered, "\noutput model-b\n")
+}
@@ -0,0 +1,223 @@
+package render
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+)
+
+// Renderer executes the fabric blocks of a document, blocks without dependencies between them run in parallel
+type Renderer struct {
+	// Execute runs the pattern of the block on the resolved input
+	Execute func(block *Block, input string) (string, error)
+	// LoadURL fetches the content of URLs referenced as block inputs
+	LoadURL func(url string) (string, error)
+	// Fingerprint describes what else the output of the block depends on, e.g. the resolved model and the content of
+	// the pattern and the context, it is part of the hash and may be nil
+	Fingerprint func(block *Block) (string, error)
+	// Cache keeps outputs by the hash of the block, it may be nil
+	Cache *fsdb.StorageEntity
+
+	Dir   string
+	Jobs  int
+	Force bool
+	// DryRun executes all blocks without the cache and without writing the document
+	DryRun bool
+}
+
+// RenderFile renders the document in place
+func (o *Renderer) RenderFile(path string) (err error) {
+	var content []byte
+	if content, err = os.ReadFile(path); err != nil {
+		return
+	}
+
+	if o.Dir == "" {
+		o.Dir = filepath.Dir(path)
+	}
+
+	var rendered string
+	rendered, err = o.Render(string(content))
+	if !o.DryRun && rendered != string(content) {
+		if writeErr := os.WriteFile(path, []byte(rendered), 0644); writeErr != nil {
+			err = errors.Join(err, writeErr)
+		}
+	}
+	return
+}
+
+// Render executes the blocks of the document and returns it with the outputs. Blocks whose input did not change since
+// the last rendering keep their output. On errors the outputs of the successful blocks are still returned.
+func (o *Renderer) Render(content string) (ret string, err error) {
+	var doc *Document
+	if doc, err = Parse(content); err != nil {
+		ret = content
+		return
+	}
+
+	jobs := o.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	semaphore := make(chan struct{}, jobs)
+
+	done := map[*Block]chan struct{}{}
+	failed := map[*Block]bool{}
+	for _, block := range doc.Blocks {
+		done[block] = make(chan struct{})
+	}
+
+	var mutex sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	for _, block := range doc.Blocks {
+		wg.Add(1)
+		go func(block *Block) {
+			defer wg.Done()
+			defer close(done[block])
+
+			dependencyFailed := false
+			for _, dependency := range block.DependsOn() {
+				dependencyBlock := doc.BlockById(dependency)
+				<-done[dependencyBlock]
+				mutex.Lock()
+				dependencyFailed = dependencyFailed || failed[dependencyBlock]
+				mutex.Unlock()
+			}
+
+			var blockErr error
+			if dependencyFailed {
+				blockErr = fmt.Errorf("input block failed")
+			} else {
+				semaphore <- struct{}{}
+				blockErr = o.renderBlock(doc, block)
+				<-semaphore
+			}
+
+			if blockErr != nil {
+				mutex.Lock()
+				failed[block] = true
+				errs = append(errs, fmt.Errorf("fabric block %s: %w", block.Name(), blockErr))
+				mutex.Unlock()
+			}
+		}(block)
+	}
+	wg.Wait()
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	err = errors.Join(errs...)
+	ret = doc.String()
+	return
+}
+
+func (o *Renderer) renderBlock(doc *Document, block *Block) (err error) {
+	var input string
+	if input, err = o.resolveInput(doc, block); err != nil {
+		return
+	}
+
+	if o.DryRun {
+		var output string
+		if output, err = o.Execute(block, input); err == nil {
+			block.Output = strings.TrimSpace(output)
+		}
+		return
+	}
+
+	var hash string
+	if hash, err = o.hash(block, input); err != nil {
+		return
+	}
+	if !o.Force && block.OutputHash == hash {
+		return
+	}
+
+	if !o.Force && o.Cache != nil && o.Cache.Exists(hash) {
+		var cached []byte
+		if cached, err = o.Cache.Load(hash); err == nil {
+			block.Output, block.OutputHash = string(cached), hash
+			return
+		}
+	}
+
+	var output string
+	if output, err = o.Execute(block, input); err != nil {
+		return
+	}
+	block.Output, block.OutputHash = strings.TrimSpace(output), hash
+
+	if o.Cache != nil {
+		err = o.Cache.Save(hash, []byte(block.Output))
+	}
+	return
+}
+
+// resolveInput joins the block body and the referenced inputs
+func (o *Renderer) resolveInput(doc *Document, block *Block) (ret string, err error) {
+	var parts []string
+	for _, input := range block.Inputs() {
+		var part string
+		if id, isBlock := strings.CutPrefix(input, InputBlockPrefix); isBlock {
+			part = doc.BlockById(id).Output
+		} else if file, isFile := strings.CutPrefix(input, InputFilePrefix); isFile {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(o.Dir, file)
+			}
+			var content []byte
+			if content, err = os.ReadFile(file); err != nil {
+				return
+			}
+			part = string(content)
+		} else if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+			if o.LoadURL == nil {
+				err = fmt.Errorf("can't load URL input %s", input)
+				return
+			}
+			if part, err = o.LoadURL(input); err != nil {
+				return
+			}
+		} else {
+			err = fmt.Errorf("unknown input reference %s, use block:<id>, file:<path> or a URL", input)
+			return
+		}
+		parts = append(parts, strings.TrimSpace(part))
+	}
+
+	if body := strings.TrimSpace(block.Body); body != "" {
+		parts = append(parts, body)
+	}
+	ret = strings.Join(parts, "\n\n")
+	return
+}
+
+// hash identifies a block execution by its attributes, its fingerprint and its resolved input
+func (o *Renderer) hash(block *Block, input string) (ret string, err error) {
+	keys := make([]string, 0, len(block.Attributes))
+	for key := range block.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hasher := sha256.New()
+	for _, key := range keys {
+		hasher.Write([]byte(fmt.Sprintf("%s=%s\n", key, block.Attributes[key])))
+	}
+	if o.Fingerprint != nil {
+		var fingerprint string
+		if fingerprint, err = o.Fingerprint(block); err != nil {
+			return
+		}
+		hasher.Write([]byte(fingerprint + "\n"))
+	}
+	hasher.Write([]byte(input))
+	ret = hex.EncodeToString(hasher.Sum(nil))[:16]
+	return
+}
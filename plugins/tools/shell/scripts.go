@@ -0,0 +1,102 @@
+package shell
+
+const bashScript = `# fabric shell integration for bash, add to ~/.bashrc: eval "$(fabric shell-init bash)"
+#   Alt-g  replace the command line, a description of what to do, with a generated command
+#   Alt-e  explain the command on the command line
+# The command line is never executed, review it and press Enter yourself.
+# FABRIC_SHELL_MODEL chooses the model, empty for a small model of the default vendor.
+
+if [ -z "$FABRIC_SHELL_MODEL" ]; then
+    FABRIC_SHELL_MODEL={{model}}
+fi
+
+_fabric_shell_generate() {
+    [ -z "$READLINE_LINE" ] && return
+    local command
+    command=$(history 20 | sed 's/^ *[0-9]* *//' |
+        {{fabric}} shell-command --shell=bash --model="$FABRIC_SHELL_MODEL" -- "$READLINE_LINE") || return
+    if [ -n "$command" ]; then
+        READLINE_LINE="$command"
+        READLINE_POINT=${#READLINE_LINE}
+    fi
+}
+
+_fabric_shell_explain() {
+    [ -z "$READLINE_LINE" ] && return
+    {{fabric}} shell-command --shell=bash --explain --model="$FABRIC_SHELL_MODEL" -- "$READLINE_LINE" </dev/null
+}
+
+bind -x '"\eg": _fabric_shell_generate'
+bind -x '"\ee": _fabric_shell_explain'
+`
+
+const zshScript = `# fabric shell integration for zsh, add to ~/.zshrc: eval "$(fabric shell-init zsh)"
+#   Alt-g  replace the command line, a description of what to do, with a generated command
+#   Alt-e  explain the command on the command line
+# The command line is never executed, review it and press Enter yourself.
+# FABRIC_SHELL_MODEL chooses the model, empty for a small model of the default vendor.
+
+if [[ -z "$FABRIC_SHELL_MODEL" ]]; then
+    FABRIC_SHELL_MODEL={{model}}
+fi
+
+_fabric_shell_generate() {
+    [[ -z "$BUFFER" ]] && return
+    local command
+    # errors of fabric are printed above the prompt
+    zle -I
+    command=$(fc -ln -20 2>/dev/null |
+        {{fabric}} shell-command --shell=zsh --model="$FABRIC_SHELL_MODEL" -- "$BUFFER") || return
+    if [[ -n "$command" ]]; then
+        BUFFER="$command"
+        CURSOR=${#BUFFER}
+    fi
+}
+
+_fabric_shell_explain() {
+    [[ -z "$BUFFER" ]] && return
+    zle -I
+    {{fabric}} shell-command --shell=zsh --explain --model="$FABRIC_SHELL_MODEL" -- "$BUFFER" </dev/null
+}
+
+zle -N _fabric_shell_generate
+zle -N _fabric_shell_explain
+bindkey '^[g' _fabric_shell_generate
+bindkey '^[e' _fabric_shell_explain
+`
+
+const fishScript = `# fabric shell integration for fish, add to ~/.config/fish/config.fish: fabric shell-init fish | source
+#   Alt-g  replace the command line, a description of what to do, with a generated command
+#   Alt-e  explain the command on the command line
+# The command line is never executed, review it and press Enter yourself.
+# FABRIC_SHELL_MODEL chooses the model, empty for a small model of the default vendor.
+
+if test -z "$FABRIC_SHELL_MODEL"
+    set -g FABRIC_SHELL_MODEL {{model}}
+end
+
+function _fabric_shell_generate
+    set -l line (commandline)
+    test -z "$line"; and return
+    echo
+    set -l command (history --max=20 --reverse |
+        {{fabric}} shell-command --shell=fish --model="$FABRIC_SHELL_MODEL" -- "$line" | string collect)
+    # string collect fails without output, e.g. if fabric failed
+    and begin
+        commandline -r -- $command
+        commandline -f end-of-line
+    end
+    commandline -f repaint
+end
+
+function _fabric_shell_explain
+    set -l line (commandline)
+    test -z "$line"; and return
+    echo
+    {{fabric}} shell-command --shell=fish --explain --model="$FABRIC_SHELL_MODEL" -- "$line" </dev/null
+    commandline -f repaint
+end
+
+bind \eg _fabric_shell_generate
+bind \ee _fabric_shell_explain
+`
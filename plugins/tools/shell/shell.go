@@ -0,0 +1,130 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// The patterns of the shell integration, create_shell_command builds on create_command with the context of the shell
+const (
+	GeneratePattern = "create_shell_command"
+	ExplainPattern  = "explain_shell_command"
+)
+
+var Shells = []string{"bash", "zsh", "fish"}
+
+// cheapModels are small models of the vendors, the widgets are used often and only need short answers. Vendors that
+// are not listed use their default model.
+var cheapModels = map[string]string{
+	"OpenAI":     "gpt-4o-mini",
+	"Azure":      "gpt-4o-mini",
+	"Anthropic":  "claude-3-haiku-20240307",
+	"Gemini":     "gemini-1.5-flash",
+	"Groq":       "llama-3.1-8b-instant",
+	"Mistral":    "open-mistral-nemo",
+	"OpenRouter": "openai/gpt-4o-mini",
+}
+
+// CheapModel returns a small model of the vendor, empty if there is none known
+func CheapModel(vendor string) string {
+	return cheapModels[vendor]
+}
+
+// Script returns the integration of the shell. It binds Alt-g to replace the command line, a description of a command,
+// with the generated command and Alt-e to explain the command line. Nothing is executed.
+func Script(shell string, fabricPath string, model string) (ret string, err error) {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript
+	case "zsh":
+		script = zshScript
+	case "fish":
+		script = fishScript
+	default:
+		err = fmt.Errorf("unsupported shell %s, use one of %s", shell, strings.Join(Shells, ", "))
+		return
+	}
+
+	quote := quotePosix
+	if shell == "fish" {
+		quote = quoteFish
+	}
+	ret = strings.NewReplacer("{{fabric}}", quote(fabricPath), "{{model}}", quote(model)).Replace(script)
+	return
+}
+
+// BuildGenerateInput returns the input of the create_shell_command pattern, the description on the first line and the
+// context on the following ones
+func BuildGenerateInput(description string, shell string, history string) string {
+	var ret strings.Builder
+	ret.WriteString(singleLine(description))
+	ret.WriteString("\n\n")
+	ret.WriteString(buildContext(shell))
+	if workingDir, err := os.Getwd(); err == nil {
+		ret.WriteString(fmt.Sprintf("Working directory: %s\n", workingDir))
+	}
+	if history = strings.TrimSpace(history); history != "" {
+		ret.WriteString("Recent commands:\n")
+		ret.WriteString(history)
+		ret.WriteString("\n")
+	}
+	return ret.String()
+}
+
+// BuildExplainInput returns the input of the explain_shell_command pattern
+func BuildExplainInput(command string, shell string) string {
+	return strings.TrimSpace(command) + "\n\n" + buildContext(shell)
+}
+
+func buildContext(shell string) string {
+	return fmt.Sprintf("Shell: %s\nOperating system: %s\n", shell, describeOS())
+}
+
+// CleanCommand removes the formatting models add despite the instructions, e.g. code blocks
+func CleanCommand(output string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	ret := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(ret) > 1 && strings.HasPrefix(ret, "`") && strings.HasSuffix(ret, "`") && !strings.Contains(ret, "\n") {
+		ret = strings.Trim(ret, "`")
+	}
+	return strings.TrimPrefix(ret, "$ ")
+}
+
+func describeOS() (ret string) {
+	ret = runtime.GOOS
+	switch runtime.GOOS {
+	case "darwin":
+		ret = "macOS"
+	case "linux":
+		if content, err := os.ReadFile("/etc/os-release"); err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				if value, found := strings.CutPrefix(line, "PRETTY_NAME="); found {
+					ret = strings.Trim(value, `"'`)
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+func singleLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func quotePosix(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func quoteFish(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
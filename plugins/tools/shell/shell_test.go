@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScript(t *testing.T) {
+	for _, shell := range Shells {
+		script, err := Script(shell, "/opt/it's/fabric", "gpt-4o-mini")
+		require.NoError(t, err, shell)
+		assert.NotContains(t, script, "{{", shell)
+		assert.Contains(t, script, "shell-command --shell="+shell, shell)
+		assert.Contains(t, script, "'gpt-4o-mini'", shell)
+		// the widgets only change the command line, they never accept it
+		assert.NotContains(t, script, "accept-line", shell)
+		assert.NotContains(t, script, "-f execute", shell)
+	}
+
+	script, _ := Script("bash", "/opt/it's/fabric", "")
+	assert.Contains(t, script, `'/opt/it'\''s/fabric' shell-command`)
+	script, _ = Script("fish", "/opt/it's/fabric", "")
+	assert.Contains(t, script, `'/opt/it\'s/fabric' shell-command`)
+
+	_, err := Script("tcsh", "fabric", "")
+	assert.Error(t, err)
+}
+
+func TestCleanCommand(t *testing.T) {
+	tests := map[string]string{
+		"ls -la\n":                         "ls -la",
+		"```bash\nfind . -size +100M\n```": "find . -size +100M",
+		"`du -sh *`":                       "du -sh *",
+		"$ echo 'a`b'":                     "echo 'a`b'",
+		"for f in *; do\n  echo \"$f\"\ndone\n\n": "for f in *; do\n  echo \"$f\"\ndone",
+	}
+	for output, expected := range tests {
+		assert.Equal(t, expected, CleanCommand(output), output)
+	}
+}
+
+func TestBuildGenerateInput(t *testing.T) {
+	input := BuildGenerateInput("find the\nbiggest files", "zsh", "ls\ncd src\n")
+	lines := strings.Split(input, "\n")
+	assert.Equal(t, "find the biggest files", lines[0])
+	assert.Contains(t, input, "Shell: zsh\n")
+	assert.Contains(t, input, "Recent commands:\nls\ncd src\n")
+
+	assert.NotContains(t, BuildGenerateInput("list files", "bash", ""), "Recent commands")
+}
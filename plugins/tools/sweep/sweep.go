@@ -0,0 +1,255 @@
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The parameters a request can be swept over
+const (
+	ParamModel            = "model"
+	ParamTemperature      = "temperature"
+	ParamTopP             = "top_p"
+	ParamPresencePenalty  = "presence_penalty"
+	ParamFrequencyPenalty = "frequency_penalty"
+	ParamSeed             = "seed"
+)
+
+var Params = []string{ParamModel, ParamTemperature, ParamTopP, ParamPresencePenalty, ParamFrequencyPenalty, ParamSeed}
+
+// IndexFile is the file of a sweep directory with all runs and their scores
+const IndexFile = "sweep.json"
+
+var (
+	scoreRegex    = regexp.MustCompile(`(?i)score\W*?(\d+(?:\.\d+)?)`)
+	fileNameRegex = regexp.MustCompile(`[^A-Za-z0-9._=-]+`)
+)
+
+// Axis is a parameter with the values it is swept over
+type Axis struct {
+	Param  string   `json:"param"`
+	Values []string `json:"values"`
+}
+
+// ParseAxes parses the values of --sweep, e.g. temperature=0,0.5,1. The values of a parameter that is given several
+// times are joined.
+func ParseAxes(specs []string) (ret []*Axis, err error) {
+	axes := map[string]*Axis{}
+	for _, spec := range specs {
+		param, values, found := strings.Cut(spec, "=")
+		param = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(param)), "-", "_")
+		if param == "topp" {
+			param = ParamTopP
+		}
+		if !found || !slices.Contains(Params, param) {
+			err = fmt.Errorf("invalid sweep %s, use <parameter>=<value>,<value> with one of %s",
+				spec, strings.Join(Params, ", "))
+			return
+		}
+
+		axis := axes[param]
+		if axis == nil {
+			axis = &Axis{Param: param}
+			axes[param] = axis
+			ret = append(ret, axis)
+		}
+		for _, value := range strings.Split(values, ",") {
+			if value = strings.TrimSpace(value); value == "" || slices.Contains(axis.Values, value) {
+				continue
+			}
+			if err = checkValue(param, value); err != nil {
+				return
+			}
+			axis.Values = append(axis.Values, value)
+		}
+		if len(axis.Values) == 0 {
+			err = fmt.Errorf("the sweep of %s has no values", param)
+			return
+		}
+	}
+	return
+}
+
+func checkValue(param string, value string) (err error) {
+	switch param {
+	case ParamModel:
+	case ParamSeed:
+		if _, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("invalid %s %s, use an integer", param, value)
+		}
+	default:
+		if _, err = strconv.ParseFloat(value, 64); err != nil {
+			err = fmt.Errorf("invalid %s %s, use a number", param, value)
+		}
+	}
+	return
+}
+
+// Run is one combination of the values of the axes
+type Run struct {
+	Label  string            `json:"label"`
+	Values map[string]string `json:"values"`
+	Output string            `json:"output,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	// Score is the score of the judge, nil if the run was not judged
+	Score     *float64      `json:"score,omitempty"`
+	Judgement string        `json:"judgement,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// Float returns the value of the numeric parameter of the run, ok is false if it is not swept
+func (o *Run) Float(param string) (ret float64, ok bool) {
+	var value string
+	if value, ok = o.Values[param]; ok {
+		ret, _ = strconv.ParseFloat(value, 64)
+	}
+	return
+}
+
+// Combinations returns the runs of the cartesian product of the values, the values of the last axis change fastest
+func Combinations(axes []*Axis) (ret []*Run) {
+	ret = []*Run{{Values: map[string]string{}}}
+	for _, axis := range axes {
+		var next []*Run
+		for _, run := range ret {
+			for _, value := range axis.Values {
+				values := make(map[string]string, len(run.Values)+1)
+				for param, existing := range run.Values {
+					values[param] = existing
+				}
+				values[axis.Param] = value
+				next = append(next, &Run{Values: values})
+			}
+		}
+		ret = next
+	}
+
+	for _, run := range ret {
+		var labels []string
+		for _, axis := range axes {
+			labels = append(labels, fmt.Sprintf("%s=%s", axis.Param, run.Values[axis.Param]))
+		}
+		run.Label = strings.Join(labels, " ")
+	}
+	return
+}
+
+// Execute runs at most jobs runs at the same time. The errors of the runs are kept in the runs, a failed run doesn't
+// stop the others. The judge is optional, it scores the output of a run.
+func Execute(runs []*Run, jobs int, execute func(run *Run) (string, error),
+	judge func(run *Run) (float64, string, error)) {
+
+	if jobs < 1 {
+		jobs = 1
+	}
+	semaphore := make(chan struct{}, jobs)
+
+	var wg sync.WaitGroup
+	for _, run := range runs {
+		wg.Add(1)
+		go func(run *Run) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			output, err := execute(run)
+			run.Duration = time.Since(start)
+			if err != nil {
+				run.Error = err.Error()
+				return
+			}
+			run.Output = output
+
+			if judge == nil {
+				return
+			}
+			score, judgement, judgeErr := judge(run)
+			if judgeErr != nil {
+				run.Error = fmt.Sprintf("judge: %v", judgeErr)
+				return
+			}
+			run.Score, run.Judgement = &score, judgement
+		}(run)
+	}
+	wg.Wait()
+}
+
+// BuildJudgeInput returns the input of the judge pattern, the prompt with the input and the output of a run
+func BuildJudgeInput(prompt string, input string, output string) string {
+	return fmt.Sprintf("PROMPT:\n%s\n\nINPUT:\n%s\n\nOUTPUT:\n%s\n", strings.TrimSpace(prompt),
+		strings.TrimSpace(input), strings.TrimSpace(output))
+}
+
+// ParseScore returns the last score of the output of the judge, e.g. of "FINAL SCORE: 70.3"
+func ParseScore(output string) (ret float64, err error) {
+	matches := scoreRegex.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		err = fmt.Errorf("could not read the score of the judge: %s", output)
+		return
+	}
+	ret, err = strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	return
+}
+
+// Result is the labeled grid of a sweep
+type Result struct {
+	Pattern string    `json:"pattern,omitempty"`
+	Judge   string    `json:"judge,omitempty"`
+	Created time.Time `json:"created"`
+	Axes    []*Axis   `json:"axes"`
+	Runs    []*Run    `json:"runs"`
+}
+
+// Best returns the run with the highest score, nil if no run was scored
+func (o *Result) Best() (ret *Run) {
+	for _, run := range o.Runs {
+		if run.Score != nil && (ret == nil || *run.Score > *ret.Score) {
+			ret = run
+		}
+	}
+	return
+}
+
+// Save writes the result to a JSON file if the path ends with .json, otherwise to a directory with one Markdown file
+// per run and the index file
+func (o *Result) Save(path string) (err error) {
+	var content []byte
+	if content, err = json.MarshalIndent(o, "", "  "); err != nil {
+		return
+	}
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		err = os.WriteFile(path, append(content, '\n'), 0644)
+		return
+	}
+
+	if err = os.MkdirAll(path, os.ModePerm); err != nil {
+		return
+	}
+	for i, run := range o.Runs {
+		text := run.Output
+		if run.Error != "" {
+			text = fmt.Sprintf("error: %s\n\n%s", run.Error, text)
+		}
+		if err = os.WriteFile(filepath.Join(path, o.FileName(i)), []byte(text), 0644); err != nil {
+			return
+		}
+	}
+	err = os.WriteFile(filepath.Join(path, IndexFile), append(content, '\n'), 0644)
+	return
+}
+
+// FileName returns the name of the file of the run in a sweep directory, e.g. 01_temperature=0.5_top_p=0.9.md
+func (o *Result) FileName(index int) string {
+	width := len(strconv.Itoa(len(o.Runs)))
+	label := fileNameRegex.ReplaceAllString(strings.ReplaceAll(o.Runs[index].Label, " ", "_"), "-")
+	return fmt.Sprintf("%0*d_%s.md", width, index+1, label)
+}
@@ -0,0 +1,78 @@
+package sweep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAxes(t *testing.T) {
+	axes, err := ParseAxes([]string{"temperature=0,0.5,1", "top-p=0.9, 1.0", "temperature=1,1.5"})
+	assert.NoError(t, err)
+	assert.Len(t, axes, 2)
+	assert.Equal(t, []string{"0", "0.5", "1", "1.5"}, axes[0].Values)
+	assert.Equal(t, ParamTopP, axes[1].Param)
+
+	for _, spec := range []string{"temperature", "color=red", "temperature=hot", "seed=1.5", "model="} {
+		_, err = ParseAxes([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCombinations(t *testing.T) {
+	runs := Combinations([]*Axis{
+		{Param: ParamModel, Values: []string{"a", "b"}},
+		{Param: ParamTemperature, Values: []string{"0", "1"}},
+	})
+	assert.Len(t, runs, 4)
+	assert.Equal(t, "model=a temperature=0", runs[0].Label)
+	assert.Equal(t, "model=a temperature=1", runs[1].Label)
+	assert.Equal(t, "model=b temperature=0", runs[2].Label)
+
+	temperature, ok := runs[1].Float(ParamTemperature)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, temperature)
+	_, ok = runs[1].Float(ParamTopP)
+	assert.False(t, ok)
+}
+
+func TestExecute(t *testing.T) {
+	runs := Combinations([]*Axis{{Param: ParamTemperature, Values: []string{"0", "0.5", "1"}}})
+	Execute(runs, 2,
+		func(run *Run) (string, error) {
+			if run.Values[ParamTemperature] == "1" {
+				return "", fmt.Errorf("too hot")
+			}
+			return "output " + run.Values[ParamTemperature], nil
+		},
+		func(run *Run) (float64, string, error) {
+			score, err := ParseScore("Construction: 8\nFINAL SCORE: " + run.Values[ParamTemperature] + "5")
+			return score, "judged", err
+		})
+
+	assert.Equal(t, "output 0", runs[0].Output)
+	assert.Equal(t, 5.0, *runs[0].Score)
+	assert.Equal(t, 0.55, *runs[1].Score)
+	assert.Equal(t, "too hot", runs[2].Error)
+	assert.Nil(t, runs[2].Score)
+
+	result := &Result{Axes: []*Axis{{Param: ParamTemperature}}, Runs: runs}
+	assert.Equal(t, runs[0], result.Best())
+
+	dir := filepath.Join(t.TempDir(), "sweep")
+	assert.NoError(t, result.Save(dir))
+	content, err := os.ReadFile(filepath.Join(dir, "1_temperature=0.md"))
+	assert.NoError(t, err)
+	assert.Equal(t, "output 0", string(content))
+	assert.FileExists(t, filepath.Join(dir, IndexFile))
+
+	file := filepath.Join(t.TempDir(), "sweep.json")
+	assert.NoError(t, result.Save(file))
+	assert.FileExists(t, file)
+
+	_, err = ParseScore("no rating")
+	assert.Error(t, err)
+}
@@ -0,0 +1,245 @@
+package variables
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProvidersFile is the file of the configuration directory with the shell commands of the custom variables, e.g.
+// {"weather": "curl -s wttr.in?format=3"}
+const ProvidersFile = "variables.json"
+
+// providerTimeout limits how long the command of a custom variable runs
+const providerTimeout = 10 * time.Second
+
+var (
+	variableRegex = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_.-]*)\s*\}\}`)
+	urlRegex      = regexp.MustCompile(`https?://\S+`)
+	titleRegex    = regexp.MustCompile(`(?m)^(?:Title:\s*(.+)|#\s+(.+))$`)
+	htmlTitle     = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// Names are the built-in variables, they are referenced like {{date}}
+var Names = []string{"date", "time", "datetime", "timezone", "weekday", "user", "hostname", "os", "cwd", "git_branch",
+	"git_repo", "input_source", "input_url", "input_title", "input_length", "input_words", "model"}
+
+// Request is what the variables of the request are taken from
+type Request struct {
+	Model       string
+	Input       string
+	InputSource string
+	// Values are used instead of resolving the variables, e.g. the ones recorded with a request to run it again
+	Values map[string]string
+}
+
+// Resolver replaces the built-in and the custom variables in patterns and contexts when a request is sent. Only the
+// referenced variables are resolved, so e.g. git is only run if the git branch is used.
+type Resolver struct {
+	// ProvidersPath is the JSON file with the shell commands of the custom variables, it is read on first use
+	ProvidersPath string
+	// Now returns the current time, it can be replaced in tests
+	Now func() time.Time
+
+	providers     map[string]string
+	providersErr  error
+	providersOnce sync.Once
+}
+
+func NewResolver(providersPath string) *Resolver {
+	return &Resolver{ProvidersPath: providersPath, Now: time.Now}
+}
+
+// Resolve returns the values of the variables referenced in the contents, the unknown variables and the ones that
+// can't be resolved are missing
+func (o *Resolver) Resolve(request *Request, contents ...string) (ret map[string]string) {
+	ret = map[string]string{}
+	resolved := map[string]bool{}
+	for _, content := range contents {
+		for _, match := range variableRegex.FindAllStringSubmatch(content, -1) {
+			name := match[1]
+			if resolved[name] {
+				continue
+			}
+			resolved[name] = true
+			if value, ok := request.Values[name]; ok {
+				ret[name] = value
+			} else if value := o.resolve(name, request); value != nil {
+				ret[name] = *value
+			}
+		}
+	}
+	return
+}
+
+// Apply replaces the variables in the contents, the variables are resolved once for all of them. Unknown variables and
+// the ones that can't be resolved are left as they are.
+func (o *Resolver) Apply(request *Request, contents ...string) (ret []string) {
+	values := o.Resolve(request, contents...)
+	for _, content := range contents {
+		ret = append(ret, variableRegex.ReplaceAllStringFunc(content, func(reference string) string {
+			if value, ok := values[variableRegex.FindStringSubmatch(reference)[1]]; ok {
+				return value
+			}
+			return reference
+		}))
+	}
+	return
+}
+
+// resolve returns the value of the variable, nil if it is unknown or can't be resolved
+func (o *Resolver) resolve(name string, request *Request) *string {
+	if value, ok := o.resolveBuiltIn(name, request); ok {
+		return &value
+	}
+
+	providers, err := o.getProviders()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	command, ok := providers[name]
+	if !ok {
+		return nil
+	}
+	value, err := runProvider(command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: variable %s: %v\n", name, err)
+		return nil
+	}
+	return &value
+}
+
+func (o *Resolver) resolveBuiltIn(name string, request *Request) (ret string, ok bool) {
+	now := o.now()
+	ok = true
+	switch name {
+	case "date":
+		ret = now.Format("2006-01-02")
+	case "time":
+		ret = now.Format("15:04:05")
+	case "datetime":
+		ret = now.Format(time.RFC3339)
+	case "timezone":
+		ret = now.Format("MST -07:00")
+	case "weekday":
+		ret = now.Weekday().String()
+	case "user":
+		ret = currentUser()
+	case "hostname":
+		ret, _ = os.Hostname()
+	case "os":
+		ret = runtime.GOOS + "/" + runtime.GOARCH
+	case "cwd":
+		ret, _ = os.Getwd()
+	case "git_branch":
+		ret, ok = runGit("rev-parse", "--abbrev-ref", "HEAD")
+	case "git_repo":
+		if ret, ok = runGit("remote", "get-url", "origin"); !ok {
+			if ret, ok = runGit("rev-parse", "--show-toplevel"); ok {
+				ret = filepath.Base(ret)
+			}
+		}
+	case "input_source":
+		ret = request.InputSource
+	case "input_url":
+		if ret = urlRegex.FindString(request.InputSource); ret == "" {
+			ret = urlRegex.FindString(strings.TrimSpace(request.Input))
+		}
+	case "input_title":
+		ret = Title(request.Input)
+	case "input_length":
+		ret = strconv.Itoa(len([]rune(request.Input)))
+	case "input_words":
+		ret = strconv.Itoa(len(strings.Fields(request.Input)))
+	case "model":
+		ret = request.Model
+	default:
+		ok = false
+	}
+	return
+}
+
+func (o *Resolver) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+func (o *Resolver) getProviders() (map[string]string, error) {
+	o.providersOnce.Do(func() {
+		if o.ProvidersPath == "" {
+			return
+		}
+		content, err := os.ReadFile(o.ProvidersPath)
+		if err != nil {
+			// the custom variables are optional
+			return
+		}
+		if err = json.Unmarshal(content, &o.providers); err != nil {
+			o.providersErr = fmt.Errorf("invalid custom variables in %s: %v", o.ProvidersPath, err)
+		}
+	})
+	return o.providers, o.providersErr
+}
+
+// Title returns the title of the input: the one of a Jina AI page, the first Markdown heading or the HTML title
+func Title(input string) (ret string) {
+	if match := titleRegex.FindStringSubmatch(input); match != nil {
+		ret = strings.TrimSpace(match[1] + match[2])
+	} else if match = htmlTitle.FindStringSubmatch(input); match != nil {
+		ret = strings.TrimSpace(match[1])
+	}
+	return
+}
+
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+func runGit(args ...string) (ret string, ok bool) {
+	output, err := exec.Command("git", args...).Output()
+	if ret = strings.TrimSpace(string(output)); err == nil && ret != "" {
+		ok = true
+	}
+	return
+}
+
+func runProvider(command string) (ret string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		err = fmt.Errorf("%s failed: %v %s", command, err, strings.TrimSpace(stderr.String()))
+		return
+	}
+	ret = strings.TrimSpace(stdout.String())
+	return
+}
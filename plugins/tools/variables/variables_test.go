@@ -0,0 +1,71 @@
+package variables
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApply(t *testing.T) {
+	providers := filepath.Join(t.TempDir(), ProvidersFile)
+	if err := os.WriteFile(providers, []byte(`{"greeting": "echo hello", "broken": "exit 3"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver(providers)
+	resolver.Now = func() time.Time { return time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC) }
+
+	request := &Request{Model: "llama3", InputSource: "https://example.com/post", Input: "# Hello world\n\nsome text"}
+	got := resolver.Apply(request,
+		"Today is {{date}} at {{ time }}, {{weekday}}.",
+		"{{greeting}} {{model}}: {{input_title}} ({{input_words}} words from {{input_url}})",
+		"{{broken}} {{unknown}} {{#role}}")
+	want := []string{
+		"Today is 2024-03-09 at 14:05:00, Saturday.",
+		"hello llama3: Hello world (5 words from https://example.com/post)",
+		"{{broken}} {{unknown}} {{#role}}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+}
+
+func TestResolveWithValues(t *testing.T) {
+	resolver := NewResolver("")
+	request := &Request{Model: "llama3", Values: map[string]string{"date": "2024-03-09", "custom": "recorded"}}
+	got := resolver.Resolve(request, "{{date}} {{model}}", "{{custom}} {{unknown}}")
+	want := map[string]string{"date": "2024-03-09", "model": "llama3", "custom": "recorded"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyWithoutProviders(t *testing.T) {
+	resolver := NewResolver(filepath.Join(t.TempDir(), ProvidersFile))
+	got := resolver.Apply(&Request{}, "{{os}} {{custom}}")
+	if strings.Contains(got[0], "{{os}}") || !strings.HasSuffix(got[0], " {{custom}}") {
+		t.Errorf("Apply() = %q", got[0])
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"jina", "Title: The Page\n\nURL Source: https://example.com", "The Page"},
+		{"markdown", "intro\n# The Heading\ntext", "The Heading"},
+		{"html", "<html><head><title> The HTML </title></head></html>", "The HTML"},
+		{"none", "just text", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Title(tt.input); got != tt.want {
+				t.Errorf("Title() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
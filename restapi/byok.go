@@ -0,0 +1,66 @@
+package restapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/ai"
+)
+
+const (
+	// ByokOff rejects requests with vendor credentials
+	ByokOff = "off"
+	// ByokAllow uses the vendor credentials of a request instead of the ones of the server
+	ByokAllow = "allow"
+	// ByokRequire uses only the vendor credentials of the requests, never the ones of the server
+	ByokRequire = "require"
+)
+
+// ByokPolicy controls the "bring your own key" headers of chat requests, X-Fabric-<Vendor>-Key and
+// X-Fabric-<Vendor>-Base-Url, e.g. X-Fabric-OpenAI-Key. The values are used for the request only, they are neither
+// saved nor logged.
+type ByokPolicy struct {
+	Mode string
+	// Vendors lists the vendors that accept request credentials, empty for all
+	Vendors []string
+}
+
+func (o *ByokPolicy) AllowsVendor(vendorName string) bool {
+	if len(o.Vendors) == 0 {
+		return true
+	}
+	for _, allowed := range o.Vendors {
+		if strings.EqualFold(strings.TrimSpace(allowed), vendorName) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCredentials reads the vendor credentials from the headers and checks them against the policy
+func (o *ByokPolicy) GetCredentials(header http.Header, vendors []ai.Vendor) (ret []*core.VendorCredentials, err error) {
+	for _, vendor := range vendors {
+		name := vendor.GetName()
+		credentials := &core.VendorCredentials{
+			Vendor:     name,
+			ApiKey:     header.Get(fmt.Sprintf("X-Fabric-%s-Key", name)),
+			ApiBaseURL: header.Get(fmt.Sprintf("X-Fabric-%s-Base-Url", name)),
+		}
+		if credentials.ApiKey == "" && credentials.ApiBaseURL == "" {
+			continue
+		}
+
+		if o.Mode != ByokAllow && o.Mode != ByokRequire {
+			err = fmt.Errorf("request credentials are not allowed by this server")
+			return
+		}
+		if !o.AllowsVendor(name) {
+			err = fmt.Errorf("request credentials are not allowed for vendor %s", name)
+			return
+		}
+		ret = append(ret, credentials)
+	}
+	return
+}
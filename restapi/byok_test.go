@@ -0,0 +1,49 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChat_Byok(t *testing.T) {
+	header := map[string]string{"X-Fabric-Test-Key": "user_key"}
+
+	router, _ := newTestRouter(t, &ByokPolicy{Mode: ByokOff})
+	recorder := doRequestWithHeader(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"}, header)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	router, _ = newTestRouter(t, &ByokPolicy{Mode: ByokAllow, Vendors: []string{"OpenAI"}})
+	recorder = doRequestWithHeader(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"}, header)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	router, _ = newTestRouter(t, &ByokPolicy{Mode: ByokAllow, Vendors: []string{"test"}})
+	recorder = doRequestWithHeader(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"}, header)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response ChatResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "answer to 1 messages with key user_key", response.Message)
+	assert.Empty(t, os.Getenv("TEST_API_KEY"))
+
+	// the key is used for the request only
+	recorder = doRequest(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"})
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "answer to 1 messages", response.Message)
+}
+
+func TestChat_ByokRequired(t *testing.T) {
+	router, _ := newTestRouter(t, &ByokPolicy{Mode: ByokRequire})
+
+	recorder := doRequest(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "credentials for vendor 'Test' are required")
+
+	recorder = doRequestWithHeader(router, http.MethodPost, "/chat",
+		ChatRequest{Model: "test-model", Message: "hello"}, map[string]string{"X-Fabric-Test-Key": "user_key"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "with key user_key")
+}
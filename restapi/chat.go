@@ -0,0 +1,109 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/gin-gonic/gin"
+)
+
+// ChatHandler defines the handler for chat requests
+type ChatHandler struct {
+	registry *core.PluginRegistry
+	byok     *ByokPolicy
+}
+
+// ChatRequest is the body of the POST /chat route
+type ChatRequest struct {
+	Vendor           string            `json:"vendor"`
+	Model            string            `json:"model"`
+	Pattern          string            `json:"pattern"`
+	Context          string            `json:"context"`
+	Session          string            `json:"session"`
+	Variables        map[string]string `json:"variables"`
+	Message          string            `json:"message"`
+	Language         string            `json:"language"`
+	Temperature      float64           `json:"temperature"`
+	TopP             float64           `json:"topP"`
+	PresencePenalty  float64           `json:"presencePenalty"`
+	FrequencyPenalty float64           `json:"frequencyPenalty"`
+	Raw              bool              `json:"raw"`
+	Seed             int               `json:"seed"`
+}
+
+// ChatResponse is the answer of the POST /chat route
+type ChatResponse struct {
+	Message string `json:"message"`
+}
+
+// NewChatHandler creates a new ChatHandler
+func NewChatHandler(r *gin.Engine, registry *core.PluginRegistry, byok *ByokPolicy) (ret *ChatHandler) {
+	if byok == nil {
+		byok = &ByokPolicy{Mode: ByokOff}
+	}
+	ret = &ChatHandler{registry: registry, byok: byok}
+	r.POST("/chat", ret.Chat)
+	return
+}
+
+// Chat handles the POST /chat route
+func (h *ChatHandler) Chat(c *gin.Context) {
+	// the same defaults as the command line
+	request := ChatRequest{Temperature: 0.7, TopP: 0.9}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	credentials, err := h.byok.GetCredentials(c.Request.Header, h.registry.VendorsAll.GetVendors())
+	if err != nil {
+		c.JSON(http.StatusForbidden, err.Error())
+		return
+	}
+
+	var chatter *core.Chatter
+	if len(credentials) > 0 || h.byok.Mode == ByokRequire {
+		chatter, err = h.registry.GetChatterWithCredentials(
+			request.Vendor, request.Model, credentials, h.byok.Mode == ByokRequire)
+	} else if request.Vendor != "" {
+		chatter, err = h.registry.GetChatterWithCredentials(request.Vendor, request.Model, nil, false)
+	} else {
+		chatter, err = h.registry.GetChatter(request.Model, false, false)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var session *fsdb.Session
+	if session, err = chatter.Send(request.BuildChatRequest(), request.BuildChatOptions()); err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, ChatResponse{Message: session.GetLastMessage().Content})
+}
+
+func (o *ChatRequest) BuildChatRequest() *common.ChatRequest {
+	return &common.ChatRequest{
+		ContextName:      o.Context,
+		SessionName:      o.Session,
+		PatternName:      o.Pattern,
+		PatternVariables: o.Variables,
+		Message:          o.Message,
+		Language:         o.Language,
+		InputSource:      "REST request",
+	}
+}
+
+func (o *ChatRequest) BuildChatOptions() *common.ChatOptions {
+	return &common.ChatOptions{
+		Temperature:      o.Temperature,
+		TopP:             o.TopP,
+		PresencePenalty:  o.PresencePenalty,
+		FrequencyPenalty: o.FrequencyPenalty,
+		Raw:              o.Raw,
+		Seed:             o.Seed,
+	}
+}
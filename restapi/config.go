@@ -0,0 +1,29 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/danielmiessler/fabric/core"
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandler defines the handler for configuration-related operations
+type ConfigHandler struct {
+	registry *core.PluginRegistry
+}
+
+// NewConfigHandler creates a new ConfigHandler
+func NewConfigHandler(r *gin.Engine, registry *core.PluginRegistry) (ret *ConfigHandler) {
+	ret = &ConfigHandler{registry: registry}
+	r.POST("/config/reload", ret.Reload)
+	return
+}
+
+// Reload handles the POST /config/reload route, it applies the changes of the .env file without a restart
+func (h *ConfigHandler) Reload(c *gin.Context) {
+	if err := h.registry.Reconfigure(); err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,35 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelsHandler defines the handler for models-related operations
+type ModelsHandler struct {
+	vendorManager *ai.VendorsManager
+}
+
+// NewModelsHandler creates a new ModelsHandler
+func NewModelsHandler(r *gin.Engine, vendorManager *ai.VendorsManager) (ret *ModelsHandler) {
+	ret = &ModelsHandler{vendorManager: vendorManager}
+	r.GET("/models/names", ret.GetNames)
+	return
+}
+
+// GetNames handles the GET /models/names route, it returns the models by vendor name
+func (h *ModelsHandler) GetNames(c *gin.Context) {
+	models, err := h.vendorManager.GetModels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ret := map[string][]string{}
+	for _, groupItems := range models.GroupsItems {
+		ret[groupItems.Group] = groupItems.Items
+	}
+	c.JSON(http.StatusOK, ret)
+}
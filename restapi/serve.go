@@ -5,8 +5,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Serve(registry *core.PluginRegistry, address string) (err error) {
-	r := gin.Default()
+func Serve(registry *core.PluginRegistry, address string, byok *ByokPolicy) (err error) {
+	r := NewRouter(registry, byok)
+
+	// Start server
+	err = r.Run(address)
+	if err != nil {
+		return err
+	}
+
+	return
+}
+
+// NewRouter creates the engine with all routes, the handlers are safe for concurrent requests
+func NewRouter(registry *core.PluginRegistry, byok *ByokPolicy) (r *gin.Engine) {
+	r = gin.Default()
 
 	// Middleware
 	r.Use(gin.Logger())
@@ -17,12 +30,8 @@ func Serve(registry *core.PluginRegistry, address string) (err error) {
 	NewPatternsHandler(r, fabricDb.Patterns)
 	NewContextsHandler(r, fabricDb.Contexts)
 	NewSessionsHandler(r, fabricDb.Sessions)
-
-	// Start server
-	err = r.Run(address)
-	if err != nil {
-		return err
-	}
-
+	NewChatHandler(r, registry, byok)
+	NewModelsHandler(r, registry.VendorManager)
+	NewConfigHandler(r, registry)
 	return
 }
@@ -0,0 +1,134 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/danielmiessler/fabric/common"
+	"github.com/danielmiessler/fabric/core"
+	"github.com/danielmiessler/fabric/plugins"
+	"github.com/danielmiessler/fabric/plugins/ai"
+	"github.com/danielmiessler/fabric/plugins/db/fsdb"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testVendor struct {
+	*plugins.PluginBase
+	ApiKey *plugins.SetupQuestion
+}
+
+func newTestVendor() (ret *testVendor) {
+	ret = &testVendor{PluginBase: &plugins.PluginBase{Name: "Test", EnvNamePrefix: "TEST_"}}
+	ret.ApiKey = ret.AddSetupQuestion("API Key", false)
+	return
+}
+
+func (o *testVendor) ListModels() ([]string, error) {
+	return []string{"test-model"}, nil
+}
+
+func (o *testVendor) SendStream(msgs []*common.Message, opts *common.ChatOptions, channel chan string) error {
+	return fmt.Errorf("not supported")
+}
+
+func (o *testVendor) Send(ctx context.Context, msgs []*common.Message, opts *common.ChatOptions) (string, error) {
+	if o.ApiKey.Value != "" {
+		return fmt.Sprintf("answer to %d messages with key %s", len(msgs), o.ApiKey.Value), nil
+	}
+	return fmt.Sprintf("answer to %d messages", len(msgs)), nil
+}
+
+func newTestRouter(t *testing.T, byok *ByokPolicy) (*gin.Engine, *core.PluginRegistry) {
+	gin.SetMode(gin.TestMode)
+
+	db := fsdb.NewDb(t.TempDir())
+	assert.NoError(t, os.WriteFile(db.EnvFilePath, []byte("DEFAULT_VENDOR=Test\nDEFAULT_MODEL=test-model\n"), 0644))
+	assert.NoError(t, db.Configure())
+
+	registry := core.NewPluginRegistry(db)
+	registry.VendorsFactory = func() []ai.Vendor {
+		return []ai.Vendor{newTestVendor()}
+	}
+	assert.NoError(t, registry.Reconfigure())
+	return NewRouter(registry, byok), registry
+}
+
+func doRequest(router *gin.Engine, method string, path string, body any) *httptest.ResponseRecorder {
+	return doRequestWithHeader(router, method, path, body, nil)
+}
+
+func doRequestWithHeader(
+	router *gin.Engine, method string, path string, body any, header map[string]string) *httptest.ResponseRecorder {
+
+	var content []byte
+	if body != nil {
+		content, _ = json.Marshal(body)
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(content))
+	for key, value := range header {
+		request.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestChat(t *testing.T) {
+	router, _ := newTestRouter(t, nil)
+
+	recorder := doRequest(router, http.MethodPost, "/chat", ChatRequest{Message: "hello"})
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var response ChatResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, "answer to 1 messages", response.Message)
+
+	recorder = doRequest(router, http.MethodPost, "/chat", ChatRequest{Model: "unknown", Message: "hello"})
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestModelsNames(t *testing.T) {
+	router, _ := newTestRouter(t, nil)
+
+	recorder := doRequest(router, http.MethodGet, "/models/names", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"Test":["test-model"]}`, recorder.Body.String())
+}
+
+// TestConcurrentRequests runs chats on the same session while the configuration is reloaded, run it with -race
+func TestConcurrentRequests(t *testing.T) {
+	router, registry := newTestRouter(t, nil)
+
+	const chats = 20
+	var wg sync.WaitGroup
+	for i := 0; i < chats; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			recorder := doRequest(router, http.MethodPost, "/chat",
+				ChatRequest{Model: "test-model", Session: "shared", Message: "hello"})
+			assert.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, doRequest(router, http.MethodGet, "/models/names", nil).Code)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, doRequest(router, http.MethodPost, "/config/reload", nil).Code)
+		}()
+	}
+	wg.Wait()
+
+	session, err := registry.Db.Sessions.Get("shared")
+	assert.NoError(t, err)
+	assert.Len(t, session.Messages, 2*chats)
+}
@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"net/http"
+
 	"github.com/danielmiessler/fabric/plugins/db/fsdb"
 	"github.com/gin-gonic/gin"
 )
@@ -15,5 +17,16 @@ type SessionsHandler struct {
 func NewSessionsHandler(r *gin.Engine, sessions *fsdb.SessionsEntity) (ret *SessionsHandler) {
 	ret = &SessionsHandler{
 		StorageHandler: NewStorageHandler[fsdb.Session](r, "sessions", sessions), sessions: sessions}
+	r.GET("/sessions/info", ret.GetInfos)
 	return ret
 }
+
+// GetInfos handles the GET /sessions/info route
+func (h *SessionsHandler) GetInfos(c *gin.Context) {
+	infos, err := h.sessions.GetInfos()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, infos)
+}